@@ -0,0 +1,317 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package main
+
+import (
+	"fmt"
+	"github.com/graniticio/granitic/v2/config"
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/ws/openapi"
+	"sort"
+	"strings"
+)
+
+const (
+	componentsField    = "components"
+	templatesField     = "templates"
+	templateField      = "compTemplate"
+	templateFieldAlias = "ct"
+	typeField          = "type"
+	typeFieldAlias     = "t"
+	handlerType        = ".WsHandler"
+	ruleRefPrefix      = "RULE:"
+)
+
+var refPrefixes = []string{"ref:", "r:", "+"}
+var confPrefixes = []string{"conf:", "c:", "$"}
+
+// describer finds handler.WsHandler components in a merged component definition file and converts them into
+// openapi.EndpointDescriptions
+type describer struct {
+	components map[string]interface{}
+	templates  map[string]interface{}
+	conf       *config.Accessor
+	log        logging.Logger
+	failed     bool
+}
+
+func newDescriber(defs map[string]interface{}, conf map[string]interface{}, log logging.Logger) *describer {
+
+	d := new(describer)
+	d.log = log
+	d.conf = &config.Accessor{JSONData: conf, FrameworkLogger: log}
+
+	d.components, _ = defs[componentsField].(map[string]interface{})
+	d.templates, _ = defs[templatesField].(map[string]interface{})
+
+	return d
+}
+
+func (d *describer) describe() []*openapi.EndpointDescription {
+
+	var names []string
+
+	for n := range d.components {
+		names = append(names, n)
+	}
+
+	sort.Strings(names)
+
+	var descriptions []*openapi.EndpointDescription
+
+	for _, n := range names {
+
+		c := d.component(d.components[n])
+
+		if c == nil || !strings.HasSuffix(d.stringField(c, typeField), handlerType) {
+			continue
+		}
+
+		d.log.LogDebugf("Describing handler %s", n)
+
+		if e := d.describeHandler(n, c); e != nil {
+			descriptions = append(descriptions, e)
+		}
+	}
+
+	return descriptions
+}
+
+func (d *describer) describeHandler(name string, c map[string]interface{}) *openapi.EndpointDescription {
+
+	e := new(openapi.EndpointDescription)
+	e.Name = name
+	e.HTTPMethod = d.stringField(c, "HTTPMethod")
+	e.PathPattern = d.stringField(c, "PathPattern")
+	e.PathTemplate = d.stringField(c, "PathTemplate")
+
+	if e.HTTPMethod == "" || (e.PathPattern == "" && e.PathTemplate == "") {
+		d.fail("Handler %s must have an HTTPMethod and a PathPattern or PathTemplate", name)
+		return nil
+	}
+
+	if !d.boolField(c, "DisablePathParsing") {
+		e.PathParams = d.stringsField(c, "BindPathParams")
+
+		if pt, err := httpendpoint.ParsePathTemplate(e.PathTemplate); err == nil && len(e.PathParams) == 0 {
+			// Parameters are bound to fields with the same name
+			e.PathParams = pt.Params
+		}
+	}
+
+	if !d.boolField(c, "DisableQueryParsing") {
+		e.QueryParams = d.mapField(c, "FieldQueryParam")
+	}
+
+	e.HeaderParams = d.mapField(c, "FieldHeader")
+	e.CookieParams = d.mapField(c, "FieldCookie")
+
+	e.RequireAuthentication = d.boolField(c, "RequireAuthentication")
+	e.AccessChecked = d.value(c["AccessChecker"]) != nil
+
+	if v := d.component(d.value(c["AutoValidator"])); v != nil {
+		e.Rules = d.rules(name, v)
+	}
+
+	return e
+}
+
+// rules extracts the rules from a validate.RuleValidator definition, expanding references to shared rules
+func (d *describer) rules(handler string, v map[string]interface{}) [][]string {
+
+	var shared map[string]interface{}
+
+	if rm := d.component(d.value(v["RuleManager"])); rm != nil {
+		shared, _ = d.value(rm["Rules"]).(map[string]interface{})
+	}
+
+	raw, _ := d.value(v["Rules"]).([]interface{})
+
+	var rules [][]string
+
+	for _, r := range raw {
+
+		rule := toStrings(r)
+
+		if len(rule) > 1 && strings.HasPrefix(rule[1], ruleRefPrefix) {
+
+			ref := strings.TrimPrefix(rule[1], ruleRefPrefix)
+
+			if shared[ref] == nil {
+				d.fail("Handler %s uses shared rule %s, which could not be found", handler, ref)
+				continue
+			}
+
+			rule = append([]string{rule[0]}, toStrings(shared[ref])...)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules
+}
+
+// component resolves a component definition (possibly a reference to another component) and merges in any template
+func (d *describer) component(v interface{}) map[string]interface{} {
+
+	if s, found := v.(string); found {
+		v = d.components[s]
+	}
+
+	c, found := v.(map[string]interface{})
+
+	if !found {
+		return nil
+	}
+
+	flat := make(map[string]interface{})
+
+	for k, v := range c {
+		flat[k] = v
+	}
+
+	if flat[typeField] == nil {
+		flat[typeField] = flat[typeFieldAlias]
+	}
+
+	d.applyTemplate(flat, templateName(c), 0)
+
+	return flat
+}
+
+func (d *describer) applyTemplate(c map[string]interface{}, name string, depth int) {
+
+	if name == "" || depth > len(d.templates) {
+		return
+	}
+
+	t, found := d.templates[name].(map[string]interface{})
+
+	if !found {
+		d.fail("No template named %s", name)
+		return
+	}
+
+	for k, v := range t {
+
+		if k == typeFieldAlias {
+			k = typeField
+		}
+
+		if c[k] == nil {
+			c[k] = v
+		}
+	}
+
+	d.applyTemplate(c, templateName(t), depth+1)
+}
+
+func templateName(c map[string]interface{}) string {
+
+	if n, found := c[templateField].(string); found {
+		return n
+	}
+
+	n, _ := c[templateFieldAlias].(string)
+
+	return n
+}
+
+// value resolves references to configuration and other components. References to components are returned as the
+// name of the referenced component.
+func (d *describer) value(v interface{}) interface{} {
+
+	s, found := v.(string)
+
+	if !found {
+		return v
+	}
+
+	for _, p := range refPrefixes {
+		if strings.HasPrefix(s, p) && !strings.HasPrefix(s, p+p) {
+			return strings.TrimPrefix(s, p)
+		}
+	}
+
+	for _, p := range confPrefixes {
+		if strings.HasPrefix(s, p) && !strings.HasPrefix(s, p+p) {
+
+			path := strings.TrimPrefix(s, p)
+
+			if i := strings.Index(path, "("); i > 0 && strings.HasSuffix(path, ")") {
+				// Path with a default value
+				if !d.conf.PathExists(path[:i]) {
+					return path[i+1 : len(path)-1]
+				}
+
+				path = path[:i]
+			}
+
+			if !d.conf.PathExists(path) {
+				d.fail("No value found in configuration for %s", path)
+				return nil
+			}
+
+			return d.conf.Value(path)
+		}
+	}
+
+	return s
+}
+
+func (d *describer) stringField(c map[string]interface{}, f string) string {
+	s, _ := d.value(c[f]).(string)
+
+	return s
+}
+
+func (d *describer) boolField(c map[string]interface{}, f string) bool {
+	b, _ := d.value(c[f]).(bool)
+
+	return b
+}
+
+func (d *describer) stringsField(c map[string]interface{}, f string) []string {
+	return toStrings(d.value(c[f]))
+}
+
+func (d *describer) mapField(c map[string]interface{}, f string) map[string]string {
+
+	m, found := d.value(c[f]).(map[string]interface{})
+
+	if !found {
+		return nil
+	}
+
+	sm := make(map[string]string)
+
+	for k, v := range m {
+		sm[k] = fmt.Sprint(v)
+	}
+
+	return sm
+}
+
+func (d *describer) fail(message string, a ...interface{}) {
+	d.failed = true
+	d.log.LogErrorf(message, a...)
+}
+
+func toStrings(v interface{}) []string {
+
+	a, found := v.([]interface{})
+
+	if !found {
+		return nil
+	}
+
+	s := make([]string, len(a))
+
+	for i, e := range a {
+		s[i] = fmt.Sprint(e)
+	}
+
+	return s
+}
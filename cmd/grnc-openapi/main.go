@@ -0,0 +1,163 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+/*
+The grnc-openapi tool - used to generate an OpenAPI 3 document describing the web service endpoints declared in a
+Granitic application's component definition files.
+
+The tool is normally run, without arguments, in your application's root directory. It finds every component of type
+handler.WsHandler in your component definition files and describes it using the handler's HTTPMethod, PathPattern (or PathTemplate),
+BindPathParams, FieldQueryParam, FieldHeader, FieldCookie, RequireAuthentication and AccessChecker fields and the rules of its AutoValidator.
+Values stored in configuration (conf:) are read from your application's configuration files.
+
+Because the tool does not compile your application, request schemas are built from the fields referenced by validation
+rules and path/query bindings only. For schemas derived from your Go types, enable the OpenAPI endpoint on the
+HTTPServer facility (HTTPServer.OpenAPI.Enabled) and retrieve the document from your running application.
+
+Usage of grnc-openapi:
+
+	grnc-openapi [-c component-files] [-f config-files] [-o output-file] [-t title] [-v version] [-d description] [-l log-level]
+
+	-c string
+		A comma separated list of component definition files or directories containing component definition files (default "comp-def")
+	-f string
+		A comma separated list of configuration files or directories containing configuration files (default "config")
+	-o string
+		Path to the file that the OpenAPI document will be written to (default "openapi.json")
+	-t string
+		The title of the API (default "API")
+	-v string
+		The version of the API (default "1.0.0")
+	-d string
+		A description of the API
+	-l string
+		The level at which messages will be logged to the console (TRACE, DEBUG, WARN, INFO, ERROR, FATAL) (default "WARN")
+*/
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/graniticio/granitic/v2/config"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/ws/openapi"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+const toolName = "grnc-openapi"
+
+type settings struct {
+	CompDefLocation *string
+	ConfigLocation  *string
+	OutputFile      *string
+	Title           *string
+	Version         *string
+	Description     *string
+	LogLevelLabel   *string
+}
+
+func main() {
+
+	s := settingsFromArgs()
+
+	ll, err := logging.LogLevelFromLabel(*s.LogLevelLabel)
+
+	if err != nil {
+		exitError("Could not map %s to a valid logging level", *s.LogLevelLabel)
+	}
+
+	log := logging.NewStdoutLogger(ll, toolName+": ")
+
+	components, err := loadJSON(*s.CompDefLocation, log, true)
+
+	if err != nil {
+		exitError("Problem loading component definition files: %s", err.Error())
+	}
+
+	conf, err := loadJSON(*s.ConfigLocation, log, false)
+
+	if err != nil {
+		exitError("Problem loading configuration files: %s", err.Error())
+	}
+
+	d := newDescriber(components, conf, log)
+
+	g := new(openapi.Generator)
+	g.Title = *s.Title
+	g.Version = *s.Version
+	g.Description = *s.Description
+
+	doc := g.Generate(d.describe())
+
+	b, err := json.MarshalIndent(doc, "", "  ")
+
+	if err != nil {
+		exitError("Problem serialising OpenAPI document: %s", err.Error())
+	}
+
+	if err = ioutil.WriteFile(*s.OutputFile, b, 0644); err != nil {
+		exitError("Problem writing OpenAPI document: %s", err.Error())
+	}
+
+	if d.failed {
+		exitError("Problems found while describing handlers. The document written to %s may be incomplete", *s.OutputFile)
+	}
+}
+
+func settingsFromArgs() *settings {
+
+	s := new(settings)
+
+	s.CompDefLocation = flag.String("c", "comp-def", "A comma separated list of component definition files or directories containing component definition files")
+	s.ConfigLocation = flag.String("f", "config", "A comma separated list of configuration files or directories containing configuration files")
+	s.OutputFile = flag.String("o", "openapi.json", "Path to the file that the OpenAPI document will be written to")
+	s.Title = flag.String("t", "API", "The title of the API")
+	s.Version = flag.String("v", "1.0.0", "The version of the API")
+	s.Description = flag.String("d", "", "A description of the API")
+	s.LogLevelLabel = flag.String("l", "WARN", "The level at which messages will be logged to the console (TRACE, DEBUG, WARN, INFO, ERROR, FATAL)")
+
+	flag.Parse()
+
+	return s
+}
+
+// loadJSON merges the JSON files found at the supplied comma separated locations. Missing locations are only
+// an error if required is true.
+func loadJSON(locations string, log logging.Logger, required bool) (map[string]interface{}, error) {
+
+	var paths []string
+
+	for _, l := range strings.Split(locations, ",") {
+
+		if _, err := os.Stat(l); err != nil && !required && !strings.HasPrefix(l, "http") {
+			log.LogDebugf("Skipping missing location %s", l)
+			continue
+		}
+
+		paths = append(paths, l)
+	}
+
+	if len(paths) == 0 {
+		return make(map[string]interface{}), nil
+	}
+
+	files, err := config.ExpandToFilesAndURLs(paths)
+
+	if err != nil {
+		return nil, err
+	}
+
+	jm := config.NewJSONMergerWithDirectLogging(log, new(config.JSONContentParser))
+	jm.MergeArrays = true
+
+	return jm.LoadAndMergeConfig(files)
+}
+
+func exitError(message string, a ...interface{}) {
+
+	fmt.Printf("%s: %s\n", toolName, fmt.Sprintf(message, a...))
+	os.Exit(1)
+}
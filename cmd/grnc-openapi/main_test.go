@@ -0,0 +1,64 @@
+package main
+
+import (
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"github.com/graniticio/granitic/v2/ws/openapi"
+	"path/filepath"
+	"testing"
+)
+
+func TestDescribeFromDefinitions(t *testing.T) {
+
+	log := new(logging.ConsoleErrorLogger)
+
+	defs, err := loadJSON(test.FilePath("comp-def"), log, true)
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	conf, err := loadJSON(test.FilePath("config")+","+filepath.Join("testdata", "missing"), log, false)
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	d := newDescriber(defs, conf, log)
+
+	eps := d.describe()
+
+	test.ExpectBool(t, d.failed, false)
+	test.ExpectInt(t, len(eps), 2)
+
+	doc := new(openapi.Generator).Generate(eps)
+
+	list := (*doc.Paths["/artists"])["get"]
+
+	test.ExpectString(t, list.OperationID, "listArtistsHandler")
+
+	if list.Responses["401"] != nil {
+		t.Errorf("Did not expect list handler to require authentication")
+	}
+
+	update := (*doc.Paths["/artist/{ID}"])["put"]
+
+	test.ExpectString(t, update.PathPattern, "")
+
+	for _, code := range []string{"200", "400", "401", "403"} {
+		if update.Responses[code] == nil {
+			t.Errorf("Expected a %s response", code)
+		}
+	}
+
+	test.ExpectInt(t, len(update.Parameters), 2)
+	test.ExpectString(t, update.Parameters[0].Schema.Type, "integer")
+	test.ExpectString(t, update.Parameters[1].Name, "notify")
+	test.ExpectString(t, update.Parameters[1].Schema.Type, "boolean")
+
+	body := update.RequestBody.Content["application/json"].Schema
+
+	test.ExpectInt(t, len(body.Properties), 1)
+	test.ExpectInt(t, *body.Property("Name").MaxLength, 64)
+	test.ExpectString(t, body.Required[0], "Name")
+}
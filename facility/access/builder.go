@@ -0,0 +1,99 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+/*
+Package access provides the AccessControl facility which restricts access to web service endpoints based on the roles
+and scopes held by the caller.
+
+Enabling this facility creates a single access.RuleChecker component and, unless AccessControl.InjectIntoHandlers is set
+to false, injects it into the AccessChecker field of every handler.WsHandler that has a rule in AccessControl.Rules and
+does not already have an AccessChecker set. Rules are keyed by the name of the handler component:
+
+	{
+	  "AccessControl": {
+		"Rules": {
+		  "deleteArticleHandler": "role:admin",
+		  "updateArticleHandler": "role:admin OR (role:editor AND scope:articles.write)"
+		}
+	  }
+	}
+
+Roles and scopes are read from the caller's iam.ClientIdentity, so this facility is normally used alongside an
+Identifier such as the one provided by the JWTIdentifier facility. See the iam/access package documentation for the
+syntax of rules.
+*/
+package access
+
+import (
+	"fmt"
+	"github.com/graniticio/granitic/v2/config"
+	"github.com/graniticio/granitic/v2/iam/access"
+	"github.com/graniticio/granitic/v2/instance"
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/ws/handler"
+)
+
+// CheckerComponentName is the name of the access.RuleChecker component as stored in the IoC framework.
+const CheckerComponentName = instance.FrameworkPrefix + "AccessChecker"
+const checkerDecoratorName = instance.FrameworkPrefix + "AccessCheckerDecorator"
+
+// FacilityBuilder creates an access.RuleChecker and, optionally, a decorator to inject it into web service handlers.
+type FacilityBuilder struct {
+}
+
+// BuildAndRegister implements FacilityBuilder.BuildAndRegister
+func (fb *FacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManager, ca *config.Accessor, cn *ioc.ComponentContainer) error {
+
+	rc := new(access.RuleChecker)
+
+	if err := ca.Populate("AccessControl", rc); err != nil {
+		return fmt.Errorf("unable to read configuration for the AccessControl facility: %s", err.Error())
+	}
+
+	cn.WrapAndAddProto(CheckerComponentName, rc)
+
+	if inject, err := ca.BoolVal("AccessControl.InjectIntoHandlers"); err != nil {
+		return err
+	} else if inject {
+		d := new(checkerDecorator)
+		d.Checker = rc
+		d.FrameworkLogger = lm.CreateLogger(checkerDecoratorName)
+
+		cn.WrapAndAddProto(checkerDecoratorName, d)
+	}
+
+	return nil
+}
+
+// FacilityName implements FacilityBuilder.FacilityName
+func (fb *FacilityBuilder) FacilityName() string {
+	return "AccessControl"
+}
+
+// DependsOnFacilities implements FacilityBuilder.DependsOnFacilities
+func (fb *FacilityBuilder) DependsOnFacilities() []string {
+	return []string{}
+}
+
+// Injects the access.RuleChecker into any handler.WsHandler that has a configured rule and doesn't already have an AccessChecker
+type checkerDecorator struct {
+	FrameworkLogger logging.Logger
+	Checker         *access.RuleChecker
+}
+
+// OfInterest returns true if the subject is a handler.WsHandler with a configured rule and without an AccessChecker
+func (cd *checkerDecorator) OfInterest(subject *ioc.Component) bool {
+
+	h, found := subject.Instance.(*handler.WsHandler)
+
+	return found && h.AccessChecker == nil && cd.Checker.HasRule(subject.Name)
+}
+
+// DecorateComponent sets the handler's AccessChecker to the facility's access.RuleChecker
+func (cd *checkerDecorator) DecorateComponent(subject *ioc.Component, cc *ioc.ComponentContainer) {
+
+	cd.FrameworkLogger.LogTracef("Injecting access checker into %s", subject.Name)
+
+	subject.Instance.(*handler.WsHandler).AccessChecker = cd.Checker
+}
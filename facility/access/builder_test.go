@@ -0,0 +1,112 @@
+package access
+
+import (
+	"github.com/graniticio/granitic/v2/config"
+	"github.com/graniticio/granitic/v2/iam/access"
+	"github.com/graniticio/granitic/v2/instance"
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"github.com/graniticio/granitic/v2/ws/handler"
+	"testing"
+)
+
+func TestFacilityNaming(t *testing.T) {
+
+	fb := new(FacilityBuilder)
+
+	if fb.FacilityName() != "AccessControl" {
+		t.Errorf("Unexpected facility name %s", fb.FacilityName())
+	}
+
+}
+
+func TestBuilderWithRules(t *testing.T) {
+
+	cc, err := buildFacility(t, "rules.json")
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	rc := cc.ProtoComponents()[CheckerComponentName].Component.Instance.(*access.RuleChecker)
+
+	test.ExpectString(t, rc.DeniedCode, "FORBIDDEN")
+	test.ExpectString(t, rc.RolesKey, "Roles")
+	test.ExpectBool(t, rc.HasRule("deleteHandler"), true)
+
+	d := cc.ProtoComponents()[checkerDecoratorName].Component.Instance.(*checkerDecorator)
+
+	h := new(handler.WsHandler)
+	c := ioc.NewComponent("deleteHandler", h)
+
+	test.ExpectBool(t, d.OfInterest(c), true)
+	test.ExpectBool(t, d.OfInterest(ioc.NewComponent("otherHandler", new(handler.WsHandler))), false)
+
+	d.DecorateComponent(c, cc)
+
+	if h.AccessChecker != rc {
+		t.Fatalf("Expected checker to be injected")
+	}
+
+	test.ExpectBool(t, d.OfInterest(c), false)
+}
+
+func TestBuilderWithoutDecorator(t *testing.T) {
+
+	cc, err := buildFacility(t, "nodecorator.json")
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if cc.ProtoComponents()[checkerDecoratorName] != nil {
+		t.Fatalf("Did not expect decorator to be registered")
+	}
+}
+
+func buildFacility(t *testing.T, file string) (*ioc.ComponentContainer, error) {
+
+	lm := logging.CreateComponentLoggerManager(logging.Fatal, make(map[string]interface{}), []logging.LogWriter{}, logging.NewFrameworkLogMessageFormatter(), false)
+
+	ca, err := configAccessor(lm, test.FilePath(file))
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	cc := ioc.NewComponentContainer(lm, ca, new(instance.System))
+
+	if err = new(FacilityBuilder).BuildAndRegister(lm, ca, cc); err != nil {
+		return nil, err
+	}
+
+	return cc, nil
+}
+
+func configAccessor(lm *logging.ComponentLoggerManager, additionalFiles ...string) (*config.Accessor, error) {
+
+	jm := config.NewJSONMergerWithManagedLogging(lm, new(config.JSONContentParser))
+
+	configLoc, err := test.FindFacilityConfigFromWD()
+
+	if err != nil {
+		return nil, err
+	}
+
+	jf, err := config.FindJSONFilesInDir(configLoc)
+
+	if err != nil {
+		return nil, err
+	}
+
+	jf = append(jf, additionalFiles...)
+
+	mergedJSON, err := jm.LoadAndMergeConfigWithBase(make(map[string]interface{}), jf)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &config.Accessor{JSONData: mergedJSON, FrameworkLogger: lm.CreateLogger("ca")}, nil
+}
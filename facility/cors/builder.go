@@ -0,0 +1,126 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+/*
+Package cors provides the CORS facility which allows web services to be called from web pages served from other origins
+(Cross-Origin Resource Sharing).
+
+Enabling this facility creates a cors.Manager component which is injected into the HTTPServer facility (so that CORS
+preflight requests are answered before they are matched to a handler) and set as the HeaderBuilder of the JSON and XML
+response writers (so that CORS headers are added to every response). If a response writer already has a HeaderBuilder,
+the CORS headers are added to the headers built by that component.
+
+Policies are declared in configuration:
+
+	{
+	  "CORS": {
+		"AllowedOrigins": ["https://*.example.com"],
+		"AllowedHeaders": ["Content-Type", "Authorization"],
+		"AllowCredentials": true,
+		"Handlers": {
+		  "publicArticleHandler": {
+			"AllowedOrigins": ["*"]
+		  }
+		}
+	  }
+	}
+
+See the ws/cors package documentation for more details.
+*/
+package cors
+
+import (
+	"fmt"
+	"github.com/graniticio/granitic/v2/config"
+	"github.com/graniticio/granitic/v2/facility/httpserver"
+	"github.com/graniticio/granitic/v2/instance"
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/ws"
+	"github.com/graniticio/granitic/v2/ws/cors"
+	"github.com/graniticio/granitic/v2/ws/xml"
+)
+
+// ManagerComponentName is the name of the cors.Manager component as stored in the IoC framework.
+const ManagerComponentName = instance.FrameworkPrefix + "CORSManager"
+const headerDecoratorName = instance.FrameworkPrefix + "CORSHeaderDecorator"
+
+// HTTPServerCORSFieldName is the field on the HTTPServer component into which the cors.Manager is injected.
+const HTTPServerCORSFieldName = "CORS"
+
+// FacilityBuilder creates a cors.Manager and a decorator to add it to response writers.
+type FacilityBuilder struct {
+}
+
+// BuildAndRegister implements FacilityBuilder.BuildAndRegister
+func (fb *FacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManager, ca *config.Accessor, cn *ioc.ComponentContainer) error {
+
+	m := new(cors.Manager)
+
+	if err := ca.Populate("CORS", m); err != nil {
+		return fmt.Errorf("unable to read configuration for the CORS facility: %s", err.Error())
+	}
+
+	cn.WrapAndAddProto(ManagerComponentName, m)
+
+	if !cn.ModifierExists(httpserver.HTTPServerComponentName, HTTPServerCORSFieldName) {
+		cn.AddModifier(httpserver.HTTPServerComponentName, HTTPServerCORSFieldName, ManagerComponentName)
+	}
+
+	d := new(headerDecorator)
+	d.Manager = m
+	d.FrameworkLogger = lm.CreateLogger(headerDecoratorName)
+
+	cn.WrapAndAddProto(headerDecoratorName, d)
+
+	return nil
+}
+
+// FacilityName implements FacilityBuilder.FacilityName
+func (fb *FacilityBuilder) FacilityName() string {
+	return "CORS"
+}
+
+// DependsOnFacilities implements FacilityBuilder.DependsOnFacilities
+func (fb *FacilityBuilder) DependsOnFacilities() []string {
+	return []string{"HTTPServer"}
+}
+
+// Sets the cors.Manager as the HeaderBuilder of response writers (or chains it with the writer's existing HeaderBuilder)
+type headerDecorator struct {
+	FrameworkLogger logging.Logger
+	Manager         *cors.Manager
+}
+
+// OfInterest returns true if the subject is a ws.MarshallingResponseWriter or xml.TemplatedXMLResponseWriter
+func (hd *headerDecorator) OfInterest(subject *ioc.Component) bool {
+
+	switch subject.Instance.(type) {
+	case *ws.MarshallingResponseWriter, *xml.TemplatedXMLResponseWriter:
+		return true
+	}
+
+	return false
+}
+
+// DecorateComponent sets the response writer's HeaderBuilder
+func (hd *headerDecorator) DecorateComponent(subject *ioc.Component, cc *ioc.ComponentContainer) {
+
+	hd.FrameworkLogger.LogTracef("Adding CORS headers to responses written by %s", subject.Name)
+
+	switch rw := subject.Instance.(type) {
+	case *ws.MarshallingResponseWriter:
+		rw.HeaderBuilder = hd.builder(rw.HeaderBuilder)
+	case *xml.TemplatedXMLResponseWriter:
+		rw.HeaderBuilder = hd.builder(rw.HeaderBuilder)
+	}
+}
+
+func (hd *headerDecorator) builder(existing ws.CommonResponseHeaderBuilder) ws.CommonResponseHeaderBuilder {
+
+	if existing == nil {
+		return hd.Manager
+	}
+
+	return &cors.ChainedHeaderBuilder{Next: existing, Manager: hd.Manager}
+}
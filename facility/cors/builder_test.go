@@ -0,0 +1,122 @@
+package cors
+
+import (
+	"context"
+	"github.com/graniticio/granitic/v2/config"
+	"github.com/graniticio/granitic/v2/facility/httpserver"
+	"github.com/graniticio/granitic/v2/instance"
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"github.com/graniticio/granitic/v2/ws"
+	"github.com/graniticio/granitic/v2/ws/cors"
+	"testing"
+)
+
+func TestFacilityNaming(t *testing.T) {
+
+	fb := new(FacilityBuilder)
+
+	if fb.FacilityName() != "CORS" {
+		t.Errorf("Unexpected facility name %s", fb.FacilityName())
+	}
+
+	test.ExpectString(t, fb.DependsOnFacilities()[0], "HTTPServer")
+}
+
+func TestBuilderWithConfig(t *testing.T) {
+
+	cc, err := buildFacility(t, "cors.json")
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	m := cc.ProtoComponents()[ManagerComponentName].Component.Instance.(*cors.Manager)
+
+	test.ExpectInt(t, len(m.AllowedOrigins), 1)
+	test.ExpectBool(t, m.AllowCredentials, true)
+	test.ExpectInt(t, m.MaxAge, 600)
+	test.ExpectInt(t, len(m.AllowedMethods), 6)
+
+	if m.Handlers["publicHandler"] == nil {
+		t.Fatalf("Expected a policy for publicHandler")
+	}
+
+	test.ExpectBool(t, cc.ModifierExists(httpserver.HTTPServerComponentName, HTTPServerCORSFieldName), true)
+
+	d := cc.ProtoComponents()[headerDecoratorName].Component.Instance.(*headerDecorator)
+
+	rw := new(ws.MarshallingResponseWriter)
+	c := ioc.NewComponent("writer", rw)
+
+	test.ExpectBool(t, d.OfInterest(c), true)
+	test.ExpectBool(t, d.OfInterest(ioc.NewComponent("other", new(cors.Manager))), false)
+
+	d.DecorateComponent(c, cc)
+
+	if rw.HeaderBuilder != m {
+		t.Fatalf("Expected the manager to be set as the HeaderBuilder")
+	}
+
+	rw = new(ws.MarshallingResponseWriter)
+	rw.HeaderBuilder = new(fixedHeaders)
+
+	d.DecorateComponent(ioc.NewComponent("writer", rw), cc)
+
+	if _, found := rw.HeaderBuilder.(*cors.ChainedHeaderBuilder); !found {
+		t.Fatalf("Expected the existing HeaderBuilder to be chained")
+	}
+}
+
+type fixedHeaders struct{}
+
+func (fh *fixedHeaders) BuildHeaders(ctx context.Context, state *ws.ProcessState) map[string]string {
+	return nil
+}
+
+func buildFacility(t *testing.T, file string) (*ioc.ComponentContainer, error) {
+
+	lm := logging.CreateComponentLoggerManager(logging.Fatal, make(map[string]interface{}), []logging.LogWriter{}, logging.NewFrameworkLogMessageFormatter(), false)
+
+	ca, err := configAccessor(lm, test.FilePath(file))
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	cc := ioc.NewComponentContainer(lm, ca, new(instance.System))
+
+	if err = new(FacilityBuilder).BuildAndRegister(lm, ca, cc); err != nil {
+		return nil, err
+	}
+
+	return cc, nil
+}
+
+func configAccessor(lm *logging.ComponentLoggerManager, additionalFiles ...string) (*config.Accessor, error) {
+
+	jm := config.NewJSONMergerWithManagedLogging(lm, new(config.JSONContentParser))
+
+	configLoc, err := test.FindFacilityConfigFromWD()
+
+	if err != nil {
+		return nil, err
+	}
+
+	jf, err := config.FindJSONFilesInDir(configLoc)
+
+	if err != nil {
+		return nil, err
+	}
+
+	jf = append(jf, additionalFiles...)
+
+	mergedJSON, err := jm.LoadAndMergeConfigWithBase(make(map[string]interface{}), jf)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &config.Accessor{JSONData: mergedJSON, FrameworkLogger: lm.CreateLogger("ca")}, nil
+}
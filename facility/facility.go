@@ -30,7 +30,10 @@ which will look something like:
 		"RdbmsAccess": false,
 		"ServiceErrorManager": false,
 		"RuntimeCtl": false,
-		"TaskScheduler": false
+		"TaskScheduler": false,
+		"JWTIdentifier": false,
+		"AccessControl": false,
+		"CORS": false
 	  }
 	}
 
@@ -69,7 +69,9 @@ type AccessLogWriter struct {
 	builder LineBuilder
 
 	lines chan string
-	state ioc.ComponentState
+	// Closed when all lines have been written to the log file
+	written chan struct{}
+	state   ioc.ComponentState
 }
 
 // LogRequest generates an access log line according the configured format. As long as the number of log lines waiting to
@@ -132,6 +134,8 @@ func (alw *AccessLogWriter) StartComponent() error {
 		return err
 	}
 
+	alw.written = make(chan struct{})
+
 	go alw.watchLineBuffer()
 
 	alw.state = ioc.RunningState
@@ -141,8 +145,10 @@ func (alw *AccessLogWriter) StartComponent() error {
 }
 
 func (alw *AccessLogWriter) watchLineBuffer() {
-	for {
-		line := <-alw.lines
+
+	defer close(alw.written)
+
+	for line := range alw.lines {
 
 		f := alw.logFile
 
@@ -200,11 +206,15 @@ func (alw *AccessLogWriter) ReadyToStop() (bool, error) {
 
 }
 
-// Stop closes the log file and message channel
+// Stop closes the message channel, waits for any buffered lines to be written and closes the log file
 func (alw *AccessLogWriter) Stop() error {
 
 	if alw.lines != nil {
 		close(alw.lines)
+
+		if alw.written != nil {
+			<-alw.written
+		}
 	}
 
 	alw.state = ioc.StoppedState
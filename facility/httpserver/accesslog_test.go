@@ -241,3 +241,26 @@ func (cf *contextFilter) Extract(ctx context.Context) logging.FilteredContextDat
 
 	return fd
 }
+
+func TestCompressedSizeLogging(t *testing.T) {
+
+	alw, fs := logWriterWithBuffer(t, "%B %Z")
+
+	req := new(http.Request)
+	req.Method = "GET"
+	req.URL, _ = url.Parse("http://localhost:80/test")
+
+	end := time.Now()
+	start := end.Add(time.Second * -2)
+
+	rw := responseWriter(true, 200)
+	rw.BytesServed = 120
+	rw.UncompressedBytes = 1000
+
+	alw.LogRequest(context.Background(), req, rw, &start, &end)
+	alw.PrepareToStop()
+
+	alw.Stop()
+
+	checkContents(t, fs, "120 1000")
+}
@@ -7,13 +7,17 @@ import (
 	"context"
 	"fmt"
 	"github.com/graniticio/granitic/v2/config"
+	"github.com/graniticio/granitic/v2/httpendpoint"
 	"github.com/graniticio/granitic/v2/instance"
 	"github.com/graniticio/granitic/v2/instrument"
 	"github.com/graniticio/granitic/v2/ioc"
 	"github.com/graniticio/granitic/v2/logging"
 	"github.com/graniticio/granitic/v2/uuid"
+	"github.com/graniticio/granitic/v2/ws/openapi"
+	"github.com/graniticio/granitic/v2/ws/version"
 	"net/http"
 	"strings"
+	"unicode"
 )
 
 // HTTPServerComponentName is the name of the HTTPServer component as stored in the IoC framework.
@@ -21,6 +25,13 @@ const HTTPServerComponentName = instance.FrameworkPrefix + "HTTPServer"
 const contextIDDecoratorName = instance.FrameworkPrefix + "RequestIDContextDecorator"
 const instrumentationDecoratorName = instance.FrameworkPrefix + "RequestInstrumentationDecorator"
 
+const (
+	versionHeaderSource    = "HEADER"
+	versionPathSource      = "PATH"
+	versionQuerySource     = "QUERY"
+	versionMediaTypeSource = "MEDIA_TYPE"
+)
+
 const textEntryMode = "TEXT"
 const jsonEntryMode = "JSON"
 
@@ -33,6 +44,15 @@ const jsonEntryMode = "JSON"
 const HTTPServerAbnormalStatusFieldName = "AbnormalStatusWriter"
 const accessLogWriterName = instance.FrameworkPrefix + "AccessLogWriter"
 
+// ReloadTLSCommandComponentName is the name of the runtime command component that reloads the server's certificates (if TLS is enabled)
+const ReloadTLSCommandComponentName = instance.FrameworkPrefix + "ReloadTLSCommand"
+
+// RateLimitsCommandComponentName is the name of the runtime command component that shows the state of the server's rate limiters
+const RateLimitsCommandComponentName = instance.FrameworkPrefix + "RateLimitsCommand"
+
+// OpenAPIEndpointComponentName is the name of the component that serves a generated OpenAPI document (if enabled)
+const OpenAPIEndpointComponentName = instance.FrameworkPrefix + "OpenAPIEndpoint"
+
 // FacilityBuilder creates the components that make up the HTTPServer facility (the server and an access log writer).
 type FacilityBuilder struct {
 }
@@ -75,8 +95,119 @@ func (hsfb *FacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManager
 		return err
 	}
 
+	if err := configureCompression(ca, log, httpServer); err != nil {
+		return err
+	}
+
+	if err := configureVersioning(ca, log, httpServer); err != nil {
+		return err
+	}
+
+	if httpServer.TLS != nil && httpServer.TLS.Enabled {
+		log.LogDebugf("TLS enabled - certificates can be reloaded with the %s runtime command", reloadTLSCommandName)
+
+		rc := new(reloadTLSCommand)
+		rc.Server = httpServer
+
+		cn.WrapAndAddProto(ReloadTLSCommandComponentName, rc)
+	}
+
+	rlc := new(rateLimitsCommand)
+	rlc.Server = httpServer
+
+	cn.WrapAndAddProto(RateLimitsCommandComponentName, rlc)
+
+	return configureOpenAPI(ca, log, cn)
+
+}
+
+func configureVersioning(ca *config.Accessor, log logging.Logger, h *HTTPServer) error {
+
+	cfg := new(versioningConfig)
+	basePath := "HTTPServer.Versioning"
+
+	if err := ca.Populate(basePath, cfg); err != nil {
+		return fmt.Errorf("Unable to read configuration for version routing %s", err.Error())
+	} else if !cfg.Enabled {
+		return nil
+	}
+
+	switch cfg.Source {
+	case versionHeaderSource:
+		h.VersionExtractor = &version.HeaderExtractor{Header: cfg.Header, Default: cfg.Default}
+	case versionPathSource:
+		if p := cfg.PathPrefix; p == "" || strings.Contains(p, "/") || unicode.IsDigit(rune(p[len(p)-1])) {
+			return fmt.Errorf("%s.PathPrefix must be set to text that does not contain / or end with a digit when %s.Source is %s", basePath, basePath, versionPathSource)
+		}
+
+		h.VersionExtractor = &version.PathPrefixExtractor{Prefix: cfg.PathPrefix, Default: cfg.Default}
+	case versionQuerySource:
+		h.VersionExtractor = &version.QueryExtractor{Param: cfg.Param, Default: cfg.Default}
+	case versionMediaTypeSource:
+		h.VersionExtractor = &version.MediaTypeExtractor{Param: cfg.Param, Default: cfg.Default}
+	default:
+		return fmt.Errorf("%s is not a valid value for %s.Source. Must be one of %s, %s, %s or %s", cfg.Source, basePath,
+			versionHeaderSource, versionPathSource, versionQuerySource, versionMediaTypeSource)
+	}
+
+	log.LogDebugf("Requested versions will be extracted from the request's %s", strings.ToLower(cfg.Source))
+
 	return nil
+}
+
+func configureOpenAPI(ca *config.Accessor, log logging.Logger, cn *ioc.ComponentContainer) error {
 
+	cfg := new(openAPIConfig)
+	basePath := "HTTPServer.OpenAPI"
+
+	if err := ca.Populate(basePath, cfg); err != nil {
+		return fmt.Errorf("Unable to read configuration for OpenAPI document generation %s", err.Error())
+	} else if !cfg.Enabled {
+		return nil
+	}
+
+	if !strings.HasPrefix(cfg.Path, "/") {
+		return fmt.Errorf("%s is not a valid configuration value for %s.Path. Must start with /", cfg.Path, basePath)
+	}
+
+	log.LogDebugf("OpenAPI document will be served on %s", cfg.Path)
+
+	g := new(openapi.Generator)
+	g.Title = cfg.Title
+	g.Description = cfg.Description
+	g.Version = cfg.Version
+	g.ContentType = cfg.ContentType
+
+	ep := new(openapi.Endpoint)
+	ep.Path = cfg.Path
+	ep.Generator = g
+
+	cn.WrapAndAddProto(OpenAPIEndpointComponentName, ep)
+
+	return nil
+}
+
+func configureCompression(ca *config.Accessor, log logging.Logger, s *HTTPServer) error {
+
+	cfg := new(compressionConfig)
+	basePath := "HTTPServer.Compression"
+
+	if err := ca.Populate(basePath, cfg); err != nil {
+		return fmt.Errorf("Unable to read configuration for response compression %s", err.Error())
+	} else if !cfg.Enabled {
+		return nil
+	}
+
+	if err := cfg.Compressor.Validate(); err != nil {
+		return fmt.Errorf("Invalid configuration for %s: %s", basePath, err.Error())
+	}
+
+	log.LogDebugf("Responses of at least %d bytes will be compressed", cfg.MinSize)
+
+	c := cfg.Compressor
+	s.Compressor = &c
+
+	return nil
 }
 
 func (hsfb *FacilityBuilder) setupAccessLogging(ca *config.Accessor, log logging.Logger, httpServer *HTTPServer, cn *ioc.ComponentContainer) error {
@@ -238,6 +369,29 @@ type requestIDConfig struct {
 	}
 }
 
+type compressionConfig struct {
+	Enabled bool
+	httpendpoint.Compressor
+}
+
+type openAPIConfig struct {
+	Enabled     bool
+	Path        string
+	Title       string
+	Description string
+	Version     string
+	ContentType string
+}
+
+type versioningConfig struct {
+	Enabled    bool
+	Source     string
+	Header     string
+	Param      string
+	PathPrefix string
+	Default    string
+}
+
 type requestContextBuilder struct {
 	idGen   uuid.Generate16Byte
 	encoder uuid.EncodeFrom16Byte
@@ -7,6 +7,8 @@ import (
 	"github.com/graniticio/granitic/v2/ioc"
 	"github.com/graniticio/granitic/v2/logging"
 	"github.com/graniticio/granitic/v2/test"
+	"github.com/graniticio/granitic/v2/ws/openapi"
+	"github.com/graniticio/granitic/v2/ws/version"
 	"net/http"
 	"net/url"
 	"testing"
@@ -203,3 +205,105 @@ type testFilter struct {
 func (tf testFilter) Extract(ctx context.Context) logging.FilteredContextData {
 	return tf.m
 }
+
+func TestBuilderWithOpenAPI(t *testing.T) {
+	lm := logging.CreateComponentLoggerManager(logging.Fatal, make(map[string]interface{}), []logging.LogWriter{}, logging.NewFrameworkLogMessageFormatter(), false)
+
+	ca, err := configAccessor(lm, test.FilePath("openapi.json"))
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	cc := ioc.NewComponentContainer(lm, ca, new(instance.System))
+
+	if err = new(FacilityBuilder).BuildAndRegister(lm, ca, cc); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if err = cc.Populate(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	ep := cc.ComponentByName(OpenAPIEndpointComponentName).Instance.(*openapi.Endpoint)
+
+	test.ExpectString(t, ep.RegexPattern(), "^/docs/openapi\\.json$")
+	test.ExpectString(t, ep.Generator.Title, "Test API")
+	test.ExpectString(t, ep.Generator.ContentType, "application/json")
+}
+
+func TestBuilderWithCompression(t *testing.T) {
+	lm := logging.CreateComponentLoggerManager(logging.Fatal, make(map[string]interface{}), []logging.LogWriter{}, logging.NewFrameworkLogMessageFormatter(), false)
+
+	ca, err := configAccessor(lm, test.FilePath("compression.json"))
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	cc := ioc.NewComponentContainer(lm, ca, new(instance.System))
+
+	if err = new(FacilityBuilder).BuildAndRegister(lm, ca, cc); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if err = cc.Populate(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	s := cc.ComponentByName(HTTPServerComponentName).Instance.(*HTTPServer)
+
+	test.ExpectInt(t, s.Compressor.MinSize, 512)
+	test.ExpectInt(t, len(s.Compressor.Encodings), 1)
+	test.ExpectInt(t, s.Compressor.Level, -1)
+	test.ExpectInt(t, len(s.Compressor.ContentTypes), 4)
+}
+
+func TestBuilderWithVersioning(t *testing.T) {
+	lm := logging.CreateComponentLoggerManager(logging.Fatal, make(map[string]interface{}), []logging.LogWriter{}, logging.NewFrameworkLogMessageFormatter(), false)
+
+	ca, err := configAccessor(lm, test.FilePath("versioning.json"))
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	cc := ioc.NewComponentContainer(lm, ca, new(instance.System))
+
+	if err = new(FacilityBuilder).BuildAndRegister(lm, ca, cc); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if err = cc.Populate(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	s := cc.ComponentByName(HTTPServerComponentName).Instance.(*HTTPServer)
+
+	test.ExpectInt(t, s.UnsupportedVersionStatus, http.StatusNotAcceptable)
+
+	e, found := s.VersionExtractor.(*version.MediaTypeExtractor)
+
+	if !found {
+		t.Fatalf("Expected a MediaTypeExtractor")
+	}
+
+	test.ExpectString(t, e.Param, "v")
+	test.ExpectString(t, e.Default, "1.0")
+}
+
+func TestBuilderRejectsEmptyPathPrefix(t *testing.T) {
+	lm := logging.CreateComponentLoggerManager(logging.Fatal, make(map[string]interface{}), []logging.LogWriter{}, logging.NewFrameworkLogMessageFormatter(), false)
+
+	ca, err := configAccessor(lm, test.FilePath("versioning-path.json"))
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	cc := ioc.NewComponentContainer(lm, ca, new(instance.System))
+
+	if err = new(FacilityBuilder).BuildAndRegister(lm, ca, cc); err == nil {
+		t.Fatalf("Expected an empty PathPrefix to be rejected")
+	}
+}
@@ -0,0 +1,22 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package httpserver
+
+import (
+	"context"
+	"net/http"
+)
+
+// CrossOriginHandler is implemented by components that support Cross-Origin Resource Sharing (CORS). The HTTPServer
+// answers CORS preflight requests itself (before the request is matched to a handler) using the headers returned by
+// PreflightHeaders. The component is expected to add headers to the responses to actual requests via a
+// ws.CommonResponseHeaderBuilder. The CORS facility provides an implementation of this interface (cors.Manager).
+type CrossOriginHandler interface {
+	// PreflightHeaders returns the headers to send in response to a preflight request and true if the request is
+	// allowed. handler is the name of the component that would handle the actual request.
+	PreflightHeaders(req *http.Request, handler string) (map[string]string, bool)
+
+	// WithOrigin stores information about a (non-preflight) cross-origin request in the supplied context.
+	WithOrigin(ctx context.Context, req *http.Request) context.Context
+}
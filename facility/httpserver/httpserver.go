@@ -25,21 +25,29 @@ import (
 	"github.com/graniticio/granitic/v2/ioc"
 	"github.com/graniticio/granitic/v2/logging"
 	"github.com/graniticio/granitic/v2/ws"
+	"github.com/graniticio/granitic/v2/ws/cors"
 	"net"
 	"net/http"
 	"regexp"
+	"sort"
+	"strings"
 	"sync/atomic"
 	"time"
 )
 
+const allowHeader = "Allow"
+
 type registeredProvider struct {
 	Provider httpendpoint.Provider
 	Pattern  *regexp.Regexp
+	Name     string
+	limiter  *httpendpoint.RateLimiter
 }
 
 // HTTPServer is the server that accepts incoming HTTP requests and maps them to handlers to process them.
 type HTTPServer struct {
 	registeredProvidersByMethod map[string][]*registeredProvider
+	router                      *router
 	unregisteredProviders       map[string]httpendpoint.Provider
 	componentContainer          *ioc.ComponentContainer
 
@@ -86,13 +94,66 @@ type HTTPServer struct {
 	TooBusyStatus int
 
 	// A component able to examine an incoming request and determine which version of functionality is being requested.
+	// Set by this facility's builder if HTTPServer.Versioning.Enabled is true.
 	VersionExtractor httpendpoint.RequestedVersionExtractor
 
+	// The HTTP status code (normally 404 or 406) returned when a request matches the path and method of at least one
+	// version aware provider, but none of them support the requested version.
+	UnsupportedVersionStatus int
+
 	// A component able to use data in an HTTP request's headers to populate a context
 	IDContextBuilder IdentifiedRequestContextBuilder
 
-	state  ioc.ComponentState
-	server *http.Server
+	// A component able to respond to CORS preflight requests. Automatically injected if the CORS facility is enabled.
+	CORS CrossOriginHandler
+
+	// A component able to compress response bodies. Set by this facility's builder if compression is enabled.
+	Compressor *httpendpoint.Compressor
+
+	// Settings for accepting HTTPS connections and verifying client certificates.
+	TLS *TLSConfig
+
+	// Whether or not HTTP/2 requests should be accepted over unencrypted connections (h2c). Ignored if TLS is enabled,
+	// as HTTP/2 is always available to TLS clients.
+	H2C bool
+
+	// The maximum time (in milliseconds) allowed to read an entire request, including its body. Zero means no limit.
+	ReadTimeoutMS time.Duration
+
+	// The maximum time (in milliseconds) allowed to write a response, measured from the end of the request's headers being read. Zero means no limit.
+	WriteTimeoutMS time.Duration
+
+	// The maximum time (in milliseconds) an idle keep-alive connection will be kept open. Zero means ReadTimeoutMS is used.
+	IdleTimeoutMS time.Duration
+
+	// The maximum size (in bytes) of a request's headers. Zero means Go's default (1MB) is used.
+	MaxHeaderBytes int
+
+	// The maximum size (in bytes) of a request body. Requests with larger bodies receive a 413 (Request Entity Too Large)
+	// response. Providers implementing httpendpoint.BodyLimitedProvider can override this limit. Zero means no limit.
+	MaxRequestBodyBytes int64
+
+	// The maximum time (in milliseconds) PrepareToStop will wait for in-flight requests to complete and connections
+	// to close before forcibly closing any remaining connections. Zero means no limit.
+	DrainTimeoutMS time.Duration
+
+	// The maximum time (in milliseconds) a provider can take to serve a request. Providers implementing
+	// httpendpoint.TimeLimitedProvider can override this timeout. Zero means no limit.
+	RequestTimeoutMS time.Duration
+
+	// The HTTP status code (503 or 504) returned to clients whose request is not served within its timeout.
+	TimeoutStatus int
+
+	// The default limit on the rate at which each client can make requests. Providers implementing httpendpoint.RateLimitedProvider
+	// can override this limit. Clients exceeding their limit receive a 429 (Too Many Requests) response.
+	RateLimit *httpendpoint.RateLimit
+
+	state   ioc.ComponentState
+	server  *http.Server
+	tls     *tlsManager
+	drained chan struct{}
+
+	rateLimiters map[string]*httpendpoint.RateLimiter
 }
 
 // Container allows Granitic to inject a reference to the IOC container
@@ -100,38 +161,49 @@ func (h *HTTPServer) Container(container *ioc.ComponentContainer) {
 	h.componentContainer = container
 }
 
-func (h *HTTPServer) registerProvider(endPointProvider httpendpoint.Provider) {
+func (h *HTTPServer) registerProvider(name string, endPointProvider httpendpoint.Provider) error {
 
-	for _, method := range endPointProvider.SupportedHTTPMethods() {
-		var compiledRegex *regexp.Regexp
-		var err error
+	pattern := endPointProvider.RegexPattern()
 
-		pattern := endPointProvider.RegexPattern()
+	if pattern == "" {
+		return fmt.Errorf("%s does not have a valid path pattern or template", name)
+	}
 
-		if compiledRegex, err = regexp.Compile(pattern); err != nil {
-			h.FrameworkLogger.LogErrorf("Unable to compile regular expression from pattern %s: %s", pattern, err.Error())
-		}
+	compiledRegex, err := regexp.Compile(pattern)
+
+	if err != nil {
+		return fmt.Errorf("unable to compile regular expression from pattern %s for %s: %s", pattern, name, err.Error())
+	}
+
+	limiter, err := h.limiterFor(name, endPointProvider)
+
+	if err != nil {
+		return err
+	}
+
+	for _, method := range endPointProvider.SupportedHTTPMethods() {
 
 		h.FrameworkLogger.LogTracef("Registering %s %s", pattern, method)
 
-		rp := registeredProvider{endPointProvider, compiledRegex}
+		rp := &registeredProvider{Provider: endPointProvider, Pattern: compiledRegex, Name: name, limiter: limiter}
+
+		if existing := h.router.conflict(method, rp); existing != nil {
+			return fmt.Errorf("%s (%s) and %s (%s) could both handle the same %s requests. Make their paths distinct or make them version aware",
+				existing.Name, existing.Pattern.String(), name, pattern, method)
+		}
 
 		providersForMethod := h.registeredProvidersByMethod[method]
 
-		if providersForMethod == nil {
-			providersForMethod = make([]*registeredProvider, 1)
-			providersForMethod[0] = &rp
-			h.registeredProvidersByMethod[method] = providersForMethod
-		} else {
-			h.registeredProvidersByMethod[method] = append(providersForMethod, &rp)
-		}
+		h.registeredProvidersByMethod[method] = append(providersForMethod, rp)
+		h.router.add(method, rp)
 	}
 
+	return nil
 }
 
 // StartComponent Finds and registers any available components that implement httpendpoint.Provider (normally instances of
-// handler.WsHandler) unless auto finding of handlers is disabled. The server does not actually start listening for
-// requests until the IoC container calls AllowAccess.
+// handler.WsHandler) unless auto finding of handlers is disabled. Providers are registered in order of their component
+// names. The server does not actually start listening for requests until the IoC container calls AllowAccess.
 func (h *HTTPServer) StartComponent() error {
 
 	if h.state != ioc.StoppedState {
@@ -140,22 +212,52 @@ func (h *HTTPServer) StartComponent() error {
 
 	h.state = ioc.StartingState
 	h.registeredProvidersByMethod = make(map[string][]*registeredProvider)
+	h.router = newRouter()
+	h.rateLimiters = make(map[string]*httpendpoint.RateLimiter)
+
+	if err := h.configureRateLimit(); err != nil {
+		return err
+	}
+
+	if h.UnsupportedVersionStatus == 0 {
+		h.UnsupportedVersionStatus = http.StatusNotFound
+	}
 
 	if h.AutoFindHandlers {
-		for _, component := range h.componentContainer.AllComponents() {
+
+		components := h.componentContainer.AllComponents()
+
+		sort.Slice(components, func(i, j int) bool {
+			return components[i].Name < components[j].Name
+		})
+
+		for _, component := range components {
 
 			name := component.Name
 
 			if provider, found := component.Instance.(httpendpoint.Provider); found && provider.AutoWireable() {
 				h.FrameworkLogger.LogDebugf("Found Provider %s", name)
-				h.registerProvider(provider)
+
+				if err := h.registerProvider(name, provider); err != nil {
+					return err
+				}
 			}
 		}
 	} else if h.unregisteredProviders != nil {
 
-		for _, provider := range h.unregisteredProviders {
+		var names []string
+
+		for name := range h.unregisteredProviders {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
 
-			h.registerProvider(provider)
+			if err := h.registerProvider(name, h.unregisteredProviders[name]); err != nil {
+				return err
+			}
 
 		}
 
@@ -174,6 +276,17 @@ func (h *HTTPServer) StartComponent() error {
 		h.InstrumentationManager = new(noopRequestInstrumentationManager)
 	}
 
+	if h.TLS != nil && h.TLS.Enabled {
+
+		tm, err := newTLSManager(h.TLS, h.FrameworkLogger)
+
+		if err != nil {
+			return err
+		}
+
+		h.tls = tm
+	}
+
 	h.state = ioc.AwaitingAccessState
 
 	return nil
@@ -210,11 +323,7 @@ func (h *HTTPServer) AllowAccess() error {
 		return nil
 	}
 
-	sm := http.NewServeMux()
-	sm.Handle("/", http.HandlerFunc(h.handleAll))
-
-	sv := new(http.Server)
-	sv.Handler = sm
+	sv := h.newServer()
 
 	listenAddress := fmt.Sprintf("%s:%d", h.Address, h.Port)
 
@@ -227,17 +336,66 @@ func (h *HTTPServer) AllowAccess() error {
 
 	sv.Addr = listenAddress
 
-	go sv.ListenAndServe()
+	if h.tls != nil {
+		sv.TLSConfig = h.tls.serverConfig()
 
-	h.server = sv
+		// Certificates are supplied by the TLS config so that they can be reloaded
+		go sv.ListenAndServeTLS("", "")
+
+		h.FrameworkLogger.LogInfof("Listening for TLS connections on %d", h.Port)
+	} else {
+		go sv.ListenAndServe()
 
-	h.FrameworkLogger.LogInfof("Listening on %d", h.Port)
+		h.FrameworkLogger.LogInfof("Listening on %d", h.Port)
+	}
+
+	h.server = sv
 
 	h.state = ioc.RunningState
 
 	return nil
 }
 
+// newServer creates an http.Server that passes all requests to this component, with the configured timeouts and protocols
+func (h *HTTPServer) newServer() *http.Server {
+
+	sm := http.NewServeMux()
+	sm.Handle("/", http.HandlerFunc(h.handleAll))
+
+	sv := new(http.Server)
+	sv.Handler = sm
+
+	sv.ReadTimeout = h.ReadTimeoutMS * time.Millisecond
+	sv.WriteTimeout = h.WriteTimeoutMS * time.Millisecond
+	sv.IdleTimeout = h.IdleTimeoutMS * time.Millisecond
+	sv.MaxHeaderBytes = h.MaxHeaderBytes
+
+	if h.H2C {
+
+		if h.tls != nil {
+			h.FrameworkLogger.LogWarnf("HTTPServer.H2C is ignored when TLS is enabled")
+		} else {
+			sv.Protocols = new(http.Protocols)
+			sv.Protocols.SetHTTP1(true)
+			sv.Protocols.SetUnencryptedHTTP2(true)
+		}
+	}
+
+	return sv
+}
+
+// ReloadTLS re-reads the certificate, key and client CA files named in the server's TLS configuration. Connections made
+// after a successful reload use the new certificates. If the files cannot be loaded, an error is returned and the server
+// continues to use the existing certificates.
+func (h *HTTPServer) ReloadTLS() error {
+
+	if h.tls == nil {
+		return errors.New("TLS is not enabled for this server")
+	}
+
+	return h.tls.reload()
+}
+
 // SetProvidersManually manually injects a set of httpendpoint.HTTPEndpointProviders when auto finding is disabled.
 func (h *HTTPServer) SetProvidersManually(p map[string]httpendpoint.Provider) {
 	h.unregisteredProviders = p
@@ -290,6 +448,10 @@ func (h *HTTPServer) handleAll(res http.ResponseWriter, req *http.Request) {
 		defer endInstrumentation()
 	}
 
+	if h.Compressor != nil {
+		h.Compressor.Wrap(wrw, req)
+	}
+
 	var requestID string
 	received := time.Now()
 
@@ -311,46 +473,238 @@ func (h *HTTPServer) handleAll(res http.ResponseWriter, req *http.Request) {
 
 			//Something went wrong trying to use HTTP data to identify a context - treat as a bad request (400)
 			h.writeAbnormal(ctx, http.StatusBadRequest, wrw, err)
+			wrw.Finish()
 
 			return
 
 		}
 	}
 
-	matched := false
+	// Providers are found using the path without its version, but the request is logged as it was received
+	versioned := req
+	req = h.unversioned(req)
+	path := req.URL.Path
 
-	providersByMethod := h.registeredProvidersByMethod[req.Method]
+	h.FrameworkLogger.LogTracef("Finding provider to handle %s %s from %d providers", path, req.Method, len(h.registeredProvidersByMethod[req.Method]))
 
-	path := req.URL.Path
+	accept := func(rp *registeredProvider) bool {
+		return h.versionMatch(instrumentor, versioned, rp.Provider)
+	}
 
-	h.FrameworkLogger.LogTracef("Finding provider to handle %s %s from %d providers", path, req.Method, len(providersByMethod))
+	if h.CORS != nil {
 
-	for _, handlerPattern := range providersByMethod {
+		if cors.IsPreflight(req) {
 
-		pattern := handlerPattern.Pattern
+			if rp := h.router.find(req.Header.Get(cors.RequestMethodHeader), path, accept); rp != nil {
+				h.writePreflight(ctx, req, wrw, rp.Name)
+				h.finishRequest(ctx, versioned, wrw, &received)
 
-		h.FrameworkLogger.LogTracef("Testing %s", pattern.String())
+				return
+			}
 
-		if pattern.MatchString(path) && h.versionMatch(instrumentor, req, handlerPattern.Provider) {
-			h.FrameworkLogger.LogTracef("Matches %s", pattern.String())
-			matched = true
-			ctx = handlerPattern.Provider.ServeHTTP(ctx, wrw, req)
+		} else {
+			ctx = h.CORS.WithOrigin(ctx, req)
 		}
 	}
 
-	if !matched {
-		state := ws.NewAbnormalState(http.StatusNotFound, wrw)
+	if rp := h.router.find(req.Method, path, accept); rp != nil {
+		h.FrameworkLogger.LogTracef("Matches %s (%s)", rp.Name, rp.Pattern.String())
 
-		if err := h.AbnormalStatusWriter.WriteAbnormalStatus(ctx, state); err != nil {
-			h.FrameworkLogger.LogErrorfCtx(ctx, err.Error())
+		var allowed bool
+
+		if ctx, allowed = h.limitRate(ctx, req, wrw, rp); !allowed {
+			h.finishRequest(ctx, versioned, wrw, &received)
+			return
+		}
+
+		if !h.limitBody(ctx, req, wrw, rp.Provider) {
+			h.finishRequest(ctx, versioned, wrw, &received)
+			return
 		}
+
+		tctx, cancelTimeout := h.withTimeout(ctx, rp.Provider)
+		defer cancelTimeout()
+
+		ctx = h.serve(tctx, wrw, req, rp)
+	} else if h.router.find(req.Method, path, anyVersion) != nil {
+		h.FrameworkLogger.LogDebugfCtx(ctx, "No provider for %s %s supports the requested version", req.Method, path)
+		h.writeAbnormal(ctx, h.UnsupportedVersionStatus, wrw)
+	} else if allowed := h.router.allowed(path, accept); len(allowed) > 0 {
+		h.writeAllowed(ctx, req, wrw, allowed)
+	} else {
+		h.writeAbnormal(ctx, http.StatusNotFound, wrw)
+	}
+
+	h.finishRequest(ctx, versioned, wrw, &received)
+}
+
+// limitBody applies the maximum request body size for the supplied provider. If the request declares a body larger than
+// the limit, a 413 response is written and false is returned. Otherwise the request's body is wrapped so that reading
+// beyond the limit causes an error.
+func (h *HTTPServer) limitBody(ctx context.Context, req *http.Request, wrw *httpendpoint.HTTPResponseWriter, p httpendpoint.Provider) bool {
+
+	limit := h.MaxRequestBodyBytes
+
+	if blp, found := p.(httpendpoint.BodyLimitedProvider); found && blp.RequestBodyLimit() != 0 {
+		limit = blp.RequestBodyLimit()
+	}
+
+	if limit <= 0 || req.Body == nil {
+		return true
+	}
+
+	if req.ContentLength > limit {
+		h.FrameworkLogger.LogDebugfCtx(ctx, "Rejected request for %s with a body of %d bytes (limit %d bytes)", req.URL.Path, req.ContentLength, limit)
+		h.writeAbnormal(ctx, http.StatusRequestEntityTooLarge, wrw)
+
+		return false
+	}
+
+	req.Body = http.MaxBytesReader(wrw, req.Body, limit)
+
+	return true
+}
+
+// serve passes the request to the supplied provider. If the context has a deadline that the provider does not enforce
+// itself (see httpendpoint.DeadlineEnforcer), the provider is run in a separate goroutine in the manner of
+// http.TimeoutHandler: if the deadline passes first, an error response is sent straight away and anything the provider
+// writes after that point is discarded.
+func (h *HTTPServer) serve(ctx context.Context, wrw *httpendpoint.HTTPResponseWriter, req *http.Request, rp *registeredProvider) context.Context {
+
+	_, hasDeadline := ctx.Deadline()
+
+	if de, found := rp.Provider.(httpendpoint.DeadlineEnforcer); !hasDeadline || (found && de.EnforcesDeadline()) {
+		return rp.Provider.ServeHTTP(ctx, wrw, req)
+	}
+
+	dw := httpendpoint.NewDeadlineWriter(wrw)
+	done := make(chan context.Context, 1)
+	panicked := make(chan interface{}, 1)
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				panicked <- r
+			}
+		}()
+
+		done <- rp.Provider.ServeHTTP(ctx, httpendpoint.NewHTTPResponseWriter(dw), req)
+	}()
+
+	rctx := ctx
+
+	select {
+	case rctx = <-done:
+	case r := <-panicked:
+		// Let the panic be handled as if the provider had been called directly
+		panic(r)
+	case <-ctx.Done():
+	}
+
+	if started := dw.Expire(); !started && ctx.Err() == context.DeadlineExceeded {
+		h.FrameworkLogger.LogErrorfCtx(ctx, "%s did not serve %s %s within its timeout", rp.Name, req.Method, req.URL.Path)
+		h.writeAbnormal(ctx, httpendpoint.TimeoutStatus(ctx), wrw)
+	}
+
+	return rctx
+}
+
+// withTimeout derives a context with a deadline if the server or the supplied provider defines a timeout for requests.
+func (h *HTTPServer) withTimeout(ctx context.Context, p httpendpoint.Provider) (context.Context, context.CancelFunc) {
+
+	timeout := h.RequestTimeoutMS * time.Millisecond
+
+	if tlp, found := p.(httpendpoint.TimeLimitedProvider); found && tlp.RequestTimeout() != 0 {
+		timeout = tlp.RequestTimeout()
+	}
+
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+
+	return httpendpoint.WithRequestTimeout(ctx, timeout, h.TimeoutStatus)
+}
+
+// finishRequest writes any data still buffered by the response writer and records the request in the access log
+func (h *HTTPServer) finishRequest(ctx context.Context, req *http.Request, wrw *httpendpoint.HTTPResponseWriter, received *time.Time) {
+
+	if err := wrw.Finish(); err != nil {
+		h.FrameworkLogger.LogDebugfCtx(ctx, "Unable to complete response: %s", err.Error())
 	}
 
 	if h.AccessLogging {
 		finished := time.Now()
-		h.AccessLogWriter.LogRequest(ctx, req, wrw, &received, &finished)
+		h.AccessLogWriter.LogRequest(ctx, req, wrw, received, &finished)
+	}
+}
+
+// writePreflight responds to a CORS preflight request for a path and method that is supported by the named provider.
+// Allowed requests receive a 204 response with the CORS headers and others a 403 response.
+func (h *HTTPServer) writePreflight(ctx context.Context, req *http.Request, wrw *httpendpoint.HTTPResponseWriter, provider string) {
+
+	headers, allowed := h.CORS.PreflightHeaders(req, provider)
+
+	if !allowed {
+		h.writeAbnormal(ctx, http.StatusForbidden, wrw)
+		return
+	}
+
+	for k, v := range headers {
+		wrw.Header().Set(k, v)
 	}
 
+	h.writeAbnormal(ctx, http.StatusNoContent, wrw)
+}
+
+// writeAllowed handles requests where the path is supported by at least one provider, but not with the requested HTTP method.
+// OPTIONS requests receive a 204 response and all other methods a 405 response. In both cases the Allow header lists the methods
+// that are supported.
+func (h *HTTPServer) writeAllowed(ctx context.Context, req *http.Request, wrw *httpendpoint.HTTPResponseWriter, allowed []string) {
+
+	status := http.StatusMethodNotAllowed
+
+	if req.Method == http.MethodOptions {
+		status = http.StatusNoContent
+	}
+
+	if i := sort.SearchStrings(allowed, http.MethodOptions); i == len(allowed) || allowed[i] != http.MethodOptions {
+		allowed = append(allowed, http.MethodOptions)
+		sort.Strings(allowed)
+	}
+
+	wrw.Header().Set(allowHeader, strings.Join(allowed, ", "))
+
+	h.writeAbnormal(ctx, status, wrw)
+}
+
+// unversioned returns a shallow copy of the request with the requested version removed from its path, if the server's
+// VersionExtractor finds versions in request paths. Otherwise the request is returned unmodified.
+func (h *HTTPServer) unversioned(req *http.Request) *http.Request {
+
+	vpe, found := h.VersionExtractor.(httpendpoint.VersionedPathExtractor)
+
+	if !found {
+		return req
+	}
+
+	p := vpe.UnversionedPath(req.URL.Path)
+
+	if p == req.URL.Path {
+		return req
+	}
+
+	r := req.WithContext(req.Context())
+	u := *req.URL
+	u.Path = p
+	u.RawPath = ""
+	r.URL = &u
+
+	return r
+}
+
+// anyVersion accepts providers regardless of the version they support
+func anyVersion(rp *registeredProvider) bool {
+	return true
 }
 
 func (h *HTTPServer) versionMatch(ri instrument.Instrumentor, r *http.Request, p httpendpoint.Provider) bool {
@@ -367,31 +721,60 @@ func (h *HTTPServer) versionMatch(ri instrument.Instrumentor, r *http.Request, p
 
 }
 
-// PrepareToStop sets state to Stopping. Any subsequent requests will receive a 'too busy response'
+// PrepareToStop sets state to Stopping. Any subsequent requests will receive a 'too busy response'. The server stops
+// accepting new connections and closes idle connections, waiting up to DrainTimeoutMS for in-flight requests to complete
+// before forcibly closing any remaining connections.
 func (h *HTTPServer) PrepareToStop() {
 	h.state = ioc.StoppingState
 
-	if h.server != nil {
-		h.server.Shutdown(context.Background())
+	if h.server == nil || h.drained != nil {
+		return
+	}
+
+	h.drained = make(chan struct{})
+
+	ctx := context.Background()
+	cancel := func() {}
+
+	if h.DrainTimeoutMS > 0 {
+		ctx, cancel = context.WithTimeout(ctx, h.DrainTimeoutMS*time.Millisecond)
 	}
 
+	go func(sv *http.Server, drained chan struct{}) {
+		defer cancel()
+		defer close(drained)
+
+		if err := sv.Shutdown(ctx); err != nil {
+			h.FrameworkLogger.LogWarnf("HTTP server listening on %d did not drain before the deadline (%s). Closing remaining connections", h.Port, err.Error())
+			sv.Close()
+		}
+	}(h.server, h.drained)
+
 }
 
-// ReadyToStop returns false is the server is currently handling any requests.
+// ReadyToStop returns false if the server is currently handling any requests or (after PrepareToStop has been called)
+// is still waiting for connections to drain.
 func (h *HTTPServer) ReadyToStop() (bool, error) {
 	a := h.ActiveRequests
-	ready := a <= 0
 
-	if ready {
-		return true, nil
+	if a > 0 {
+		return false, fmt.Errorf("HTTP server listening on %d is still serving %d request(s)", h.Port, a)
+	}
+
+	if h.drained != nil {
+		select {
+		case <-h.drained:
+		default:
+			return false, fmt.Errorf("HTTP server listening on %d is still draining connections", h.Port)
+		}
 	}
 
-	return false, fmt.Errorf("HTTP server listening on %d is still serving %d request(s)", h.Port, a)
+	return true, nil
 
 }
 
-// Stop sets state to Stopped. Any subsequent requests will receive a 'too busy response'. Note that the HTTP
-// server is still listening on its configured port and address.
+// Stop sets state to Stopped. Any subsequent requests will receive a 'too busy response'. Any connections that are still
+// open are closed.
 func (h *HTTPServer) Stop() error {
 
 	h.state = ioc.StoppedState
@@ -3,9 +3,18 @@ package httpserver
 import (
 	"context"
 	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/ioc"
 	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
 	"github.com/graniticio/granitic/v2/ws"
+	"github.com/graniticio/granitic/v2/ws/version"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestServerStart(t *testing.T) {
@@ -37,3 +46,714 @@ type mockAsw struct {
 func (a *mockAsw) WriteAbnormalStatus(ctx context.Context, state *ws.ProcessState) error {
 	return nil
 }
+
+func TestProviderCollisions(t *testing.T) {
+
+	s := new(HTTPServer)
+	s.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	s.AbnormalStatusWriter = new(mockAsw)
+
+	s.SetProvidersManually(map[string]httpendpoint.Provider{
+		"a": &mockProvider{pattern: "^/users/([^/]+)[/]?$"},
+		"b": &mockProvider{pattern: "^/users/([^/]+)[/]?$"},
+	})
+
+	if err := s.StartComponent(); err == nil {
+		t.Fatalf("Expected colliding providers to be rejected")
+	}
+
+	s = new(HTTPServer)
+	s.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	s.AbnormalStatusWriter = new(mockAsw)
+
+	s.SetProvidersManually(map[string]httpendpoint.Provider{
+		"a": &mockProvider{pattern: "^/users/[0-9]+$"},
+		"b": &mockProvider{pattern: "^/users/.+$"},
+	})
+
+	if err := s.StartComponent(); err == nil {
+		t.Fatalf("Expected overlapping providers to be rejected")
+	}
+
+	s = new(HTTPServer)
+	s.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	s.AbnormalStatusWriter = new(mockAsw)
+
+	s.SetProvidersManually(map[string]httpendpoint.Provider{
+		"a": &mockProvider{pattern: "^/users/([^/]+)[/]?$", versionAware: true},
+		"b": &mockProvider{pattern: "^/users/([^/]+)[/]?$", versionAware: true},
+		"c": &mockProvider{pattern: "^/users[/]?$"},
+	})
+
+	if err := s.StartComponent(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	s = new(HTTPServer)
+	s.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	s.AbnormalStatusWriter = new(mockAsw)
+
+	s.SetProvidersManually(map[string]httpendpoint.Provider{
+		"a": &mockProvider{pattern: ""},
+	})
+
+	if err := s.StartComponent(); err == nil {
+		t.Fatalf("Expected a provider without a pattern to be rejected")
+	}
+}
+
+type mockProvider struct {
+	pattern      string
+	versionAware bool
+	method       string
+}
+
+func (mp *mockProvider) SupportedHTTPMethods() []string {
+
+	if mp.method != "" {
+		return []string{mp.method}
+	}
+
+	return []string{http.MethodGet}
+}
+
+func (mp *mockProvider) RegexPattern() string {
+	return mp.pattern
+}
+
+func (mp *mockProvider) ServeHTTP(ctx context.Context, w *httpendpoint.HTTPResponseWriter, req *http.Request) context.Context {
+	w.WriteHeader(http.StatusOK)
+	return ctx
+}
+
+func (mp *mockProvider) VersionAware() bool {
+	return mp.versionAware
+}
+
+func (mp *mockProvider) SupportsVersion(version httpendpoint.RequiredVersion) bool {
+	return true
+}
+
+func (mp *mockProvider) AutoWireable() bool {
+	return true
+}
+
+func TestMethodNotAllowedAndOptions(t *testing.T) {
+
+	s := new(HTTPServer)
+	s.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	asw := new(statusRecordingAsw)
+	s.AbnormalStatusWriter = asw
+
+	s.SetProvidersManually(map[string]httpendpoint.Provider{
+		"get":    &mockProvider{pattern: "^/users/([^/]+)[/]?$"},
+		"delete": &mockProvider{pattern: "^/users/([^/]+)[/]?$", method: http.MethodDelete},
+	})
+
+	if err := s.StartComponent(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	s.state = ioc.RunningState
+
+	w := httptest.NewRecorder()
+	s.handleAll(w, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+	test.ExpectInt(t, w.Code, http.StatusOK)
+
+	w = httptest.NewRecorder()
+	s.handleAll(w, httptest.NewRequest(http.MethodPut, "/users/1", nil))
+
+	test.ExpectInt(t, asw.status, http.StatusMethodNotAllowed)
+	test.ExpectString(t, w.Header().Get("Allow"), "DELETE, GET, OPTIONS")
+
+	w = httptest.NewRecorder()
+	s.handleAll(w, httptest.NewRequest(http.MethodOptions, "/users/1", nil))
+
+	test.ExpectInt(t, asw.status, http.StatusNoContent)
+	test.ExpectString(t, w.Header().Get("Allow"), "DELETE, GET, OPTIONS")
+
+	w = httptest.NewRecorder()
+	s.handleAll(w, httptest.NewRequest(http.MethodPut, "/artists/1", nil))
+
+	test.ExpectInt(t, asw.status, http.StatusNotFound)
+	test.ExpectString(t, w.Header().Get("Allow"), "")
+}
+
+type statusRecordingAsw struct {
+	status int
+}
+
+func (a *statusRecordingAsw) WriteAbnormalStatus(ctx context.Context, state *ws.ProcessState) error {
+	a.status = state.Status
+	state.HTTPResponseWriter.WriteHeader(state.Status)
+
+	return nil
+}
+
+func TestCORSPreflight(t *testing.T) {
+
+	s := new(HTTPServer)
+	s.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	asw := new(statusRecordingAsw)
+	s.AbnormalStatusWriter = asw
+	s.CORS = new(mockCORS)
+
+	s.SetProvidersManually(map[string]httpendpoint.Provider{
+		"get": &mockProvider{pattern: "^/users/([^/]+)[/]?$"},
+	})
+
+	if err := s.StartComponent(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	s.state = ioc.RunningState
+
+	preflight := func(origin string) *http.Request {
+		r := httptest.NewRequest(http.MethodOptions, "/users/1", nil)
+		r.Header.Set("Origin", origin)
+		r.Header.Set("Access-Control-Request-Method", http.MethodGet)
+		return r
+	}
+
+	w := httptest.NewRecorder()
+	s.handleAll(w, preflight("https://allowed.example.com"))
+
+	test.ExpectInt(t, asw.status, http.StatusNoContent)
+	test.ExpectString(t, w.Header().Get("Access-Control-Allow-Origin"), "https://allowed.example.com")
+	test.ExpectString(t, w.Header().Get("X-Handler"), "get")
+
+	w = httptest.NewRecorder()
+	s.handleAll(w, preflight("https://other.example.com"))
+
+	test.ExpectInt(t, asw.status, http.StatusForbidden)
+	test.ExpectString(t, w.Header().Get("Access-Control-Allow-Origin"), "")
+
+	w = httptest.NewRecorder()
+	s.handleAll(w, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+	test.ExpectInt(t, w.Code, http.StatusOK)
+}
+
+type mockCORS struct{}
+
+func (mc *mockCORS) PreflightHeaders(req *http.Request, handler string) (map[string]string, bool) {
+
+	origin := req.Header.Get("Origin")
+
+	if origin != "https://allowed.example.com" {
+		return nil, false
+	}
+
+	return map[string]string{"Access-Control-Allow-Origin": origin, "X-Handler": handler}, true
+}
+
+func (mc *mockCORS) WithOrigin(ctx context.Context, req *http.Request) context.Context {
+	return ctx
+}
+
+// serve starts the server's http.Server on a random port, replacing its handler with the supplied handler
+func serve(t *testing.T, s *HTTPServer, handler http.HandlerFunc) string {
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	sv := s.newServer()
+	sv.Handler = handler
+	s.server = sv
+
+	go sv.Serve(ln)
+
+	return "http://" + ln.Addr().String()
+}
+
+func TestServerConfiguration(t *testing.T) {
+
+	s := new(HTTPServer)
+	s.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	s.ReadTimeoutMS = 1000
+	s.WriteTimeoutMS = 2000
+	s.IdleTimeoutMS = 3000
+	s.MaxHeaderBytes = 4096
+	s.H2C = true
+
+	sv := s.newServer()
+
+	test.ExpectInt(t, int(sv.ReadTimeout/time.Millisecond), 1000)
+	test.ExpectInt(t, int(sv.WriteTimeout/time.Millisecond), 2000)
+	test.ExpectInt(t, int(sv.IdleTimeout/time.Millisecond), 3000)
+	test.ExpectInt(t, sv.MaxHeaderBytes, 4096)
+
+	url := serve(t, s, func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(req.Proto))
+	})
+
+	defer s.Stop()
+
+	tr := new(http.Transport)
+	tr.Protocols = new(http.Protocols)
+	tr.Protocols.SetUnencryptedHTTP2(true)
+
+	res, err := (&http.Client{Transport: tr}).Get(url)
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	defer res.Body.Close()
+
+	b, _ := ioutil.ReadAll(res.Body)
+
+	test.ExpectString(t, string(b), "HTTP/2.0")
+}
+
+func TestGracefulDrain(t *testing.T) {
+
+	s := new(HTTPServer)
+	s.FrameworkLogger = new(logging.ConsoleErrorLogger)
+
+	started := make(chan bool)
+	release := make(chan bool)
+
+	url := serve(t, s, func(w http.ResponseWriter, req *http.Request) {
+		started <- true
+		<-release
+		w.Write([]byte("done"))
+	})
+
+	result := make(chan error)
+
+	go func() {
+		res, err := http.Get(url)
+
+		if err == nil {
+			res.Body.Close()
+		}
+
+		result <- err
+	}()
+
+	<-started
+
+	s.PrepareToStop()
+
+	if ready, _ := s.ReadyToStop(); ready {
+		t.Errorf("Expected the server not to be ready to stop while a request is in progress")
+	}
+
+	close(release)
+
+	if err := <-result; err != nil {
+		t.Errorf("Expected in-flight request to complete: %s", err.Error())
+	}
+
+	<-s.drained
+
+	if ready, err := s.ReadyToStop(); !ready {
+		t.Errorf("Expected the server to be ready to stop: %v", err)
+	}
+
+	s.Stop()
+}
+
+func TestDrainDeadline(t *testing.T) {
+
+	s := new(HTTPServer)
+	s.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	s.DrainTimeoutMS = 50
+
+	started := make(chan bool)
+	release := make(chan bool)
+	defer close(release)
+
+	url := serve(t, s, func(w http.ResponseWriter, req *http.Request) {
+		started <- true
+		<-release
+	})
+
+	go http.Get(url)
+
+	<-started
+
+	s.PrepareToStop()
+
+	select {
+	case <-s.drained:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected connections to be closed after the drain deadline")
+	}
+
+	if ready, err := s.ReadyToStop(); !ready {
+		t.Errorf("Expected the server to be ready to stop: %v", err)
+	}
+}
+
+type limitedProvider struct {
+	mockProvider
+	limit int64
+	read  error
+}
+
+func (lp *limitedProvider) RequestBodyLimit() int64 {
+	return lp.limit
+}
+
+func (lp *limitedProvider) ServeHTTP(ctx context.Context, w *httpendpoint.HTTPResponseWriter, req *http.Request) context.Context {
+	_, lp.read = ioutil.ReadAll(req.Body)
+	w.WriteHeader(http.StatusOK)
+
+	return ctx
+}
+
+func TestRequestBodyLimit(t *testing.T) {
+
+	s := new(HTTPServer)
+	s.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	s.MaxRequestBodyBytes = 10
+	asw := new(statusRecordingAsw)
+	s.AbnormalStatusWriter = asw
+
+	def := &limitedProvider{mockProvider: mockProvider{pattern: "^/default$", method: http.MethodPost}}
+	large := &limitedProvider{mockProvider: mockProvider{pattern: "^/large$", method: http.MethodPost}, limit: 100}
+
+	s.SetProvidersManually(map[string]httpendpoint.Provider{"default": def, "large": large})
+
+	if err := s.StartComponent(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	s.state = ioc.RunningState
+
+	body := strings.Repeat("a", 50)
+
+	w := httptest.NewRecorder()
+	s.handleAll(w, httptest.NewRequest(http.MethodPost, "/default", strings.NewReader(body)))
+
+	test.ExpectInt(t, asw.status, http.StatusRequestEntityTooLarge)
+
+	w = httptest.NewRecorder()
+	s.handleAll(w, httptest.NewRequest(http.MethodPost, "/large", strings.NewReader(body)))
+
+	test.ExpectInt(t, w.Code, http.StatusOK)
+	test.ExpectNil(t, large.read)
+
+	// Bodies without a declared length are limited while they are read
+	req := httptest.NewRequest(http.MethodPost, "/default", strings.NewReader(body))
+	req.ContentLength = -1
+
+	w = httptest.NewRecorder()
+	s.handleAll(w, req)
+
+	if def.read == nil {
+		t.Errorf("Expected reading beyond the limit to fail")
+	}
+}
+
+type rateLimitedProvider struct {
+	mockProvider
+	limit *httpendpoint.RateLimit
+}
+
+func (rp *rateLimitedProvider) RequestRateLimit() *httpendpoint.RateLimit {
+	return rp.limit
+}
+
+func TestRateLimit(t *testing.T) {
+
+	s := new(HTTPServer)
+	s.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	s.RateLimit = &httpendpoint.RateLimit{Enabled: true, RequestsPerSecond: 1, Burst: 2, Key: httpendpoint.RemoteAddressKey}
+	asw := new(statusRecordingAsw)
+	s.AbnormalStatusWriter = asw
+
+	def := &mockProvider{pattern: "^/default$", method: http.MethodGet}
+	keyed := &rateLimitedProvider{mockProvider: mockProvider{pattern: "^/keyed$", method: http.MethodGet},
+		limit: &httpendpoint.RateLimit{Enabled: true, RequestsPerSecond: 1, Key: httpendpoint.HeaderKey, Header: "X-Client"}}
+
+	s.SetProvidersManually(map[string]httpendpoint.Provider{"default": def, "keyed": keyed})
+
+	if err := s.StartComponent(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	s.state = ioc.RunningState
+
+	get := func(path, client string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-Client", client)
+
+		w := httptest.NewRecorder()
+		asw.status = 0
+		s.handleAll(w, req)
+
+		return w
+	}
+
+	w := get("/default", "")
+	test.ExpectInt(t, asw.status, 0)
+	test.ExpectString(t, w.Header().Get("RateLimit-Limit"), "2")
+	test.ExpectString(t, w.Header().Get("RateLimit-Remaining"), "1")
+
+	get("/default", "")
+	w = get("/default", "")
+
+	test.ExpectInt(t, asw.status, http.StatusTooManyRequests)
+	test.ExpectString(t, w.Header().Get("Retry-After"), "1")
+	test.ExpectString(t, w.Header().Get("RateLimit-Remaining"), "0")
+
+	// Providers declaring their own limit are not affected by the server's limit
+	get("/keyed", "a")
+	test.ExpectInt(t, asw.status, 0)
+
+	get("/keyed", "a")
+	test.ExpectInt(t, asw.status, http.StatusTooManyRequests)
+
+	get("/keyed", "b")
+	test.ExpectInt(t, asw.status, 0)
+
+	rc := &rateLimitsCommand{Server: s}
+
+	co, errs := rc.ExecuteCommand([]string{"keyed"}, nil)
+
+	test.ExpectInt(t, len(errs), 0)
+	test.ExpectInt(t, len(co.OutputBody), 2)
+	test.ExpectString(t, co.OutputBody[0][0], "keyed a")
+	test.ExpectString(t, co.OutputBody[0][1], "0/1")
+
+	co, _ = rc.ExecuteCommand(nil, nil)
+	test.ExpectInt(t, len(co.OutputBody), 3)
+
+	_, errs = rc.ExecuteCommand([]string{"missing"}, nil)
+	test.ExpectInt(t, len(errs), 1)
+}
+
+func TestInvalidRateLimit(t *testing.T) {
+
+	s := new(HTTPServer)
+	s.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	s.AbnormalStatusWriter = new(statusRecordingAsw)
+
+	p := &rateLimitedProvider{mockProvider: mockProvider{pattern: "^/keyed$", method: http.MethodGet},
+		limit: &httpendpoint.RateLimit{Enabled: true, RequestsPerSecond: 1, Key: httpendpoint.HeaderKey}}
+
+	s.SetProvidersManually(map[string]httpendpoint.Provider{"keyed": p})
+
+	if err := s.StartComponent(); err == nil {
+		t.Errorf("Expected an error for a HEADER limit without a header")
+	}
+}
+
+// deferringProvider enforces limits keyed by user identity itself
+type deferringProvider struct {
+	mockProvider
+	deferred *httpendpoint.RateLimiter
+}
+
+func (dp *deferringProvider) EnforcesDeferredRateLimit() bool {
+	return true
+}
+
+func (dp *deferringProvider) ServeHTTP(ctx context.Context, w *httpendpoint.HTTPResponseWriter, req *http.Request) context.Context {
+
+	dp.deferred = httpendpoint.DeferredRateLimit(ctx)
+	w.WriteHeader(http.StatusOK)
+
+	return ctx
+}
+
+func TestUserRateLimitFallback(t *testing.T) {
+
+	s := new(HTTPServer)
+	s.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	s.RateLimit = &httpendpoint.RateLimit{Enabled: true, RequestsPerSecond: 1, Key: httpendpoint.UserKey}
+	asw := new(statusRecordingAsw)
+	s.AbnormalStatusWriter = asw
+
+	def := &mockProvider{pattern: "^/default$", method: http.MethodGet}
+	deferring := &deferringProvider{mockProvider: mockProvider{pattern: "^/deferring$", method: http.MethodGet}}
+
+	s.SetProvidersManually(map[string]httpendpoint.Provider{"default": def, "deferring": deferring})
+
+	if err := s.StartComponent(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	s.state = ioc.RunningState
+
+	get := func(path string) {
+		asw.status = 0
+		s.handleAll(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	// Providers that cannot identify users are limited by remote address
+	get("/default")
+	test.ExpectInt(t, asw.status, 0)
+
+	get("/default")
+	test.ExpectInt(t, asw.status, http.StatusTooManyRequests)
+
+	// Providers that can are left to enforce the limit themselves
+	get("/deferring")
+	test.ExpectInt(t, asw.status, 0)
+	test.ExpectBool(t, deferring.deferred != nil, true)
+}
+
+type slowProvider struct {
+	mockProvider
+	timeout time.Duration
+	release chan bool
+}
+
+func (sp *slowProvider) RequestTimeout() time.Duration {
+	return sp.timeout
+}
+
+func (sp *slowProvider) ServeHTTP(ctx context.Context, w *httpendpoint.HTTPResponseWriter, req *http.Request) context.Context {
+
+	if sp.release != nil {
+		// Deliberately ignores cancellation of the context
+		<-sp.release
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	return ctx
+}
+
+// enforcingProvider responds to the client itself when its deadline passes
+type enforcingProvider struct {
+	mockProvider
+}
+
+func (ep *enforcingProvider) EnforcesDeadline() bool {
+	return true
+}
+
+func (ep *enforcingProvider) ServeHTTP(ctx context.Context, w *httpendpoint.HTTPResponseWriter, req *http.Request) context.Context {
+
+	<-ctx.Done()
+	w.WriteHeader(http.StatusGatewayTimeout)
+
+	return ctx
+}
+
+func TestRequestTimeout(t *testing.T) {
+
+	s := new(HTTPServer)
+	s.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	s.RequestTimeoutMS = 10
+	s.TimeoutStatus = http.StatusServiceUnavailable
+	asw := new(statusRecordingAsw)
+	s.AbnormalStatusWriter = asw
+
+	def := &slowProvider{mockProvider: mockProvider{pattern: "^/default$"}, release: make(chan bool)}
+	unlimited := &slowProvider{mockProvider: mockProvider{pattern: "^/unlimited$"}, timeout: -1}
+	enforcing := &enforcingProvider{mockProvider: mockProvider{pattern: "^/enforcing$"}}
+
+	s.SetProvidersManually(map[string]httpendpoint.Provider{"default": def, "unlimited": unlimited, "enforcing": enforcing})
+
+	if err := s.StartComponent(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	s.state = ioc.RunningState
+
+	// The server responds once the deadline passes, even though the provider is still running
+	w := httptest.NewRecorder()
+	s.handleAll(w, httptest.NewRequest(http.MethodGet, "/default", nil))
+
+	test.ExpectInt(t, asw.status, http.StatusServiceUnavailable)
+
+	close(def.release)
+
+	asw.status = 0
+	w = httptest.NewRecorder()
+	s.handleAll(w, httptest.NewRequest(http.MethodGet, "/unlimited", nil))
+
+	test.ExpectInt(t, asw.status, 0)
+	test.ExpectInt(t, w.Code, http.StatusOK)
+
+	w = httptest.NewRecorder()
+	s.handleAll(w, httptest.NewRequest(http.MethodGet, "/enforcing", nil))
+
+	test.ExpectInt(t, asw.status, 0)
+	test.ExpectInt(t, w.Code, http.StatusGatewayTimeout)
+}
+
+type versionedProvider struct {
+	mockProvider
+	assessor *version.RangeAssessor
+	served   string
+}
+
+func (vp *versionedProvider) SupportsVersion(v httpendpoint.RequiredVersion) bool {
+	return vp.assessor.SupportsVersion("", v)
+}
+
+func (vp *versionedProvider) ServeHTTP(ctx context.Context, w *httpendpoint.HTTPResponseWriter, req *http.Request) context.Context {
+	vp.served = req.URL.Path
+	w.WriteHeader(http.StatusOK)
+
+	return ctx
+}
+
+func TestVersionRouting(t *testing.T) {
+
+	s := new(HTTPServer)
+	s.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	s.VersionExtractor = &version.PathPrefixExtractor{Prefix: "v", Default: "1"}
+	s.UnsupportedVersionStatus = http.StatusNotAcceptable
+	asw := new(statusRecordingAsw)
+	s.AbnormalStatusWriter = asw
+
+	v1, _ := version.NewRangeAssessor("1.x", false)
+	v2, _ := version.NewRangeAssessor("^2.1", false)
+
+	p1 := &versionedProvider{mockProvider: mockProvider{pattern: "^/artist/\\d+$", versionAware: true}, assessor: v1}
+	p2 := &versionedProvider{mockProvider: mockProvider{pattern: "^/artist/\\d+$", versionAware: true}, assessor: v2}
+
+	s.SetProvidersManually(map[string]httpendpoint.Provider{"v1": p1, "v2": p2})
+
+	if err := s.StartComponent(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	s.state = ioc.RunningState
+
+	w := httptest.NewRecorder()
+	s.handleAll(w, httptest.NewRequest(http.MethodGet, "/v2.3/artist/12", nil))
+
+	test.ExpectInt(t, w.Code, http.StatusOK)
+	test.ExpectString(t, p2.served, "/artist/12")
+	test.ExpectString(t, p1.served, "")
+
+	w = httptest.NewRecorder()
+	s.handleAll(w, httptest.NewRequest(http.MethodGet, "/artist/12", nil))
+
+	test.ExpectInt(t, w.Code, http.StatusOK)
+	test.ExpectString(t, p1.served, "/artist/12")
+
+	w = httptest.NewRecorder()
+	s.handleAll(w, httptest.NewRequest(http.MethodGet, "/v3/artist/12", nil))
+
+	test.ExpectInt(t, asw.status, http.StatusNotAcceptable)
+
+	asw.status = 0
+	w = httptest.NewRecorder()
+	s.handleAll(w, httptest.NewRequest(http.MethodGet, "/v2/venue/12", nil))
+
+	test.ExpectInt(t, asw.status, http.StatusNotFound)
+
+	// Requests are logged with the path as received
+	alw, fs := logWriterWithBuffer(t, "%U")
+	s.AccessLogWriter = alw
+	s.AccessLogging = true
+
+	s.handleAll(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v2.3/artist/12", nil))
+
+	alw.Stop()
+	test.ExpectString(t, fs.buffer.String(), "/v2.3/artist/12\n")
+}
@@ -0,0 +1,264 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package httpserver
+
+import (
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// The maximum number of combined states explored when checking whether two patterns overlap. Patterns complex enough
+// to exceed this are assumed not to overlap.
+const maxOverlapStates = 10000
+
+// overlaps returns true if there is at least one path that both regular expressions would match (using
+// regexp.MatchString semantics, so unanchored expressions match anywhere in the path).
+//
+// The expressions are compiled to NFAs which are then run side by side over every distinguishable character until
+// both accept the same input or no new combinations of states can be reached. Line and word boundary assertions are
+// assumed to be satisfiable, so patterns relying on them may be reported as overlapping when they do not.
+func overlaps(a, b *regexp.Regexp) bool {
+
+	pa, err := searchProg(a.String())
+
+	if err != nil {
+		return false
+	}
+
+	pb, err := searchProg(b.String())
+
+	if err != nil {
+		return false
+	}
+
+	alphabet := representatives(pa, pb)
+
+	type state struct {
+		a, b  []uint32
+		start bool
+	}
+
+	initial := state{[]uint32{uint32(pa.Start)}, []uint32{uint32(pb.Start)}, true}
+	seen := map[string]bool{stateKey(initial.a, initial.b, true): true}
+	queue := []state{initial}
+
+	for len(queue) > 0 && len(seen) < maxOverlapStates {
+
+		s := queue[0]
+		queue = queue[1:]
+
+		if accepts(pa, s.a, s.start) && accepts(pb, s.b, s.start) {
+			return true
+		}
+
+		ca := closure(pa, s.a, s.start, false)
+		cb := closure(pb, s.b, s.start, false)
+
+		for _, r := range alphabet {
+
+			na := step(pa, ca, r)
+
+			if len(na) == 0 {
+				continue
+			}
+
+			nb := step(pb, cb, r)
+
+			if len(nb) == 0 {
+				continue
+			}
+
+			k := stateKey(na, nb, false)
+
+			if !seen[k] {
+				seen[k] = true
+				queue = append(queue, state{na, nb, false})
+			}
+		}
+	}
+
+	return false
+}
+
+// searchProg compiles a program that must consume an entire path and accepts it if the pattern matches anywhere within it.
+func searchProg(pattern string) (*syntax.Prog, error) {
+
+	re, err := syntax.Parse(`(?s:.*)(?:`+pattern+`)(?s:.*)`, syntax.Perl)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return syntax.Compile(re.Simplify())
+}
+
+// closure follows the instructions that do not consume a character from the supplied instructions, returning the set
+// of instructions that either consume a character or indicate a match.
+func closure(p *syntax.Prog, pcs []uint32, start, end bool) []uint32 {
+
+	visited := make(map[uint32]bool)
+
+	var out []uint32
+	var follow func(pc uint32)
+
+	follow = func(pc uint32) {
+
+		if visited[pc] {
+			return
+		}
+
+		visited[pc] = true
+		i := &p.Inst[pc]
+
+		switch i.Op {
+		case syntax.InstAlt, syntax.InstAltMatch:
+			follow(i.Out)
+			follow(i.Arg)
+		case syntax.InstCapture, syntax.InstNop:
+			follow(i.Out)
+		case syntax.InstEmptyWidth:
+			op := syntax.EmptyOp(i.Arg)
+
+			if (op&syntax.EmptyBeginText != 0 && !start) || (op&syntax.EmptyEndText != 0 && !end) {
+				return
+			}
+
+			follow(i.Out)
+		case syntax.InstFail:
+		default:
+			out = append(out, pc)
+		}
+	}
+
+	for _, pc := range pcs {
+		follow(pc)
+	}
+
+	return out
+}
+
+// accepts returns true if the program would accept the input if it ended in the supplied state
+func accepts(p *syntax.Prog, pcs []uint32, start bool) bool {
+
+	for _, pc := range closure(p, pcs, start, true) {
+		if p.Inst[pc].Op == syntax.InstMatch {
+			return true
+		}
+	}
+
+	return false
+}
+
+// step returns the (sorted) instructions reached by consuming the supplied character
+func step(p *syntax.Prog, pcs []uint32, r rune) []uint32 {
+
+	next := make(map[uint32]bool)
+
+	for _, pc := range pcs {
+
+		i := &p.Inst[pc]
+
+		if i.Op != syntax.InstMatch && i.MatchRune(r) {
+			next[i.Out] = true
+		}
+	}
+
+	out := make([]uint32, 0, len(next))
+
+	for pc := range next {
+		out = append(out, pc)
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+
+	return out
+}
+
+func stateKey(a, b []uint32, start bool) string {
+
+	var sb strings.Builder
+
+	for _, pc := range a {
+		sb.WriteString(strconv.FormatUint(uint64(pc), 10))
+		sb.WriteByte(',')
+	}
+
+	sb.WriteByte('|')
+
+	for _, pc := range b {
+		sb.WriteString(strconv.FormatUint(uint64(pc), 10))
+		sb.WriteByte(',')
+	}
+
+	if start {
+		sb.WriteByte('^')
+	}
+
+	return sb.String()
+}
+
+// representatives divides the characters into ranges that every instruction in the supplied programs either matches
+// entirely or not at all, and returns the first character of each range.
+func representatives(progs ...*syntax.Prog) []rune {
+
+	bounds := map[rune]bool{0: true}
+
+	add := func(lo, hi rune) {
+		bounds[lo] = true
+
+		if hi < unicode.MaxRune {
+			bounds[hi+1] = true
+		}
+	}
+
+	for _, p := range progs {
+		for _, i := range p.Inst {
+
+			switch i.Op {
+			case syntax.InstRune1:
+				add(i.Rune[0], i.Rune[0])
+			case syntax.InstRuneAnyNotNL:
+				add('\n', '\n')
+			case syntax.InstRune:
+
+				if len(i.Rune) == 1 && syntax.Flags(i.Arg)&syntax.FoldCase != 0 {
+					// Case folded single characters match every character in their fold orbit
+					r := i.Rune[0]
+
+					for f := unicode.SimpleFold(r); ; f = unicode.SimpleFold(f) {
+						add(f, f)
+
+						if f == r {
+							break
+						}
+					}
+
+					continue
+				}
+
+				for j := 0; j+1 < len(i.Rune); j += 2 {
+					add(i.Rune[j], i.Rune[j+1])
+				}
+
+				if len(i.Rune) == 1 {
+					add(i.Rune[0], i.Rune[0])
+				}
+			}
+		}
+	}
+
+	rs := make([]rune, 0, len(bounds))
+
+	for r := range bounds {
+		rs = append(rs, r)
+	}
+
+	sort.Slice(rs, func(i, j int) bool { return rs[i] < rs[j] })
+
+	return rs
+}
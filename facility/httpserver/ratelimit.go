@@ -0,0 +1,187 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package httpserver
+
+import (
+	"context"
+	"fmt"
+	"github.com/graniticio/granitic/v2/ctl"
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/ws"
+	"net/http"
+	"sort"
+)
+
+const (
+	rateLimitsCommandName = "rate-limits"
+	rateLimitsSummary     = "Shows the number of requests each client can currently make before being rate limited."
+	rateLimitsUsage       = "rate-limits [limiter]"
+	rateLimitsHelp        = "Lists the token bucket of every client currently tracked by the HTTP server's rate limiters, showing " +
+		"the requests remaining and the maximum burst. The server-wide limiter is named " + serverRateLimiterName + ", other limiters " +
+		"are named after the handler that declared them. Supply a limiter name to only show that limiter's clients. Clients whose " +
+		"allowance has been fully restored are periodically discarded and will not be shown."
+)
+
+// The name under which the limiter configured by HTTPServer.RateLimit is stored
+const serverRateLimiterName = "HTTPServer"
+
+// configureRateLimit validates the server-wide rate limit and creates a limiter for it
+func (h *HTTPServer) configureRateLimit() error {
+
+	if h.RateLimit == nil || !h.RateLimit.Enabled {
+		return nil
+	}
+
+	if err := h.RateLimit.Validate(); err != nil {
+		return fmt.Errorf("HTTPServer.RateLimit is invalid: %s", err.Error())
+	}
+
+	h.rateLimiters[serverRateLimiterName] = httpendpoint.NewRateLimiter(*h.RateLimit)
+
+	return nil
+}
+
+// limiterFor returns the limiter that applies to the supplied provider. Providers that declare their own limit are given
+// their own limiter, all other providers share the server-wide limiter (if one is configured).
+func (h *HTTPServer) limiterFor(name string, p httpendpoint.Provider) (*httpendpoint.RateLimiter, error) {
+
+	rlp, found := p.(httpendpoint.RateLimitedProvider)
+
+	if !found || rlp.RequestRateLimit() == nil {
+		return h.rateLimiters[serverRateLimiterName], nil
+	}
+
+	if l := h.rateLimiters[name]; l != nil {
+		return l, nil
+	}
+
+	rl := *rlp.RequestRateLimit()
+
+	if !rl.Enabled {
+		return nil, nil
+	}
+
+	if err := rl.Validate(); err != nil {
+		return nil, fmt.Errorf("rate limit for %s is invalid: %s", name, err.Error())
+	}
+
+	l := httpendpoint.NewRateLimiter(rl)
+	h.rateLimiters[name] = l
+
+	return l, nil
+}
+
+// limitRate applies the rate limit (if any) for the supplied provider. If the client has exceeded its limit, a 429 response
+// is written and false is returned. Limits keyed by user identity are stored in the returned context to be enforced by the
+// provider or, if the provider does not enforce such limits, are applied by remote address.
+func (h *HTTPServer) limitRate(ctx context.Context, req *http.Request, wrw *httpendpoint.HTTPResponseWriter, rp *registeredProvider) (context.Context, bool) {
+
+	l := rp.limiter
+
+	if l == nil {
+		return ctx, true
+	}
+
+	if l.Limit().Key == httpendpoint.UserKey {
+
+		if e, found := rp.Provider.(httpendpoint.DeferredRateLimitEnforcer); found && e.EnforcesDeferredRateLimit() {
+			return httpendpoint.WithDeferredRateLimit(ctx, l), true
+		}
+	}
+
+	key := l.KeyFor(req)
+
+	if key == "" {
+		key = httpendpoint.RemoteHost(req)
+	}
+	d := l.Take(key)
+
+	d.WriteHeaders(wrw)
+
+	if d.Allowed {
+		return ctx, true
+	}
+
+	h.FrameworkLogger.LogDebugfCtx(ctx, "Rate limited request for %s from %s", req.URL.Path, key)
+	h.writeAbnormal(ctx, http.StatusTooManyRequests, wrw)
+
+	return ctx, false
+}
+
+// RateLimitStats returns the current state of every client bucket, grouped by the name of the limiter that holds them.
+func (h *HTTPServer) RateLimitStats() map[string][]httpendpoint.BucketStats {
+
+	stats := make(map[string][]httpendpoint.BucketStats)
+
+	for name, l := range h.rateLimiters {
+		stats[name] = l.Stats()
+	}
+
+	return stats
+}
+
+// rateLimitsCommand is a ctl.Command that shows the state of the HTTPServer's rate limiters
+type rateLimitsCommand struct {
+	Server *HTTPServer
+}
+
+func (rc *rateLimitsCommand) ExecuteCommand(qualifiers []string, args map[string]string) (*ctl.CommandOutput, []*ws.CategorisedError) {
+
+	stats := rc.Server.RateLimitStats()
+
+	if len(qualifiers) > 1 {
+		return nil, []*ws.CategorisedError{ctl.NewCommandClientError("Only one limiter name can be supplied.")}
+	}
+
+	var names []string
+
+	if len(qualifiers) == 1 {
+
+		if _, found := stats[qualifiers[0]]; !found {
+			m := fmt.Sprintf("%s is not the name of a rate limiter.", qualifiers[0])
+			return nil, []*ws.CategorisedError{ctl.NewCommandClientError(m)}
+		}
+
+		names = qualifiers
+
+	} else {
+
+		for name := range stats {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+	}
+
+	rows := make([][]string, 0)
+
+	for _, name := range names {
+		for _, bs := range stats[name] {
+			rows = append(rows, []string{name + " " + bs.Key, fmt.Sprintf("%d/%d", bs.Remaining, bs.Limit)})
+		}
+	}
+
+	co := new(ctl.CommandOutput)
+	co.OutputHeader = "Limiter and client, requests remaining/burst"
+	co.OutputBody = rows
+	co.RenderHint = ctl.Columns
+
+	return co, nil
+}
+
+func (rc *rateLimitsCommand) Name() string {
+	return rateLimitsCommandName
+}
+
+func (rc *rateLimitsCommand) Summmary() string {
+	return rateLimitsSummary
+}
+
+func (rc *rateLimitsCommand) Usage() string {
+	return rateLimitsUsage
+}
+
+func (rc *rateLimitsCommand) Help() []string {
+	return []string{rateLimitsHelp}
+}
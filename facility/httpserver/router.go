@@ -0,0 +1,454 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package httpserver
+
+import (
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"regexp/syntax"
+	"sort"
+	"strings"
+)
+
+// The order in which parameter segments are tried when more than one could match a segment of a request path. Lower
+// values are tried first. Parameters of the path type are always tried last.
+var paramPrecedence = map[string]int{
+	"uuid":   0,
+	"uint":   1,
+	"int":    2,
+	"float":  3,
+	"bool":   4,
+	"alpha":  5,
+	"string": 6,
+}
+
+const pathParamType = "path"
+
+/*
+router finds the registered provider that should handle a request.
+
+Providers whose paths are declared with a path template (or whose regular expression is just a literal path) are stored in
+a tree of path segments for each HTTP method, so the cost of finding a provider depends on the number of segments in the
+request's path rather than on the number of providers. Other providers are only considered if no match is found in the
+tree, and their regular expressions are tested in the order the providers were registered.
+
+When more than one branch of the tree could match a request, branches are tried in this order:
+
+ 1. Literal segments
+ 2. Typed parameters (uuid, uint, int, float, bool, alpha)
+ 3. Untyped (string) parameters
+ 4. Path parameters (which match the remainder of the path)
+
+If a branch leads to a provider that will not accept the request (e.g. because it doesn't support the requested version)
+the next branch is tried. Providers at the same point in the tree are tried in the order they were registered.
+
+Because each request is only passed to one provider, providers that would otherwise be chosen based on the order in
+which they were registered are not allowed (see conflict).
+*/
+type router struct {
+	trees    map[string]*routeNode
+	fallback map[string][]*registeredProvider
+}
+
+func newRouter() *router {
+	r := new(router)
+	r.trees = make(map[string]*routeNode)
+	r.fallback = make(map[string][]*registeredProvider)
+
+	return r
+}
+
+// A node in the tree of path segments
+type routeNode struct {
+	// The type of parameter matched by this node (empty for literal nodes)
+	paramType string
+
+	literals map[string]*routeNode
+	params   []*routeNode
+	rest     *routeNode
+
+	routes []*route
+}
+
+// A provider registered at a point in the tree
+type route struct {
+	provider *registeredProvider
+
+	// If true, the request path must not have a trailing slash
+	strict bool
+}
+
+// add registers a provider for the supplied method, in the tree if possible
+func (r *router) add(method string, rp *registeredProvider) {
+
+	segments, strict, found := treeSegments(rp)
+
+	if !found {
+		r.fallback[method] = append(r.fallback[method], rp)
+		return
+	}
+
+	n := r.trees[method]
+
+	if n == nil {
+		n = new(routeNode)
+		r.trees[method] = n
+	}
+
+	for _, s := range segments {
+		n = n.child(s)
+	}
+
+	n.routes = append(n.routes, &route{provider: rp, strict: strict})
+}
+
+// conflict returns a provider already registered for the supplied method that could match some of the same requests
+// as the supplied provider where the order of registration, rather than the routing rules, would decide which of them
+// serves the request. That is the case if both providers are at the same point in the tree, or if at least one of them
+// is not in the tree and their patterns overlap. Version aware providers never conflict, as the requested version decides.
+func (r *router) conflict(method string, rp *registeredProvider) *registeredProvider {
+
+	if rp.Provider.VersionAware() {
+		return nil
+	}
+
+	segments, _, inTree := treeSegments(rp)
+
+	if inTree {
+
+		if n := r.trees[method].lookup(segments); n != nil {
+			for _, existing := range n.routes {
+				if !existing.provider.Provider.VersionAware() {
+					return existing.provider
+				}
+			}
+		}
+
+	} else if n := r.trees[method]; n != nil {
+
+		if existing := n.overlapping(rp); existing != nil {
+			return existing
+		}
+	}
+
+	for _, existing := range r.fallback[method] {
+		if !existing.Provider.VersionAware() && overlaps(existing.Pattern, rp.Pattern) {
+			return existing
+		}
+	}
+
+	return nil
+}
+
+// find returns the first provider that matches the request's method and path and is accepted by the supplied function.
+// Returns nil if no provider matches.
+func (r *router) find(method, path string, accept func(*registeredProvider) bool) *registeredProvider {
+
+	if n := r.trees[method]; n != nil && strings.HasPrefix(path, "/") {
+
+		trailing := path != "/" && strings.HasSuffix(path, "/")
+
+		p := path
+
+		if trailing {
+			p = strings.TrimSuffix(p, "/")
+		}
+
+		var segments []string
+
+		if p != "/" {
+			segments = strings.Split(p[1:], "/")
+		}
+
+		if rp := n.find(segments, trailing, accept); rp != nil {
+			return rp
+		}
+	}
+
+	for _, rp := range r.fallback[method] {
+		if rp.Pattern.MatchString(path) && accept(rp) {
+			return rp
+		}
+	}
+
+	return nil
+}
+
+// allowed returns the (sorted) HTTP methods for which a provider matches the supplied path and is accepted by the
+// supplied function.
+func (r *router) allowed(path string, accept func(*registeredProvider) bool) []string {
+
+	var methods []string
+
+	for _, m := range r.methods() {
+		if r.find(m, path, accept) != nil {
+			methods = append(methods, m)
+		}
+	}
+
+	return methods
+}
+
+// methods returns the (sorted) HTTP methods for which at least one provider is registered
+func (r *router) methods() []string {
+
+	found := make(map[string]bool)
+
+	for m := range r.trees {
+		found[m] = true
+	}
+
+	for m := range r.fallback {
+		found[m] = true
+	}
+
+	var methods []string
+
+	for m := range found {
+		methods = append(methods, m)
+	}
+
+	sort.Strings(methods)
+
+	return methods
+}
+
+func (n *routeNode) find(segments []string, trailing bool, accept func(*registeredProvider) bool) *registeredProvider {
+
+	if len(segments) == 0 {
+
+		for _, r := range n.routes {
+			if !(r.strict && trailing) && accept(r.provider) {
+				return r.provider
+			}
+		}
+
+		return nil
+	}
+
+	s := segments[0]
+
+	if c := n.literals[s]; c != nil {
+		if rp := c.find(segments[1:], trailing, accept); rp != nil {
+			return rp
+		}
+	}
+
+	if s != "" {
+		for _, c := range n.params {
+			if c.matches(s) {
+				if rp := c.find(segments[1:], trailing, accept); rp != nil {
+					return rp
+				}
+			}
+		}
+	}
+
+	if n.rest != nil {
+		// Path parameters match the remainder of the path (which must not be empty)
+		for _, r := range n.rest.routes {
+			if accept(r.provider) {
+				return r.provider
+			}
+		}
+	}
+
+	return nil
+}
+
+// lookup finds the node at the end of the supplied template segments, or nil if no provider has been registered there
+func (n *routeNode) lookup(segments []httpendpoint.PathSegment) *routeNode {
+
+	for _, s := range segments {
+
+		if n == nil {
+			return nil
+		}
+
+		switch {
+		case !s.IsParam():
+			n = n.literals[s.Literal]
+		case s.Type == pathParamType:
+			n = n.rest
+		default:
+			var c *routeNode
+
+			for _, p := range n.params {
+				if p.paramType == s.Type {
+					c = p
+				}
+			}
+
+			n = c
+		}
+	}
+
+	return n
+}
+
+// overlapping returns a provider at or below this node whose pattern overlaps the supplied provider's pattern
+func (n *routeNode) overlapping(rp *registeredProvider) *registeredProvider {
+
+	for _, r := range n.routes {
+		if !r.provider.Provider.VersionAware() && overlaps(r.provider.Pattern, rp.Pattern) {
+			return r.provider
+		}
+	}
+
+	children := append([]*routeNode{}, n.params...)
+
+	if n.rest != nil {
+		children = append(children, n.rest)
+	}
+
+	for _, c := range n.literals {
+		children = append(children, c)
+	}
+
+	for _, c := range children {
+		if existing := c.overlapping(rp); existing != nil {
+			return existing
+		}
+	}
+
+	return nil
+}
+
+func (n *routeNode) matches(segment string) bool {
+	return httpendpoint.PathSegment{Param: n.paramType, Type: n.paramType}.Matches(segment)
+}
+
+// child finds or creates the child node for the supplied segment of a template
+func (n *routeNode) child(s httpendpoint.PathSegment) *routeNode {
+
+	if !s.IsParam() {
+
+		if n.literals == nil {
+			n.literals = make(map[string]*routeNode)
+		}
+
+		c := n.literals[s.Literal]
+
+		if c == nil {
+			c = new(routeNode)
+			n.literals[s.Literal] = c
+		}
+
+		return c
+	}
+
+	if s.Type == pathParamType {
+
+		if n.rest == nil {
+			n.rest = &routeNode{paramType: pathParamType}
+		}
+
+		return n.rest
+	}
+
+	for _, c := range n.params {
+		if c.paramType == s.Type {
+			return c
+		}
+	}
+
+	c := &routeNode{paramType: s.Type}
+	n.params = append(n.params, c)
+
+	sort.SliceStable(n.params, func(i, j int) bool {
+		return paramPrecedence[n.params[i].paramType] < paramPrecedence[n.params[j].paramType]
+	})
+
+	return c
+}
+
+// treeSegments determines whether or not a provider can be stored in the tree and, if so, the segments of its path and
+// whether or not a trailing slash is forbidden.
+func treeSegments(rp *registeredProvider) ([]httpendpoint.PathSegment, bool, bool) {
+
+	if tp, found := rp.Provider.(httpendpoint.TemplatedProvider); found {
+		if pt := tp.ParsedPathTemplate(); pt != nil {
+			// Templates allow an optional trailing slash, except for the root template
+			return pt.Segments, len(pt.Segments) == 0, true
+		}
+	}
+
+	path, strict, found := literalPath(rp.Pattern.String())
+
+	if !found {
+		return nil, false, false
+	}
+
+	var segments []httpendpoint.PathSegment
+
+	if path != "/" {
+		for _, l := range strings.Split(path[1:], "/") {
+			segments = append(segments, httpendpoint.PathSegment{Literal: l})
+		}
+	}
+
+	return segments, strict, true
+}
+
+// literalPath checks to see if a regular expression only matches a single literal path, with an optional trailing
+// slash, e.g. ^/health-check$ or ^/artists[/]?$
+func literalPath(pattern string) (path string, strict bool, found bool) {
+
+	re, err := syntax.Parse(pattern, syntax.Perl)
+
+	if err != nil {
+		return "", false, false
+	}
+
+	re = re.Simplify()
+
+	if re.Op != syntax.OpConcat || len(re.Sub) < 3 {
+		return "", false, false
+	}
+
+	subs := re.Sub
+
+	if subs[0].Op != syntax.OpBeginText || subs[len(subs)-1].Op != syntax.OpEndText {
+		return "", false, false
+	}
+
+	subs = subs[1 : len(subs)-1]
+	strict = true
+
+	if last := subs[len(subs)-1]; last.Op == syntax.OpQuest && isLiteral(last.Sub[0], "/") {
+		strict = false
+		subs = subs[:len(subs)-1]
+	}
+
+	var b strings.Builder
+
+	for _, s := range subs {
+
+		if s.Op != syntax.OpLiteral || s.Flags&syntax.FoldCase != 0 {
+			return "", false, false
+		}
+
+		b.WriteString(string(s.Rune))
+	}
+
+	path = b.String()
+
+	if !strings.HasPrefix(path, "/") || (path != "/" && strings.HasSuffix(path, "/")) {
+		return "", false, false
+	}
+
+	return path, strict, true
+}
+
+func isLiteral(re *syntax.Regexp, s string) bool {
+
+	if re.Op == syntax.OpLiteral && re.Flags&syntax.FoldCase == 0 {
+		return string(re.Rune) == s
+	}
+
+	if re.Op == syntax.OpCharClass && len(re.Rune) == 2 && re.Rune[0] == re.Rune[1] {
+		return string(re.Rune[0]) == s
+	}
+
+	return false
+}
@@ -0,0 +1,288 @@
+package httpserver
+
+import (
+	"fmt"
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/test"
+	"net/http"
+	"regexp"
+	"testing"
+)
+
+type mockTemplatedProvider struct {
+	mockProvider
+	template *httpendpoint.PathTemplate
+}
+
+func (mp *mockTemplatedProvider) ParsedPathTemplate() *httpendpoint.PathTemplate {
+	return mp.template
+}
+
+func templatedProvider(t testing.TB, template string) *registeredProvider {
+
+	pt, err := httpendpoint.ParsePathTemplate(template)
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	p := &mockTemplatedProvider{template: pt}
+	p.pattern = pt.Regex()
+
+	return &registeredProvider{Provider: p, Pattern: regexp.MustCompile(p.pattern), Name: template}
+}
+
+func regexProvider(pattern string) *registeredProvider {
+	return &registeredProvider{Provider: &mockProvider{pattern: pattern}, Pattern: regexp.MustCompile(pattern), Name: pattern}
+}
+
+func acceptAll(rp *registeredProvider) bool {
+	return true
+}
+
+func matchedName(r *router, path string) string {
+
+	rp := r.find(http.MethodGet, path, acceptAll)
+
+	if rp == nil {
+		return ""
+	}
+
+	return rp.Name
+}
+
+func TestLiteralPathDetection(t *testing.T) {
+
+	literals := map[string]bool{
+		"^/health-check$":   true,
+		"^/openapi\\.json$": true,
+		"^/artists[/]?$":    false,
+		"^/artists/?$":      false,
+		"^/$":               true,
+	}
+
+	for pattern, strict := range literals {
+
+		_, s, found := literalPath(pattern)
+
+		if !found {
+			t.Errorf("Expected %s to be treated as a literal path", pattern)
+		}
+
+		test.ExpectBool(t, s, strict)
+	}
+
+	for _, pattern := range []string{"^/artist", "/artist$", "^/artist/(\\d+)$", "^/Artist(?i)s$", "^/a.b$", "^/artists/$"} {
+		if _, _, found := literalPath(pattern); found {
+			t.Errorf("Did not expect %s to be treated as a literal path", pattern)
+		}
+	}
+}
+
+func TestRouterPrecedence(t *testing.T) {
+
+	r := newRouter()
+
+	for _, tmpl := range []string{"/users/{name}", "/users/{id:int}", "/users/me", "/users/{id:uuid}", "/files/{name:path}", "/files/readme", "/"} {
+		r.add(http.MethodGet, templatedProvider(t, tmpl))
+	}
+
+	r.add(http.MethodGet, regexProvider("^/users/special$"))
+	r.add(http.MethodGet, regexProvider("^/users/(x|y)/orders$"))
+
+	test.ExpectString(t, matchedName(r, "/users/me"), "/users/me")
+	test.ExpectString(t, matchedName(r, "/users/me/"), "/users/me")
+	test.ExpectString(t, matchedName(r, "/users/42"), "/users/{id:int}")
+	test.ExpectString(t, matchedName(r, "/users/-42"), "/users/{id:int}")
+	test.ExpectString(t, matchedName(r, "/users/bob"), "/users/{name}")
+	test.ExpectString(t, matchedName(r, "/users/special"), "^/users/special$")
+	test.ExpectString(t, matchedName(r, "/users/123e4567-e89b-12d3-a456-426614174000"), "/users/{id:uuid}")
+	test.ExpectString(t, matchedName(r, "/files/readme"), "/files/readme")
+	test.ExpectString(t, matchedName(r, "/files/a/b/c.txt"), "/files/{name:path}")
+	test.ExpectString(t, matchedName(r, "/users/x/orders"), "^/users/(x|y)/orders$")
+	test.ExpectString(t, matchedName(r, "/"), "/")
+	test.ExpectString(t, matchedName(r, "/users"), "")
+	test.ExpectString(t, matchedName(r, "/users//"), "")
+	test.ExpectString(t, matchedName(r, "/files/"), "")
+
+	if r.find(http.MethodPost, "/users/me", acceptAll) != nil {
+		t.Errorf("Did not expect a match for a different method")
+	}
+}
+
+func TestRouterBacktracking(t *testing.T) {
+
+	r := newRouter()
+
+	r.add(http.MethodGet, templatedProvider(t, "/users/{id:int}/orders"))
+	r.add(http.MethodGet, templatedProvider(t, "/users/{name}/profile"))
+
+	test.ExpectString(t, matchedName(r, "/users/42/orders"), "/users/{id:int}/orders")
+	test.ExpectString(t, matchedName(r, "/users/42/profile"), "/users/{name}/profile")
+
+	// Providers that refuse a request (e.g. because of versioning) cause the next candidate to be tried
+	r = newRouter()
+
+	v1 := templatedProvider(t, "/users/{id}")
+	v2 := templatedProvider(t, "/users/{id}")
+	v2.Name = "v2"
+
+	r.add(http.MethodGet, v1)
+	r.add(http.MethodGet, v2)
+
+	rp := r.find(http.MethodGet, "/users/1", func(rp *registeredProvider) bool {
+		return rp.Name == "v2"
+	})
+
+	test.ExpectString(t, rp.Name, "v2")
+}
+
+// The tree must match exactly the same paths as the regular expressions it replaces
+func TestRouterConsistentWithRegex(t *testing.T) {
+
+	templates := []string{"/", "/a", "/a/{b}", "/a/{b:int}/c", "/a/{b:bool}/{c:float}", "/p/{rest:path}", "/u/{id:uint}", "/x/{y:alpha}"}
+	literals := []string{"^/lit$", "^/lit/more[/]?$"}
+
+	paths := []string{"/", "//", "/a", "/a/", "/a//", "/a/b", "/a/b/", "/a/1/c", "/a/-1/c/", "/a/1.5/c", "/a/TRUE/1.5",
+		"/a/true/.5", "/a/false/x", "/p", "/p/", "/p//", "/p/x", "/p/x/y/", "/u/1", "/u/-1", "/x/abc", "/x/ab1",
+		"/lit", "/lit/", "/lit/more", "/lit/more/", "/lit/more//", "a", ""}
+
+	var providers []*registeredProvider
+
+	for _, tmpl := range templates {
+		providers = append(providers, templatedProvider(t, tmpl))
+	}
+
+	for _, l := range literals {
+		providers = append(providers, regexProvider(l))
+	}
+
+	for _, rp := range providers {
+
+		r := newRouter()
+		r.add(http.MethodGet, rp)
+
+		if len(r.fallback[http.MethodGet]) > 0 {
+			t.Errorf("Expected %s to be stored in the tree", rp.Name)
+		}
+
+		for _, p := range paths {
+
+			expected := rp.Pattern.MatchString(p)
+			actual := r.find(http.MethodGet, p, acceptAll) != nil
+
+			if expected != actual {
+				t.Errorf("%s and path %s: regex match %v, tree match %v", rp.Name, p, expected, actual)
+			}
+		}
+	}
+}
+
+func TestOverlaps(t *testing.T) {
+
+	cases := []struct {
+		a, b     string
+		expected bool
+	}{
+		{"^/users/([^/]+)[/]?$", "^/users/([^/]+)[/]?$", true},
+		{"^/users/([0-9]+)$", "^/users/me$", false},
+		{"^/users/([0-9]+)$", "^/users/(.*)$", true},
+		{"^/users$", "^/users/?$", true},
+		{"^/users$", "^/orders$", false},
+		{"/users", "^/api/users/1$", true},
+		{"^/users", "^/api/users$", false},
+		{"^/USERS$", "(?i)^/users$", true},
+		{"^/a/[a-z]+$", "^/a/[0-9]+$", false},
+		{"^/a/x$", "^/a/[^x]$", false},
+		{"^/a$", "^/a.$", false},
+		{"^/report/(2020|2021)$", "^/report/20[2-9][1-9]$", true},
+	}
+
+	for _, c := range cases {
+
+		if actual := overlaps(regexp.MustCompile(c.a), regexp.MustCompile(c.b)); actual != c.expected {
+			t.Errorf("%s and %s: expected overlap %v, got %v", c.a, c.b, c.expected, actual)
+		}
+	}
+}
+
+func TestRouterConflicts(t *testing.T) {
+
+	r := newRouter()
+
+	add := func(rp *registeredProvider) *registeredProvider {
+
+		c := r.conflict(http.MethodGet, rp)
+
+		if c == nil {
+			r.add(http.MethodGet, rp)
+		}
+
+		return c
+	}
+
+	test.ExpectBool(t, add(templatedProvider(t, "/users/{id:int}")) == nil, true)
+	test.ExpectBool(t, add(templatedProvider(t, "/users/{name}")) == nil, true)
+	test.ExpectBool(t, add(regexProvider("^/users/me$")) == nil, true)
+	test.ExpectBool(t, add(regexProvider("^/orders/[0-9]+$")) == nil, true)
+
+	// The same point in the tree
+	test.ExpectString(t, add(templatedProvider(t, "/users/{other:int}")).Name, "/users/{id:int}")
+	test.ExpectString(t, add(regexProvider("^/users/me/?$")).Name, "^/users/me$")
+
+	// Regular expressions that overlap with providers in or out of the tree
+	test.ExpectString(t, add(regexProvider("^/users/.*$")).Name, "/users/{id:int}")
+	test.ExpectString(t, add(regexProvider("^/orders/1[0-9]*$")).Name, "^/orders/[0-9]+$")
+	test.ExpectString(t, add(templatedProvider(t, "/orders/{id}")).Name, "^/orders/[0-9]+$")
+
+	// Version aware providers are chosen by version, not by order of registration
+	vp := regexProvider("^/users/.*$")
+	vp.Provider.(*mockProvider).versionAware = true
+
+	test.ExpectBool(t, add(vp) == nil, true)
+	test.ExpectBool(t, add(regexProvider("^/artists/.*$")) == nil, true)
+}
+
+func BenchmarkRouter(b *testing.B) {
+
+	for _, count := range []int{10, 100, 1000, 10000} {
+
+		r := newRouter()
+
+		for i := 0; i < count; i++ {
+			r.add(http.MethodGet, templatedProvider(b, fmt.Sprintf("/resource%d/{id:int}/child/{name}", i)))
+		}
+
+		path := fmt.Sprintf("/resource%d/42/child/abc", count-1)
+
+		b.Run(fmt.Sprintf("templates-%d", count), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if r.find(http.MethodGet, path, acceptAll) == nil {
+					b.Fatal("No match")
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkRegexFallback(b *testing.B) {
+
+	for _, count := range []int{10, 100, 1000} {
+
+		r := newRouter()
+
+		for i := 0; i < count; i++ {
+			r.add(http.MethodGet, regexProvider(fmt.Sprintf("^/resource%d/(\\d+)/child/([^/]+)$", i)))
+		}
+
+		path := fmt.Sprintf("/resource%d/42/child/abc", count-1)
+
+		b.Run(fmt.Sprintf("regex-%d", count), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if r.find(http.MethodGet, path, acceptAll) == nil {
+					b.Fatal("No match")
+				}
+			}
+		})
+	}
+}
@@ -88,6 +88,7 @@ const (
 	queryString    = "QUERY"
 	status         = "STATUS"
 	bytesOut       = "BYTES_OUT"
+	bytesRaw       = "BYTES_UNCOMPRESSED"
 	processingTime = "PROCESS_TIME"
 	reqLine        = "REQUEST_LINE"
 	text           = "TEXT"
@@ -103,7 +104,7 @@ const (
 // ValidateJSONFields checks that the configuration of a JSON application log entry is correct
 func ValidateJSONFields(fields []*AccessLogJSONField) error {
 
-	allowed := types.NewOrderedStringSet([]string{ctxVal, remote, reqHeader, received, httpMethod, reqPath, queryString, status, bytesOut, processingTime, reqLine, text, inst})
+	allowed := types.NewOrderedStringSet([]string{ctxVal, remote, reqHeader, received, httpMethod, reqPath, queryString, status, bytesOut, bytesRaw, processingTime, reqLine, text, inst})
 
 	argNeeded := types.NewOrderedStringSet([]string{ctxVal, reqHeader, received, processingTime, text})
 
@@ -195,6 +196,8 @@ func CreateMapBuilder(cfg *AccessLogJSONConfig) (*AccessLogMapBuilder, error) {
 			f.generator = mb.statusGenerator
 		case bytesOut:
 			f.generator = mb.bytesOutGenerator
+		case bytesRaw:
+			f.generator = mb.bytesUncompressedGenerator
 		case processingTime:
 			if f.Arg == seconds {
 				f.generator = mb.processSecondsGenerator
@@ -306,6 +309,10 @@ func (mb *AccessLogMapBuilder) bytesOutGenerator(lineContext *lineContext, field
 	return strconv.Itoa(lineContext.ResponseWriter.BytesServed)
 }
 
+func (mb *AccessLogMapBuilder) bytesUncompressedGenerator(lineContext *lineContext, field *AccessLogJSONField) interface{} {
+	return strconv.Itoa(lineContext.ResponseWriter.UncompressedBytes)
+}
+
 func (mb *AccessLogMapBuilder) processSecondsGenerator(lineContext *lineContext, field *AccessLogJSONField) interface{} {
 	return processTimeGen(lineContext.Received, lineContext.Finished, time.Second)
 }
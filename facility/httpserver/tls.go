@@ -0,0 +1,258 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package httpserver
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"github.com/graniticio/granitic/v2/ctl"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/ws"
+	"io/ioutil"
+	"strings"
+	"sync"
+)
+
+const (
+	reloadTLSCommandName = "reload-tls"
+	reloadTLSSummary     = "Reloads the HTTP server's TLS certificate, key and client CA bundle from disk."
+	reloadTLSUsage       = "reload-tls"
+	reloadTLSHelp        = "Causes the HTTP server to re-read the files named in HTTPServer.TLS.CertificateFile, KeyFile and ClientCAFile. " +
+		"New connections will use the reloaded certificates. If any of the files cannot be loaded, the server continues to use the existing certificates."
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+var clientAuthModes = map[string]tls.ClientAuthType{
+	"NONE":               tls.NoClientCert,
+	"REQUEST":            tls.RequestClientCert,
+	"REQUIRE":            tls.RequireAnyClientCert,
+	"VERIFY_IF_GIVEN":    tls.VerifyClientCertIfGiven,
+	"REQUIRE_AND_VERIFY": tls.RequireAndVerifyClientCert,
+}
+
+// TLSConfig holds the settings that allow the HTTPServer to accept HTTPS connections and, optionally, to verify the
+// certificates presented by clients (mutual TLS).
+type TLSConfig struct {
+	// Whether or not the server should only accept TLS connections.
+	Enabled bool
+
+	// Path to a PEM encoded certificate (and any intermediate certificates).
+	CertificateFile string
+
+	// Path to the PEM encoded private key for the certificate.
+	KeyFile string
+
+	// The minimum version of TLS that will be accepted (1.0, 1.1, 1.2 or 1.3). Defaults to 1.2
+	MinVersion string
+
+	// The names of the cipher suites (e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256) that may be used with TLS 1.2 and
+	// earlier. If empty, Go's default suites are used.
+	CipherSuites []string
+
+	// Path to a PEM encoded bundle of CA certificates used to verify client certificates.
+	ClientCAFile string
+
+	// Whether client certificates are requested and verified (NONE, REQUEST, REQUIRE, VERIFY_IF_GIVEN or REQUIRE_AND_VERIFY).
+	// Defaults to REQUIRE_AND_VERIFY if ClientCAFile is set and NONE otherwise.
+	ClientAuth string
+}
+
+// tlsManager builds a tls.Config from a TLSConfig and allows the certificates it uses to be reloaded while the server is running.
+type tlsManager struct {
+	config  *TLSConfig
+	log     logging.Logger
+	mutex   sync.RWMutex
+	current *tls.Config
+}
+
+func newTLSManager(c *TLSConfig, log logging.Logger) (*tlsManager, error) {
+
+	tm := &tlsManager{config: c, log: log}
+
+	if err := tm.reload(); err != nil {
+		return nil, err
+	}
+
+	return tm, nil
+}
+
+// serverConfig returns the tls.Config to be set on the http.Server. Each new connection uses the most recently loaded configuration.
+func (tm *tlsManager) serverConfig() *tls.Config {
+
+	return &tls.Config{
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			tm.mutex.RLock()
+			defer tm.mutex.RUnlock()
+
+			return tm.current, nil
+		},
+	}
+}
+
+// reload reads the certificate, key and client CA files and, if they are all valid, replaces the current configuration.
+func (tm *tlsManager) reload() error {
+
+	c := tm.config
+
+	if c.CertificateFile == "" || c.KeyFile == "" {
+		return errors.New("HTTPServer.TLS.CertificateFile and HTTPServer.TLS.KeyFile must be set when TLS is enabled")
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.CertificateFile, c.KeyFile)
+
+	if err != nil {
+		return fmt.Errorf("unable to load TLS certificate and key: %s", err.Error())
+	}
+
+	tc := new(tls.Config)
+	tc.Certificates = []tls.Certificate{cert}
+	tc.NextProtos = []string{"h2", "http/1.1"}
+
+	if tc.MinVersion, err = minTLSVersion(c.MinVersion); err != nil {
+		return err
+	}
+
+	if tc.CipherSuites, err = cipherSuites(c.CipherSuites); err != nil {
+		return err
+	}
+
+	if tc.ClientAuth, err = clientAuth(c); err != nil {
+		return err
+	}
+
+	if c.ClientCAFile != "" {
+
+		pem, err := ioutil.ReadFile(c.ClientCAFile)
+
+		if err != nil {
+			return fmt.Errorf("unable to read client CA file: %s", err.Error())
+		}
+
+		pool := x509.NewCertPool()
+
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates could be parsed from client CA file %s", c.ClientCAFile)
+		}
+
+		tc.ClientCAs = pool
+	}
+
+	if leaf, err := x509.ParseCertificate(cert.Certificate[0]); err == nil {
+		tm.log.LogInfof("Loaded TLS certificate for %s (expires %s)", leaf.Subject.CommonName, leaf.NotAfter.Format("2006-01-02"))
+	}
+
+	tm.mutex.Lock()
+	tm.current = tc
+	tm.mutex.Unlock()
+
+	return nil
+}
+
+func minTLSVersion(v string) (uint16, error) {
+
+	if v == "" {
+		return tls.VersionTLS12, nil
+	}
+
+	if tv, found := tlsVersions[v]; found {
+		return tv, nil
+	}
+
+	return 0, fmt.Errorf("%s is not a supported value for HTTPServer.TLS.MinVersion (1.0, 1.1, 1.2 or 1.3)", v)
+}
+
+func cipherSuites(names []string) ([]uint16, error) {
+
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	known := make(map[string]uint16)
+
+	for _, cs := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		known[cs.Name] = cs.ID
+	}
+
+	var ids []uint16
+
+	for _, n := range names {
+
+		id, found := known[strings.TrimSpace(n)]
+
+		if !found {
+			return nil, fmt.Errorf("%s is not a supported cipher suite", n)
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+func clientAuth(c *TLSConfig) (tls.ClientAuthType, error) {
+
+	mode := strings.ToUpper(c.ClientAuth)
+
+	if mode == "" {
+
+		if c.ClientCAFile == "" {
+			return tls.NoClientCert, nil
+		}
+
+		return tls.RequireAndVerifyClientCert, nil
+	}
+
+	ca, found := clientAuthModes[mode]
+
+	if !found {
+		return ca, fmt.Errorf("%s is not a supported value for HTTPServer.TLS.ClientAuth", c.ClientAuth)
+	}
+
+	if (ca == tls.VerifyClientCertIfGiven || ca == tls.RequireAndVerifyClientCert) && c.ClientCAFile == "" {
+		return ca, fmt.Errorf("HTTPServer.TLS.ClientCAFile must be set if HTTPServer.TLS.ClientAuth is %s", mode)
+	}
+
+	return ca, nil
+}
+
+// reloadTLSCommand is a ctl.Command that reloads the HTTPServer's certificates
+type reloadTLSCommand struct {
+	Server *HTTPServer
+}
+
+func (rc *reloadTLSCommand) ExecuteCommand(qualifiers []string, args map[string]string) (*ctl.CommandOutput, []*ws.CategorisedError) {
+
+	if err := rc.Server.ReloadTLS(); err != nil {
+		return nil, []*ws.CategorisedError{ctl.NewCommandLogicError(err.Error())}
+	}
+
+	co := new(ctl.CommandOutput)
+	co.OutputHeader = "TLS certificates reloaded"
+
+	return co, nil
+}
+
+func (rc *reloadTLSCommand) Name() string {
+	return reloadTLSCommandName
+}
+
+func (rc *reloadTLSCommand) Summmary() string {
+	return reloadTLSSummary
+}
+
+func (rc *reloadTLSCommand) Usage() string {
+	return reloadTLSUsage
+}
+
+func (rc *reloadTLSCommand) Help() []string {
+	return []string{reloadTLSHelp}
+}
@@ -0,0 +1,240 @@
+package httpserver
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"github.com/graniticio/granitic/v2/ws"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+// createCert creates a certificate signed by the supplied parent (or a self-signed CA certificate if parent is nil)
+func createCert(t *testing.T, cn string, parent *testCert, usage x509.ExtKeyUsage) *testCert {
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn, Organization: []string{"Granitic"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	signer, signerKey := tmpl, key
+
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+	} else {
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{usage}
+		tmpl.KeyUsage = x509.KeyUsageDigitalSignature
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	c, _ := x509.ParseCertificate(der)
+
+	return &testCert{cert: c, key: key, der: der}
+}
+
+func (tc *testCert) write(t *testing.T, dir, name string) (string, string) {
+
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+
+	kb, err := x509.MarshalECPrivateKey(tc.key)
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if err := ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: tc.der}), 0600); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if err := ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: kb}), 0600); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	return certFile, keyFile
+}
+
+func (tc *testCert) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{tc.der}, PrivateKey: tc.key}
+}
+
+func TestTLSConfigValidation(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "grnc-tls")
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	defer os.RemoveAll(dir)
+
+	ca := createCert(t, "CA", nil, 0)
+	certFile, keyFile := createCert(t, "server", ca, x509.ExtKeyUsageServerAuth).write(t, dir, "server")
+
+	log := new(logging.ConsoleErrorLogger)
+
+	invalid := []*TLSConfig{
+		{Enabled: true},
+		{Enabled: true, CertificateFile: certFile, KeyFile: filepath.Join(dir, "missing.key")},
+		{Enabled: true, CertificateFile: certFile, KeyFile: keyFile, MinVersion: "2.0"},
+		{Enabled: true, CertificateFile: certFile, KeyFile: keyFile, CipherSuites: []string{"TLS_UNKNOWN"}},
+		{Enabled: true, CertificateFile: certFile, KeyFile: keyFile, ClientAuth: "SOMETIMES"},
+		{Enabled: true, CertificateFile: certFile, KeyFile: keyFile, ClientAuth: "REQUIRE_AND_VERIFY"},
+		{Enabled: true, CertificateFile: certFile, KeyFile: keyFile, ClientCAFile: keyFile},
+	}
+
+	for i, c := range invalid {
+		if _, err := newTLSManager(c, log); err == nil {
+			t.Errorf("Expected configuration %d to be rejected", i)
+		}
+	}
+
+	c := &TLSConfig{Enabled: true, CertificateFile: certFile, KeyFile: keyFile, MinVersion: "1.3",
+		CipherSuites: []string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"}, ClientCAFile: certFile}
+
+	tm, err := newTLSManager(c, log)
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	test.ExpectInt(t, int(tm.current.MinVersion), tls.VersionTLS13)
+	test.ExpectInt(t, len(tm.current.CipherSuites), 1)
+	test.ExpectInt(t, int(tm.current.ClientAuth), int(tls.RequireAndVerifyClientCert))
+}
+
+func TestMutualTLSAndReload(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "grnc-tls")
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	defer os.RemoveAll(dir)
+
+	ca := createCert(t, "CA", nil, 0)
+	caFile, _ := ca.write(t, dir, "ca")
+	certFile, keyFile := createCert(t, "server-1", ca, x509.ExtKeyUsageServerAuth).write(t, dir, "server")
+	client := createCert(t, "client", ca, x509.ExtKeyUsageClientAuth)
+
+	c := &TLSConfig{Enabled: true, CertificateFile: certFile, KeyFile: keyFile, ClientCAFile: caFile}
+
+	tm, err := newTLSManager(c, new(logging.ConsoleErrorLogger))
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	sv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		subject, _ := ws.VerifiedClientSubject(req)
+		fmt.Fprint(w, subject.CommonName)
+	}))
+
+	sv.TLS = tm.serverConfig()
+	sv.StartTLS()
+	defer sv.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	call := func(certs []tls.Certificate) (string, string, error) {
+
+		tr := &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: certs}, DisableKeepAlives: true}
+		hc := &http.Client{Transport: tr}
+
+		res, err := hc.Get(sv.URL)
+
+		if err != nil {
+			return "", "", err
+		}
+
+		defer res.Body.Close()
+
+		b, _ := ioutil.ReadAll(res.Body)
+
+		return string(b), res.TLS.PeerCertificates[0].Subject.CommonName, nil
+	}
+
+	body, serverName, err := call([]tls.Certificate{client.tlsCertificate()})
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	test.ExpectString(t, body, "client")
+	test.ExpectString(t, serverName, "server-1")
+
+	if _, _, err := call(nil); err == nil {
+		t.Errorf("Expected a connection without a client certificate to be refused")
+	}
+
+	// Replace the certificate on disk and reload
+	createCert(t, "server-2", ca, x509.ExtKeyUsageServerAuth).write(t, dir, "server")
+
+	h := &HTTPServer{tls: tm}
+
+	if _, errs := (&reloadTLSCommand{Server: h}).ExecuteCommand(nil, nil); len(errs) > 0 {
+		t.Fatal(errs[0].Message)
+	}
+
+	if _, serverName, err = call([]tls.Certificate{client.tlsCertificate()}); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	test.ExpectString(t, serverName, "server-2")
+
+	// A failed reload leaves the existing certificate in place
+	ioutil.WriteFile(keyFile, []byte("invalid"), 0600)
+
+	if err := h.ReloadTLS(); err == nil {
+		t.Errorf("Expected reload of an invalid key to fail")
+	}
+
+	if _, serverName, err = call([]tls.Certificate{client.tlsCertificate()}); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	test.ExpectString(t, serverName, "server-2")
+
+	if err := new(HTTPServer).ReloadTLS(); err == nil {
+		t.Errorf("Expected an error reloading certificates when TLS is not enabled")
+	}
+}
@@ -30,6 +30,7 @@ const (
 	statusCode
 	bytesReturned
 	bytesReturnedClf
+	bytesUncompressed
 	requestHeader
 	percentSymbol
 	method
@@ -319,6 +320,8 @@ func (ulb *UnstructuredLineBuilder) mapPlaceholder(ph string) logFormatPlaceHold
 		return path
 	case "X":
 		return ctxValue
+	case "Z":
+		return bytesUncompressed
 	}
 
 }
@@ -370,6 +373,9 @@ func (ulb *UnstructuredLineBuilder) findValue(ctx context.Context, element *logL
 	case bytesReturned:
 		return (strconv.Itoa(res.BytesServed))
 
+	case bytesUncompressed:
+		return (strconv.Itoa(res.UncompressedBytes))
+
 	case remoteHost:
 		return req.RemoteAddr
 
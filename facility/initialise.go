@@ -7,7 +7,10 @@ import (
 	"errors"
 	"fmt"
 	"github.com/graniticio/granitic/v2/config"
+	"github.com/graniticio/granitic/v2/facility/access"
+	"github.com/graniticio/granitic/v2/facility/cors"
 	"github.com/graniticio/granitic/v2/facility/httpserver"
+	"github.com/graniticio/granitic/v2/facility/jwt"
 	"github.com/graniticio/granitic/v2/facility/logger"
 	"github.com/graniticio/granitic/v2/facility/querymanager"
 	"github.com/graniticio/granitic/v2/facility/rdbms"
@@ -135,6 +138,9 @@ func (fi *FacilitiesInitialisor) Initialise(ca *config.Accessor) error {
 	fi.addFacility(new(rdbms.FacilityBuilder))
 	fi.addFacility(new(runtimectl.FacilityBuilder))
 	fi.addFacility(new(taskscheduler.FacilityBuilder))
+	fi.addFacility(new(jwt.FacilityBuilder))
+	fi.addFacility(new(access.FacilityBuilder))
+	fi.addFacility(new(cors.FacilityBuilder))
 
 	if fc["ApplicationLogging"].(bool) || fc["HTTPServer"].(bool) {
 		//Facilties are required that might need a logging.ContextFilter
@@ -0,0 +1,137 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+/*
+Package jwt provides the JWTIdentifier facility which authenticates web service callers using JSON Web Tokens.
+
+Enabling this facility creates a component (jwt.Identifier) that implements ws.Identifier and, unless
+JWTIdentifier.InjectIntoHandlers is set to false, injects it into the UserIdentifier field of every handler.WsHandler
+that does not already have an identifier set.
+
+Keys are declared in configuration, either individually:
+
+	{
+	  "JWTIdentifier": {
+		"Keys": [
+		  {"ID": "shared", "Algorithm": "HS256", "Secret": "my-secret"},
+		  {"ID": "issuer-2020", "Algorithm": "RS256", "PublicKeyFile": "resource/keys/issuer.pem"}
+		]
+	  }
+	}
+
+or by referencing a local JSON Web Key Set file with JWTIdentifier.JWKSFile. See the iam/jwt package documentation for
+more details.
+*/
+package jwt
+
+import (
+	"fmt"
+	"github.com/graniticio/granitic/v2/config"
+	"github.com/graniticio/granitic/v2/iam/jwt"
+	"github.com/graniticio/granitic/v2/instance"
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/ws/handler"
+)
+
+// IdentifierComponentName is the name of the jwt.Identifier component as stored in the IoC framework.
+const IdentifierComponentName = instance.FrameworkPrefix + "JWTIdentifier"
+const identifierDecoratorName = instance.FrameworkPrefix + "JWTIdentifierDecorator"
+
+// FacilityBuilder creates a jwt.Identifier and, optionally, a decorator to inject it into web service handlers.
+type FacilityBuilder struct {
+}
+
+type identifierConfig struct {
+	Header              string
+	Scheme              string
+	LoggableUserIDClaim string
+	ClaimMapping        map[string]string
+	Issuer              string
+	Audience            []string
+	ClockSkewSeconds    int
+	RequireExpiry       bool
+	Keys                []jwt.KeyDefinition
+	JWKSFile            string
+	InjectIntoHandlers  bool
+}
+
+// BuildAndRegister implements FacilityBuilder.BuildAndRegister
+func (fb *FacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManager, ca *config.Accessor, cn *ioc.ComponentContainer) error {
+
+	cfg := new(identifierConfig)
+
+	if err := ca.Populate("JWTIdentifier", cfg); err != nil {
+		return fmt.Errorf("unable to read configuration for the JWTIdentifier facility: %s", err.Error())
+	}
+
+	ks := jwt.NewKeySet()
+
+	if err := ks.AddDefinitions(cfg.Keys); err != nil {
+		return err
+	}
+
+	if cfg.JWKSFile != "" {
+		if err := ks.LoadJWKSFile(cfg.JWKSFile); err != nil {
+			return err
+		}
+	}
+
+	v := new(jwt.Validator)
+	v.Issuer = cfg.Issuer
+	v.Audience = cfg.Audience
+	v.ClockSkewSeconds = cfg.ClockSkewSeconds
+	v.RequireExpiry = cfg.RequireExpiry
+
+	id := new(jwt.Identifier)
+	id.Header = cfg.Header
+	id.Scheme = cfg.Scheme
+	id.LoggableUserIDClaim = cfg.LoggableUserIDClaim
+	id.ClaimMapping = cfg.ClaimMapping
+	id.Validator = v
+	id.Keys = ks
+
+	cn.WrapAndAddProto(IdentifierComponentName, id)
+
+	if cfg.InjectIntoHandlers {
+		d := new(identifierDecorator)
+		d.Identifier = id
+		d.FrameworkLogger = lm.CreateLogger(identifierDecoratorName)
+
+		cn.WrapAndAddProto(identifierDecoratorName, d)
+	}
+
+	return nil
+}
+
+// FacilityName implements FacilityBuilder.FacilityName
+func (fb *FacilityBuilder) FacilityName() string {
+	return "JWTIdentifier"
+}
+
+// DependsOnFacilities implements FacilityBuilder.DependsOnFacilities
+func (fb *FacilityBuilder) DependsOnFacilities() []string {
+	return []string{}
+}
+
+// Injects the jwt.Identifier into any handler.WsHandler that doesn't already have a UserIdentifier
+type identifierDecorator struct {
+	FrameworkLogger logging.Logger
+	Identifier      *jwt.Identifier
+}
+
+// OfInterest returns true if the subject is a handler.WsHandler without a UserIdentifier
+func (id *identifierDecorator) OfInterest(subject *ioc.Component) bool {
+
+	h, found := subject.Instance.(*handler.WsHandler)
+
+	return found && h.UserIdentifier == nil
+}
+
+// DecorateComponent sets the handler's UserIdentifier to the facility's jwt.Identifier
+func (id *identifierDecorator) DecorateComponent(subject *ioc.Component, cc *ioc.ComponentContainer) {
+
+	id.FrameworkLogger.LogTracef("Injecting JWT identifier into %s", subject.Name)
+
+	subject.Instance.(*handler.WsHandler).UserIdentifier = id.Identifier
+}
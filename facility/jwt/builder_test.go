@@ -0,0 +1,121 @@
+package jwt
+
+import (
+	"github.com/graniticio/granitic/v2/config"
+	"github.com/graniticio/granitic/v2/iam/jwt"
+	"github.com/graniticio/granitic/v2/instance"
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"github.com/graniticio/granitic/v2/ws/handler"
+	"testing"
+)
+
+func TestFacilityNaming(t *testing.T) {
+
+	fb := new(FacilityBuilder)
+
+	if fb.FacilityName() != "JWTIdentifier" {
+		t.Errorf("Unexpected facility name %s", fb.FacilityName())
+	}
+
+}
+
+func TestBuilderWithKeys(t *testing.T) {
+
+	cc, err := buildFacility(t, "keys.json")
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	id := cc.ProtoComponents()[IdentifierComponentName].Component.Instance.(*jwt.Identifier)
+
+	test.ExpectString(t, id.Header, "Authorization")
+	test.ExpectString(t, id.ClaimMapping["Roles"], "roles")
+	test.ExpectBool(t, id.Validator.RequireExpiry, true)
+
+	test.ExpectInt(t, len(id.Keys.Candidates(jwt.Header{Algorithm: jwt.HS256, KeyID: "shared"})), 1)
+	test.ExpectInt(t, len(id.Keys.Candidates(jwt.Header{Algorithm: jwt.HS256, KeyID: "jwks-shared"})), 1)
+
+	d := cc.ProtoComponents()[identifierDecoratorName].Component.Instance.(*identifierDecorator)
+
+	h := new(handler.WsHandler)
+	c := ioc.NewComponent("handler", h)
+
+	test.ExpectBool(t, d.OfInterest(c), true)
+
+	d.DecorateComponent(c, cc)
+
+	if h.UserIdentifier != id {
+		t.Fatalf("Expected identifier to be injected")
+	}
+
+	test.ExpectBool(t, d.OfInterest(c), false)
+}
+
+func TestBuilderWithoutDecorator(t *testing.T) {
+
+	cc, err := buildFacility(t, "nodecorator.json")
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if cc.ProtoComponents()[identifierDecoratorName] != nil {
+		t.Fatalf("Did not expect decorator to be registered")
+	}
+}
+
+func TestBuilderWithInvalidKey(t *testing.T) {
+
+	if _, err := buildFacility(t, "badkey.json"); err == nil {
+		t.Fatalf("Expected an error for an unsupported algorithm")
+	}
+}
+
+func buildFacility(t *testing.T, file string) (*ioc.ComponentContainer, error) {
+
+	lm := logging.CreateComponentLoggerManager(logging.Fatal, make(map[string]interface{}), []logging.LogWriter{}, logging.NewFrameworkLogMessageFormatter(), false)
+
+	ca, err := configAccessor(lm, test.FilePath(file))
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	cc := ioc.NewComponentContainer(lm, ca, new(instance.System))
+
+	if err = new(FacilityBuilder).BuildAndRegister(lm, ca, cc); err != nil {
+		return nil, err
+	}
+
+	return cc, nil
+}
+
+func configAccessor(lm *logging.ComponentLoggerManager, additionalFiles ...string) (*config.Accessor, error) {
+
+	jm := config.NewJSONMergerWithManagedLogging(lm, new(config.JSONContentParser))
+
+	configLoc, err := test.FindFacilityConfigFromWD()
+
+	if err != nil {
+		return nil, err
+	}
+
+	jf, err := config.FindJSONFilesInDir(configLoc)
+
+	if err != nil {
+		return nil, err
+	}
+
+	jf = append(jf, additionalFiles...)
+
+	mergedJSON, err := jm.LoadAndMergeConfigWithBase(make(map[string]interface{}), jf)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &config.Accessor{JSONData: mergedJSON, FrameworkLogger: lm.CreateLogger("ca")}, nil
+}
@@ -0,0 +1,185 @@
+package ws
+
+import (
+	"github.com/graniticio/granitic/v2/config"
+	"github.com/graniticio/granitic/v2/facility/httpserver"
+	"github.com/graniticio/granitic/v2/instance"
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"github.com/graniticio/granitic/v2/ws"
+	"github.com/graniticio/granitic/v2/ws/idempotency"
+	"github.com/graniticio/granitic/v2/ws/json"
+	"testing"
+)
+
+func TestNegotiationWithBothFacilities(t *testing.T) {
+
+	lm := logging.CreateComponentLoggerManager(logging.Fatal, make(map[string]interface{}), []logging.LogWriter{}, logging.NewFrameworkLogMessageFormatter(), false)
+
+	ca, err := configAccessor(lm, test.FilePath("negotiation.json"))
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	cc := ioc.NewComponentContainer(lm, ca, new(instance.System))
+
+	if err = new(JSONFacilityBuilder).BuildAndRegister(lm, ca, cc); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if _, found := cc.ProtoComponents()[negotiatingResponseWriterName]; found {
+		t.Fatalf("Negotiating components should not be created unless both facilities are enabled")
+	}
+
+	if err = new(XMLFacilityBuilder).BuildAndRegister(lm, ca, cc); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	protos := cc.ProtoComponents()
+
+	nrw := protos[negotiatingResponseWriterName].Component.Instance.(*ws.NegotiatingResponseWriter)
+
+	test.ExpectInt(t, len(nrw.MediaTypes), 3)
+	test.ExpectString(t, nrw.MediaTypes[0], "application/json")
+
+	d := protos[wsHandlerDecoratorName].Component.Instance.(*wsHandlerDecorator)
+
+	test.ExpectBool(t, d.ResponseWriter == nrw, true)
+
+	_, negotiating := d.Unmarshaller.(*ws.NegotiatingUnmarshaller)
+	test.ExpectBool(t, negotiating, true)
+
+	test.ExpectString(t, cc.Modifiers(httpserver.HTTPServerComponentName)[httpserver.HTTPServerAbnormalStatusFieldName], negotiatingResponseWriterName)
+
+	// Components shared by both facilities are only built once, so handlers use the registered instances
+	test.ExpectBool(t, d.Store == protos[IdempotencyStoreComponentName].Component.Instance, true)
+	test.ExpectBool(t, d.Paginator == protos[paginatorName].Component.Instance, true)
+	test.ExpectBool(t, d.QueryBinder == protos[wsParamBinderComponentName].Component.Instance, true)
+	test.ExpectBool(t, d.FrameworkErrors == protos[wsFrameworkErrorGenerator].Component.Instance, true)
+
+	jw := protos[jsonResponseWriterComponentName].Component.Instance.(*ws.MarshallingResponseWriter)
+
+	test.ExpectBool(t, jw.Streamer == protos[responseStreamerName].Component.Instance, true)
+	test.ExpectBool(t, jw.FrameworkErrors == d.FrameworkErrors, true)
+}
+
+func configAccessor(lm *logging.ComponentLoggerManager, additionalFiles ...string) (*config.Accessor, error) {
+
+	jm := config.NewJSONMergerWithManagedLogging(lm, new(config.JSONContentParser))
+
+	configLoc, err := test.FindFacilityConfigFromWD()
+
+	if err != nil {
+		return nil, err
+	}
+
+	jf, err := config.FindJSONFilesInDir(configLoc)
+
+	if err != nil {
+		return nil, err
+	}
+
+	jf = append(jf, additionalFiles...)
+
+	mergedJSON, err := jm.LoadAndMergeConfigWithBase(make(map[string]interface{}), jf)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &config.Accessor{JSONData: mergedJSON, FrameworkLogger: lm.CreateLogger("ca")}, nil
+}
+
+func TestProblemErrorFormat(t *testing.T) {
+
+	lm := logging.CreateComponentLoggerManager(logging.Fatal, make(map[string]interface{}), []logging.LogWriter{}, logging.NewFrameworkLogMessageFormatter(), false)
+
+	ca, err := configAccessor(lm, test.FilePath("problem.json"))
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	cc := ioc.NewComponentContainer(lm, ca, new(instance.System))
+
+	if err = new(JSONFacilityBuilder).BuildAndRegister(lm, ca, cc); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	rw := cc.ProtoComponents()[jsonResponseWriterComponentName].Component.Instance.(*ws.MarshallingResponseWriter)
+
+	pf, found := rw.ErrorFormatter.(*json.ProblemJSONErrorFormatter)
+
+	test.ExpectBool(t, found, true)
+	test.ExpectString(t, pf.TypeBaseURI, "https://example.com/problems/")
+}
+
+func TestIdempotencyStore(t *testing.T) {
+
+	lm := logging.CreateComponentLoggerManager(logging.Fatal, make(map[string]interface{}), []logging.LogWriter{}, logging.NewFrameworkLogMessageFormatter(), false)
+
+	ca, err := configAccessor(lm)
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	cc := ioc.NewComponentContainer(lm, ca, new(instance.System))
+
+	if err = new(JSONFacilityBuilder).BuildAndRegister(lm, ca, cc); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	ms, found := cc.ProtoComponents()[IdempotencyStoreComponentName].Component.Instance.(*idempotency.MemoryStore)
+
+	test.ExpectBool(t, found, true)
+	test.ExpectInt(t, ms.ExpiryMS, 86400000)
+
+	ca, err = configAccessor(lm, test.FilePath("idempotency.json"))
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	cc = ioc.NewComponentContainer(lm, ca, new(instance.System))
+
+	if err = new(JSONFacilityBuilder).BuildAndRegister(lm, ca, cc); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	rs, found := cc.ProtoComponents()[IdempotencyStoreComponentName].Component.Instance.(*idempotency.RdbmsStore)
+
+	test.ExpectBool(t, found, true)
+	test.ExpectInt(t, rs.ExpiryMS, 1000)
+	test.ExpectString(t, rs.SelectQueryID, "selectKey")
+	test.ExpectString(t, rs.InsertQueryID, "grncIdempotencyInsert")
+}
+
+func TestPaginator(t *testing.T) {
+
+	lm := logging.CreateComponentLoggerManager(logging.Fatal, make(map[string]interface{}), []logging.LogWriter{}, logging.NewFrameworkLogMessageFormatter(), false)
+
+	ca, err := configAccessor(lm)
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	cc := ioc.NewComponentContainer(lm, ca, new(instance.System))
+
+	if err = new(JSONFacilityBuilder).BuildAndRegister(lm, ca, cc); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	pg, found := cc.ProtoComponents()[paginatorName].Component.Instance.(*ws.Paginator)
+
+	test.ExpectBool(t, found, true)
+	test.ExpectString(t, pg.PageParam, "page")
+	test.ExpectString(t, pg.CursorParam, "cursor")
+	test.ExpectInt(t, pg.DefaultSize, 20)
+	test.ExpectInt(t, pg.MaxSize, 100)
+	test.ExpectBool(t, pg.LinkHeaders, true)
+	test.ExpectBool(t, pg.FrameworkErrors != nil, true)
+}
@@ -20,6 +20,9 @@ const jsonUnmarshallerComponentName = instance.FrameworkPrefix + "JSONUnmarshall
 const modeWrap = "WRAP"
 const modeBody = "BODY"
 
+const errorFormatGranitic = "GRANITIC"
+const errorFormatProblem = "PROBLEM"
+
 // JSONFacilityBuilder creates the components required to support the JSONWs facility and adds them the IoC container.
 type JSONFacilityBuilder struct {
 }
@@ -42,11 +45,30 @@ func (fb *JSONFacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManag
 
 	rw.StatusDeterminer = wc.StatusDeterminer
 	rw.FrameworkErrors = wc.FrameworkErrors
+	rw.Streamer = wc.Streamer
 
 	buildRegisterWsDecorator(cn, rw, um, wc, lm)
 
 	if !cn.ModifierExists(jsonResponseWriterComponentName, "ErrorFormatter") {
-		rw.ErrorFormatter = new(json.GraniticJSONErrorFormatter)
+
+		// User hasn't defined their own error formatter, use one of the defaults
+		if format, err := ca.StringVal("JSONWs.ErrorFormat"); err == nil {
+
+			switch format {
+			case errorFormatGranitic:
+				rw.ErrorFormatter = new(json.GraniticJSONErrorFormatter)
+			case errorFormatProblem:
+				pf := new(json.ProblemJSONErrorFormatter)
+				ca.Populate("JSONWs.ProblemDetails", pf)
+				rw.ErrorFormatter = pf
+			default:
+				m := fmt.Sprintf("JSONWs.ErrorFormat must be either %s or %s", errorFormatGranitic, errorFormatProblem)
+
+				return errors.New(m)
+			}
+		} else {
+			return err
+		}
 	}
 
 	if !cn.ModifierExists(jsonResponseWriterComponentName, "ResponseWrapper") {
@@ -83,7 +105,7 @@ func (fb *JSONFacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManag
 
 	offerAbnormalStatusWriter(rw, cn, jsonResponseWriterComponentName)
 
-	return nil
+	return buildNegotiatingComponents(ca, cn, wc, lm)
 }
 
 // FacilityName implements FacilityBuilder.FacilityName
@@ -58,13 +58,17 @@ Refer to https://granitic.io/ref/xml-web-services for more details.
 package ws
 
 import (
+	"fmt"
 	"github.com/graniticio/granitic/v2/config"
 	"github.com/graniticio/granitic/v2/facility/httpserver"
 	"github.com/graniticio/granitic/v2/instance"
 	"github.com/graniticio/granitic/v2/ioc"
 	"github.com/graniticio/granitic/v2/logging"
 	"github.com/graniticio/granitic/v2/ws"
+	"github.com/graniticio/granitic/v2/ws/form"
 	"github.com/graniticio/granitic/v2/ws/handler"
+	"github.com/graniticio/granitic/v2/ws/idempotency"
+	"strings"
 )
 
 const wsHTTPStatusDeterminerComponentName = instance.FrameworkPrefix + "HTTPStatusDeterminer"
@@ -72,6 +76,35 @@ const wsParamBinderComponentName = instance.FrameworkPrefix + "ParamBinder"
 const wsFrameworkErrorGenerator = instance.FrameworkPrefix + "FrameworkErrorGenerator"
 const wsHandlerDecoratorName = instance.FrameworkPrefix + "WsHandlerDecorator"
 
+// MultipartUnmarshallerComponentName is the name of the component that can be set as a handler's Unmarshaller to support
+// multipart/form-data requests (including file uploads).
+const MultipartUnmarshallerComponentName = instance.FrameworkPrefix + "MultipartUnmarshaller"
+
+const responseStreamerName = instance.FrameworkPrefix + "ResponseStreamer"
+
+const paginatorName = instance.FrameworkPrefix + "Paginator"
+
+// IdempotencyStoreComponentName is the name of the component that records responses to requests made with an Idempotency-Key
+// header. It is injected into every handler.
+const IdempotencyStoreComponentName = instance.FrameworkPrefix + "IdempotencyStore"
+
+const (
+	idempotencyMemoryStore = "MEMORY"
+	idempotencyRdbmsStore  = "RDBMS"
+)
+
+const negotiatingResponseWriterName = instance.FrameworkPrefix + "NegotiatingResponseWriter"
+const negotiatingUnmarshallerName = instance.FrameworkPrefix + "NegotiatingUnmarshaller"
+
+const (
+	formatJSON = "JSON"
+	formatXML  = "XML"
+)
+
+// FormUnmarshallerComponentName is the name of the component that can be set as a handler's Unmarshaller to support
+// application/x-www-form-urlencoded requests.
+const FormUnmarshallerComponentName = instance.FrameworkPrefix + "FormUnmarshaller"
+
 func offerAbnormalStatusWriter(arw ws.AbnormalStatusWriter, cc *ioc.ComponentContainer, name string) {
 
 	if !cc.ModifierExists(httpserver.HTTPServerComponentName, httpserver.HTTPServerAbnormalStatusFieldName) {
@@ -82,6 +115,11 @@ func offerAbnormalStatusWriter(arw ws.AbnormalStatusWriter, cc *ioc.ComponentCon
 
 func buildAndRegisterWsCommon(lm *logging.ComponentLoggerManager, ca *config.Accessor, cn *ioc.ComponentContainer) (*wsCommon, error) {
 
+	if wc := registeredWsCommon(cn); wc != nil {
+		// Already built by the other web service facility
+		return wc, nil
+	}
+
 	scd := new(ws.GraniticHTTPStatusCodeDeterminer)
 
 	if err := ca.Populate("WS.HTTPStatus", scd); err != nil {
@@ -102,8 +140,110 @@ func buildAndRegisterWsCommon(lm *logging.ComponentLoggerManager, ca *config.Acc
 
 	pb.FrameworkErrors = feg
 
-	return newWsCommon(pb, feg, scd), nil
+	mu := new(form.MultipartUnmarshaller)
 
+	if err := ca.Populate("WS.Multipart", mu); err != nil {
+		return nil, err
+	}
+
+	mu.ParamBinder = pb
+	mu.FrameworkErrors = feg
+	cn.WrapAndAddProto(MultipartUnmarshallerComponentName, mu)
+
+	fu := new(form.URLEncodedUnmarshaller)
+	fu.ParamBinder = pb
+	cn.WrapAndAddProto(FormUnmarshallerComponentName, fu)
+
+	rs := new(ws.ResponseStreamer)
+
+	if err := ca.Populate("WS.Streaming", rs); err != nil {
+		return nil, err
+	}
+
+	cn.WrapAndAddProto(responseStreamerName, rs)
+
+	pg := new(ws.Paginator)
+
+	if err := ca.Populate("WS.Pagination", pg); err != nil {
+		return nil, err
+	}
+
+	pg.FrameworkErrors = feg
+	cn.WrapAndAddProto(paginatorName, pg)
+
+	is, err := buildIdempotencyStore(ca)
+
+	if err != nil {
+		return nil, err
+	}
+
+	cn.WrapAndAddProto(IdempotencyStoreComponentName, is)
+
+	wc := newWsCommon(pb, feg, scd)
+	wc.Streamer = rs
+	wc.IdempotencyStore = is
+	wc.Paginator = pg
+
+	return wc, nil
+
+}
+
+// registeredWsCommon returns the components shared by the JSONWs and XMLWs facilities if they have already been
+// registered with the container, or nil if they have not.
+func registeredWsCommon(cn *ioc.ComponentContainer) *wsCommon {
+
+	protos := cn.ProtoComponents()
+
+	instance := func(name string) interface{} {
+		if p, found := protos[name]; found {
+			return p.Component.Instance
+		}
+
+		return nil
+	}
+
+	pb, found := instance(wsParamBinderComponentName).(*ws.ParamBinder)
+
+	if !found {
+		return nil
+	}
+
+	feg := instance(wsFrameworkErrorGenerator).(*ws.FrameworkErrorGenerator)
+	scd := instance(wsHTTPStatusDeterminerComponentName).(*ws.GraniticHTTPStatusCodeDeterminer)
+
+	wc := newWsCommon(pb, feg, scd)
+	wc.Streamer = instance(responseStreamerName).(*ws.ResponseStreamer)
+	wc.IdempotencyStore = instance(IdempotencyStoreComponentName).(idempotency.Store)
+	wc.Paginator = instance(paginatorName).(*ws.Paginator)
+
+	return wc
+}
+
+func buildIdempotencyStore(ca *config.Accessor) (idempotency.Store, error) {
+
+	storeType, err := ca.StringVal("WS.Idempotency.Store")
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch storeType {
+	case idempotencyMemoryStore:
+		ms := new(idempotency.MemoryStore)
+
+		return ms, ca.Populate("WS.Idempotency", ms)
+
+	case idempotencyRdbmsStore:
+		rs := new(idempotency.RdbmsStore)
+
+		if err := ca.Populate("WS.Idempotency", rs); err != nil {
+			return nil, err
+		}
+
+		return rs, ca.Populate("WS.Idempotency.Rdbms", rs)
+	}
+
+	return nil, fmt.Errorf("%s is not a supported value for WS.Idempotency.Store. Must be %s or %s", storeType, idempotencyMemoryStore, idempotencyRdbmsStore)
 }
 
 func newWsCommon(pb *ws.ParamBinder, feg *ws.FrameworkErrorGenerator, sd *ws.GraniticHTTPStatusCodeDeterminer) *wsCommon {
@@ -121,21 +261,114 @@ type wsCommon struct {
 	ParamBinder      *ws.ParamBinder
 	FrameworkErrors  *ws.FrameworkErrorGenerator
 	StatusDeterminer *ws.GraniticHTTPStatusCodeDeterminer
+	Streamer         *ws.ResponseStreamer
+	IdempotencyStore idempotency.Store
+	Paginator        *ws.Paginator
 }
 
 func buildRegisterWsDecorator(cc *ioc.ComponentContainer, rw ws.ResponseWriter, um ws.Unmarshaller, wc *wsCommon, lm *logging.ComponentLoggerManager) {
 
 	decoratorLogger := lm.CreateLogger(wsHandlerDecoratorName)
-	decorator := wsHandlerDecorator{decoratorLogger, rw, um, wc.ParamBinder, wc.FrameworkErrors}
+	decorator := wsHandlerDecorator{decoratorLogger, rw, um, wc.ParamBinder, wc.FrameworkErrors, wc.IdempotencyStore, wc.Paginator}
 	cc.WrapAndAddProto(wsHandlerDecoratorName, &decorator)
 }
 
+type negotiationConfig struct {
+	// The format (JSON or XML) used when the client has no preference
+	Default string
+
+	// Media types that will be rendered and parsed as JSON
+	JSONMediaTypes []string
+
+	// Media types that will be rendered and parsed as XML
+	XMLMediaTypes []string
+}
+
+// buildNegotiatingComponents is called by both the JSONWs and XMLWs facility builders. Once both facilities have been
+// built, it creates a ResponseWriter and Unmarshaller that choose between JSON and XML for each request and arranges for
+// them to be used by handlers (and the HTTP server) instead of the facility-specific components.
+func buildNegotiatingComponents(ca *config.Accessor, cn *ioc.ComponentContainer, wc *wsCommon, lm *logging.ComponentLoggerManager) error {
+
+	protos := cn.ProtoComponents()
+
+	jw, jsonBuilt := protos[jsonResponseWriterComponentName]
+	xw, xmlBuilt := protos[xmlResponseWriterName]
+
+	if !jsonBuilt || !xmlBuilt {
+		return nil
+	}
+
+	nc := new(negotiationConfig)
+
+	if err := ca.Populate("WS.Negotiation", nc); err != nil {
+		return err
+	}
+
+	writers := map[string]ws.ResponseWriter{formatJSON: jw.Component.Instance.(ws.ResponseWriter), formatXML: xw.Component.Instance.(ws.ResponseWriter)}
+	unmarshallers := map[string]ws.Unmarshaller{
+		formatJSON: protos[jsonUnmarshallerComponentName].Component.Instance.(ws.Unmarshaller),
+		formatXML:  protos[xmlUnmarshallerName].Component.Instance.(ws.Unmarshaller),
+	}
+	mediaTypes := map[string][]string{formatJSON: nc.JSONMediaTypes, formatXML: nc.XMLMediaTypes}
+
+	var order []string
+
+	switch nc.Default {
+	case formatJSON:
+		order = []string{formatJSON, formatXML}
+	case formatXML:
+		order = []string{formatXML, formatJSON}
+	default:
+		return fmt.Errorf("WS.Negotiation.Default must be either %s or %s", formatJSON, formatXML)
+	}
+
+	nrw := new(ws.NegotiatingResponseWriter)
+	nrw.Writers = make(map[string]ws.ResponseWriter)
+
+	nu := new(ws.NegotiatingUnmarshaller)
+	nu.Unmarshallers = make(map[string]ws.Unmarshaller)
+	nu.FrameworkErrors = wc.FrameworkErrors
+
+	for _, format := range order {
+
+		if len(mediaTypes[format]) == 0 {
+			return fmt.Errorf("at least one media type must be set in WS.Negotiation.%sMediaTypes", format)
+		}
+
+		for _, mt := range mediaTypes[format] {
+
+			mt = strings.ToLower(mt)
+
+			nrw.MediaTypes = append(nrw.MediaTypes, mt)
+			nrw.Writers[mt] = writers[format]
+			nu.Unmarshallers[mt] = unmarshallers[format]
+		}
+	}
+
+	nu.DefaultMediaType = nrw.MediaTypes[0]
+
+	cn.WrapAndAddProto(negotiatingResponseWriterName, nrw)
+	cn.WrapAndAddProto(negotiatingUnmarshallerName, nu)
+
+	buildRegisterWsDecorator(cn, nrw, nu, wc, lm)
+
+	// Replace the facility-specific writer offered to the HTTP server, unless the application has chosen its own
+	switch cn.Modifiers(httpserver.HTTPServerComponentName)[httpserver.HTTPServerAbnormalStatusFieldName] {
+	case "", jsonResponseWriterComponentName, xmlResponseWriterName:
+		cn.AddModifier(httpserver.HTTPServerComponentName, httpserver.HTTPServerAbnormalStatusFieldName, negotiatingResponseWriterName)
+	}
+
+	return nil
+}
+
 type wsHandlerDecorator struct {
 	FrameworkLogger logging.Logger
 	ResponseWriter  ws.ResponseWriter
 	Unmarshaller    ws.Unmarshaller
 	QueryBinder     *ws.ParamBinder
 	FrameworkErrors *ws.FrameworkErrorGenerator
+	Store           idempotency.Store
+	Paginator       *ws.Paginator
 }
 
 func (jwhd *wsHandlerDecorator) OfInterest(component *ioc.Component) bool {
@@ -170,4 +403,12 @@ func (jwhd *wsHandlerDecorator) DecorateComponent(component *ioc.Component, cont
 		h.FrameworkErrors = jwhd.FrameworkErrors
 	}
 
+	if h.IdempotencyStore == nil {
+		h.IdempotencyStore = jwhd.Store
+	}
+
+	if h.Paginator == nil {
+		h.Paginator = jwhd.Paginator
+	}
+
 }
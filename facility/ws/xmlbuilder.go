@@ -52,7 +52,7 @@ func (fb *XMLFacilityBuilder) BuildAndRegister(lm *logging.ComponentLoggerManage
 	buildRegisterWsDecorator(cc, rw, um, wc, lm)
 	offerAbnormalStatusWriter(rw.(ws.AbnormalStatusWriter), cc, xmlResponseWriterName)
 
-	return nil
+	return buildNegotiatingComponents(ca, cc, wc, lm)
 }
 
 func (fb *XMLFacilityBuilder) createTemplateComponents(ca *config.Accessor, cc *ioc.ComponentContainer, wc *wsCommon) ws.ResponseWriter {
@@ -76,6 +76,7 @@ func (fb *XMLFacilityBuilder) createMarshalComponents(ca *config.Accessor, cc *i
 
 	rw.StatusDeterminer = wc.StatusDeterminer
 	rw.FrameworkErrors = wc.FrameworkErrors
+	rw.Streamer = wc.Streamer
 
 	if !cc.ModifierExists(xmlResponseWriterName, "ErrorFormatter") {
 		rw.ErrorFormatter = new(xml.GraniticXMLErrorFormatter)
@@ -0,0 +1,338 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package httpendpoint
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Names of content encodings supported by Compressor
+const (
+	GzipEncoding    = "gzip"
+	DeflateEncoding = "deflate"
+)
+
+const (
+	acceptEncodingHeader  = "Accept-Encoding"
+	contentEncodingHeader = "Content-Encoding"
+	contentLengthHeader   = "Content-Length"
+	contentTypeHeader     = "Content-Type"
+	varyHeader            = "Vary"
+)
+
+/*
+Compressor compresses response bodies using an encoding (gzip or deflate) acceptable to the client, as indicated by the
+request's Accept-Encoding header.
+
+A response is only compressed if its body is at least MinSize bytes and its Content-Type matches one of ContentTypes. The
+start of the response body is buffered until MinSize bytes have been written (or the response is finished) so that this
+decision can be made.
+*/
+type Compressor struct {
+	// The encodings that may be used, in order of preference (gzip and/or deflate).
+	Encodings []string
+
+	// The smallest response body (in bytes) that will be compressed.
+	MinSize int
+
+	// Media types (e.g. application/json) of responses that may be compressed. An entry ending in /* (e.g. text/*)
+	// matches any subtype.
+	ContentTypes []string
+
+	// The compression level (-1 for the default level, 1 for best speed to 9 for best compression).
+	Level int
+}
+
+// Validate checks that the Compressor's encodings and compression level are supported.
+func (c *Compressor) Validate() error {
+
+	if len(c.Encodings) == 0 {
+		return fmt.Errorf("at least one encoding (%s or %s) must be specified", GzipEncoding, DeflateEncoding)
+	}
+
+	for _, e := range c.Encodings {
+		if e != GzipEncoding && e != DeflateEncoding {
+			return fmt.Errorf("%s is not a supported encoding (%s or %s)", e, GzipEncoding, DeflateEncoding)
+		}
+	}
+
+	if c.Level < gzip.HuffmanOnly || c.Level > gzip.BestCompression {
+		return fmt.Errorf("%d is not a valid compression level", c.Level)
+	}
+
+	return nil
+}
+
+// Wrap arranges for data written to the supplied HTTPResponseWriter to be compressed, if the request's Accept-Encoding
+// header allows one of the Compressor's encodings. Responses with a content type that could be compressed have
+// Accept-Encoding added to their Vary header whether or not they are compressed, so that caches store each variant
+// separately. Must be called before any data is written to the HTTPResponseWriter.
+func (c *Compressor) Wrap(w *HTTPResponseWriter, req *http.Request) {
+
+	if req.Method == http.MethodHead || w.DataSent || w.compressor != nil {
+		return
+	}
+
+	// An empty encoding means the response will not be compressed, but may still need a Vary header
+	encoding := c.negotiate(req.Header.Get(acceptEncodingHeader))
+
+	cw := &compressingWriter{ResponseWriter: w.rw, compressor: c, encoding: encoding, owner: w}
+
+	w.compressor = cw
+	w.rw = cw
+}
+
+// negotiate chooses the encoding with the highest quality value in the supplied Accept-Encoding header. If more than one
+// encoding has the same quality, the Compressor's preference is used.
+func (c *Compressor) negotiate(accept string) string {
+
+	if accept == "" {
+		return ""
+	}
+
+	q := make(map[string]float64)
+
+	for _, part := range strings.Split(accept, ",") {
+
+		fields := strings.Split(part, ";")
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+		quality := 1.0
+
+		for _, p := range fields[1:] {
+
+			p = strings.TrimSpace(p)
+
+			if strings.HasPrefix(p, "q=") {
+				if f, err := strconv.ParseFloat(p[2:], 64); err == nil {
+					quality = f
+				}
+			}
+		}
+
+		q[name] = quality
+	}
+
+	best := ""
+	bestQ := 0.0
+
+	for _, e := range c.Encodings {
+
+		eq, found := q[e]
+
+		if !found {
+			eq = q["*"]
+		}
+
+		if eq > bestQ {
+			best = e
+			bestQ = eq
+		}
+	}
+
+	return best
+}
+
+func (c *Compressor) compressible(contentType string) bool {
+
+	mt, _, err := mime.ParseMediaType(contentType)
+
+	if err != nil {
+		return false
+	}
+
+	for _, allowed := range c.ContentTypes {
+
+		allowed = strings.ToLower(allowed)
+
+		if allowed == mt || (strings.HasSuffix(allowed, "/*") && strings.HasPrefix(mt, allowed[:len(allowed)-1])) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// compressingWriter buffers the start of a response until it can decide whether or not to compress it, then writes
+// the (possibly compressed) data to the underlying http.ResponseWriter.
+type compressingWriter struct {
+	http.ResponseWriter
+	compressor *Compressor
+	encoding   string
+	owner      *HTTPResponseWriter
+	status     int
+	buffer     []byte
+	decided    bool
+	encoder    io.WriteCloser
+	finished   bool
+}
+
+func (cw *compressingWriter) WriteHeader(status int) {
+
+	if cw.status == 0 {
+		cw.status = status
+	}
+}
+
+func (cw *compressingWriter) Write(b []byte) (int, error) {
+
+	if !cw.decided && cw.encoding == "" {
+		// No need to buffer a response that will not be compressed
+		if err := cw.decide(); err != nil {
+			return 0, err
+		}
+	}
+
+	if cw.decided {
+		return cw.writeDecided(b)
+	}
+
+	cw.buffer = append(cw.buffer, b...)
+
+	if len(cw.buffer) >= cw.compressor.MinSize {
+		if err := cw.decide(); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(b), nil
+}
+
+// Write to the underlying http.ResponseWriter, counting the bytes actually sent
+func (cw *compressingWriter) writeRaw(b []byte) (int, error) {
+
+	n, err := cw.ResponseWriter.Write(b)
+	cw.owner.BytesServed += n
+
+	return n, err
+}
+
+func (cw *compressingWriter) writeDecided(b []byte) (int, error) {
+
+	if cw.encoder != nil {
+		return cw.encoder.Write(b)
+	}
+
+	return cw.writeRaw(b)
+}
+
+// decide determines whether or not the response should be compressed, sends the response's headers and writes any buffered data
+func (cw *compressingWriter) decide() error {
+
+	cw.decided = true
+
+	h := cw.Header()
+
+	eligible := h.Get(contentEncodingHeader) == "" && cw.compressor.compressible(h.Get(contentTypeHeader))
+
+	if eligible {
+		addVary(h, acceptEncodingHeader)
+	}
+
+	compress := eligible && cw.encoding != "" && len(cw.buffer) >= cw.compressor.MinSize && len(cw.buffer) > 0
+
+	if compress {
+		h.Set(contentEncodingHeader, cw.encoding)
+		h.Del(contentLengthHeader)
+
+		level := cw.compressor.Level
+		out := writerFunc(cw.writeRaw)
+
+		if cw.encoding == GzipEncoding {
+			cw.encoder, _ = gzip.NewWriterLevel(out, level)
+		} else {
+			cw.encoder, _ = zlib.NewWriterLevel(out, level)
+		}
+	}
+
+	if cw.status != 0 {
+		cw.ResponseWriter.WriteHeader(cw.status)
+	}
+
+	buffered := cw.buffer
+	cw.buffer = nil
+
+	if len(buffered) == 0 {
+		return nil
+	}
+
+	_, err := cw.writeDecided(buffered)
+
+	return err
+}
+
+// flush writes any buffered data and flushes the compressed stream (if any) to the underlying http.ResponseWriter
+func (cw *compressingWriter) flush() error {
+
+	if cw.finished {
+		return nil
+	}
+
+	if !cw.decided {
+		if err := cw.decide(); err != nil {
+			return err
+		}
+	}
+
+	if f, found := cw.encoder.(flusher); found {
+		return f.Flush()
+	}
+
+	return nil
+}
+
+type flusher interface {
+	Flush() error
+}
+
+// finish writes any buffered data and completes the compressed stream
+func (cw *compressingWriter) finish() error {
+
+	if cw.finished {
+		return nil
+	}
+
+	cw.finished = true
+
+	if !cw.decided {
+		if err := cw.decide(); err != nil {
+			return err
+		}
+	}
+
+	if cw.encoder != nil {
+		return cw.encoder.Close()
+	}
+
+	return nil
+}
+
+// addVary adds the supplied header name to the response's Vary header, unless it is already listed
+func addVary(h http.Header, name string) {
+
+	for _, v := range h.Values(varyHeader) {
+		for _, listed := range strings.Split(v, ",") {
+
+			listed = strings.TrimSpace(listed)
+
+			if listed == "*" || strings.EqualFold(listed, name) {
+				return
+			}
+		}
+	}
+
+	h.Add(varyHeader, name)
+}
+
+type writerFunc func([]byte) (int, error)
+
+func (wf writerFunc) Write(b []byte) (int, error) {
+	return wf(b)
+}
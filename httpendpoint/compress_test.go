@@ -0,0 +1,248 @@
+package httpendpoint
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"github.com/graniticio/granitic/v2/test"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func compressor() *Compressor {
+	return &Compressor{
+		Encodings:    []string{GzipEncoding, DeflateEncoding},
+		MinSize:      100,
+		ContentTypes: []string{"application/json", "text/*"},
+		Level:        -1,
+	}
+}
+
+func compressedRequest(accept string) *http.Request {
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	if accept != "" {
+		req.Header.Set(acceptEncodingHeader, accept)
+	}
+
+	return req
+}
+
+func TestEncodingNegotiation(t *testing.T) {
+
+	c := compressor()
+
+	test.ExpectString(t, c.negotiate(""), "")
+	test.ExpectString(t, c.negotiate("gzip"), GzipEncoding)
+	test.ExpectString(t, c.negotiate("deflate, gzip"), GzipEncoding)
+	test.ExpectString(t, c.negotiate("gzip;q=0.5, deflate"), DeflateEncoding)
+	test.ExpectString(t, c.negotiate("gzip;q=0, deflate;q=0"), "")
+	test.ExpectString(t, c.negotiate("br"), "")
+	test.ExpectString(t, c.negotiate("*"), GzipEncoding)
+	test.ExpectString(t, c.negotiate("GZIP"), GzipEncoding)
+
+	test.ExpectBool(t, c.compressible("application/json; charset=utf-8"), true)
+	test.ExpectBool(t, c.compressible("text/html"), true)
+	test.ExpectBool(t, c.compressible("image/png"), false)
+	test.ExpectBool(t, c.compressible(""), false)
+}
+
+func TestCompressorValidation(t *testing.T) {
+
+	test.ExpectNil(t, compressor().Validate())
+
+	c := compressor()
+	c.Encodings = []string{"br"}
+
+	if c.Validate() == nil {
+		t.Errorf("Expected unsupported encoding to be rejected")
+	}
+
+	c = compressor()
+	c.Level = 10
+
+	if c.Validate() == nil {
+		t.Errorf("Expected invalid level to be rejected")
+	}
+}
+
+func TestLargeResponseCompressed(t *testing.T) {
+
+	body := strings.Repeat(`{"name":"value"}`, 100)
+
+	for _, encoding := range []string{GzipEncoding, DeflateEncoding} {
+
+		rec := httptest.NewRecorder()
+		w := NewHTTPResponseWriter(rec)
+
+		compressor().Wrap(w, compressedRequest(encoding))
+
+		w.Header().Set(contentTypeHeader, "application/json")
+		w.Header().Set(contentLengthHeader, "1600")
+		w.WriteHeader(http.StatusCreated)
+
+		// Written in chunks smaller than the minimum size
+		for i := 0; i < len(body); i += 50 {
+			w.Write([]byte(body[i : i+50]))
+		}
+
+		if err := w.Finish(); err != nil {
+			t.Fatal(err.Error())
+		}
+
+		test.ExpectInt(t, rec.Code, http.StatusCreated)
+		test.ExpectString(t, rec.Header().Get(contentEncodingHeader), encoding)
+		test.ExpectString(t, rec.Header().Get(varyHeader), acceptEncodingHeader)
+		test.ExpectString(t, rec.Header().Get(contentLengthHeader), "")
+		test.ExpectBool(t, w.Compressed(), true)
+		test.ExpectInt(t, w.UncompressedBytes, len(body))
+		test.ExpectInt(t, w.BytesServed, rec.Body.Len())
+
+		if w.BytesServed >= w.UncompressedBytes {
+			t.Errorf("Expected compressed size to be smaller than uncompressed size")
+		}
+
+		var r io.Reader
+		var err error
+
+		if encoding == GzipEncoding {
+			r, err = gzip.NewReader(bytes.NewReader(rec.Body.Bytes()))
+		} else {
+			r, err = zlib.NewReader(bytes.NewReader(rec.Body.Bytes()))
+		}
+
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		decoded, _ := ioutil.ReadAll(r)
+
+		test.ExpectString(t, string(decoded), body)
+	}
+}
+
+func TestResponsesNotCompressed(t *testing.T) {
+
+	large := []byte(strings.Repeat("a", 200))
+
+	check := func(accept, contentType string, body []byte, alreadyEncoded bool, vary string) {
+
+		rec := httptest.NewRecorder()
+		w := NewHTTPResponseWriter(rec)
+
+		compressor().Wrap(w, compressedRequest(accept))
+
+		w.Header().Set(contentTypeHeader, contentType)
+
+		if alreadyEncoded {
+			w.Header().Set(contentEncodingHeader, "identity")
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write(body)
+		w.Finish()
+
+		test.ExpectBool(t, w.Compressed(), false)
+		test.ExpectString(t, rec.Body.String(), string(body))
+		test.ExpectInt(t, w.BytesServed, len(body))
+		test.ExpectInt(t, w.UncompressedBytes, len(body))
+
+		// Caches need to know that responses with a compressible content type vary by encoding, even if this one wasn't compressed
+		test.ExpectString(t, rec.Header().Get(varyHeader), vary)
+	}
+
+	// Below minimum size
+	check("gzip", "application/json", []byte("{}"), false, acceptEncodingHeader)
+
+	// Content type not allowed
+	check("gzip", "image/png", large, false, "")
+
+	// Client doesn't support compression
+	check("", "application/json", large, false, acceptEncodingHeader)
+	check("br", "application/json", large, false, acceptEncodingHeader)
+
+	// Already encoded
+	check("gzip", "application/json", large, true, "")
+
+	// Response without a body
+	rec := httptest.NewRecorder()
+	w := NewHTTPResponseWriter(rec)
+
+	compressor().Wrap(w, compressedRequest("gzip"))
+	w.WriteHeader(http.StatusNoContent)
+	w.Finish()
+
+	test.ExpectInt(t, rec.Code, http.StatusNoContent)
+	test.ExpectString(t, rec.Header().Get(contentEncodingHeader), "")
+}
+
+func TestVaryNotDuplicated(t *testing.T) {
+
+	rec := httptest.NewRecorder()
+	w := NewHTTPResponseWriter(rec)
+
+	compressor().Wrap(w, compressedRequest(GzipEncoding))
+
+	w.Header().Set(contentTypeHeader, "application/json")
+	w.Header().Set(varyHeader, "Origin, accept-encoding")
+	w.Write([]byte(strings.Repeat("a", 200)))
+	w.Finish()
+
+	test.ExpectBool(t, w.Compressed(), true)
+	test.ExpectInt(t, len(rec.Header().Values(varyHeader)), 1)
+}
+
+func TestFlushWhileCompressing(t *testing.T) {
+
+	var _ http.Flusher = NewHTTPResponseWriter(nil)
+
+	rec := httptest.NewRecorder()
+	w := NewHTTPResponseWriter(rec)
+
+	compressor().Wrap(w, compressedRequest(GzipEncoding))
+
+	w.Header().Set(contentTypeHeader, "text/event-stream")
+	w.WriteHeader(http.StatusOK)
+
+	// Less than the minimum size, so flushing sends the data uncompressed
+	w.Write([]byte("data: 1\n\n"))
+
+	w.Flush()
+
+	test.ExpectBool(t, rec.Flushed, true)
+	test.ExpectString(t, rec.Body.String(), "data: 1\n\n")
+	test.ExpectInt(t, w.BytesServed, rec.Body.Len())
+
+	rec = httptest.NewRecorder()
+	w = NewHTTPResponseWriter(rec)
+
+	compressor().Wrap(w, compressedRequest(GzipEncoding))
+
+	w.Header().Set(contentTypeHeader, "text/event-stream")
+	w.Write([]byte(strings.Repeat("data: 1\n\n", 20)))
+
+	w.Flush()
+
+	test.ExpectBool(t, w.Compressed(), true)
+	test.ExpectInt(t, w.BytesServed, rec.Body.Len())
+
+	// Flushed data can be decoded before the compressed stream is complete
+	r, err := gzip.NewReader(bytes.NewReader(rec.Body.Bytes()))
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	decoded := make([]byte, 180)
+
+	if _, err := io.ReadFull(r, decoded); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	test.ExpectString(t, string(decoded), strings.Repeat("data: 1\n\n", 20))
+}
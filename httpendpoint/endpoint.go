@@ -52,6 +52,21 @@ type Provider interface {
 	AutoWireable() bool
 }
 
+// TemplatedProvider is implemented by Providers whose paths are declared as a PathTemplate. Servers are able to route
+// requests to these Providers without evaluating the Provider's regular expression.
+type TemplatedProvider interface {
+	// ParsedPathTemplate returns the Provider's path template, or nil if the Provider's path is declared as a regular expression.
+	ParsedPathTemplate() *PathTemplate
+}
+
+// BodyLimitedProvider is implemented by Providers that declare the maximum size of the request bodies they accept,
+// overriding the limit set on the server.
+type BodyLimitedProvider interface {
+	// RequestBodyLimit returns the maximum size (in bytes) of a request body. Zero means the server's limit should be used
+	// and a negative value means no limit.
+	RequestBodyLimit() int64
+}
+
 // RequiredVersion is a semi-structured type to allow applications flexibility in defining what a 'version' is.
 type RequiredVersion map[string]interface{}
 
@@ -61,3 +76,10 @@ type RequestedVersionExtractor interface {
 	// Extract examines an HTTP request to determine what version of functionality is required.
 	Extract(*http.Request) RequiredVersion
 }
+
+// VersionedPathExtractor is optionally implemented by RequestedVersionExtractors that find the requested version in the
+// request's path. Servers route requests using the path with the version removed.
+type VersionedPathExtractor interface {
+	// UnversionedPath returns the supplied path with any version it contains removed.
+	UnversionedPath(path string) string
+}
@@ -0,0 +1,198 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package httpendpoint
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// The regular expressions used to match typed path template parameters.
+var pathParamTypes = map[string]string{
+	"string": "[^/]+",
+	"int":    "[-+]?\\d+",
+	"uint":   "\\d+",
+	"float":  "[-+]?(?:\\d+\\.?\\d*|\\.\\d+)",
+	"bool":   "(?i:true|false)",
+	"alpha":  "[A-Za-z]+",
+	"uuid":   "[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}",
+	"path":   ".+",
+}
+
+// Compiled versions of pathParamTypes that match a whole segment
+var pathParamMatchers = make(map[string]*regexp.Regexp)
+
+func init() {
+	for k, v := range pathParamTypes {
+		pathParamMatchers[k] = regexp.MustCompile("^(?:" + v + ")$")
+	}
+}
+
+const defaultPathParamType = "string"
+const pathParamTypeSep = ":"
+
+var validParamName = regexp.MustCompile("^[A-Za-z_][A-Za-z0-9_]*$")
+
+// PathSegment is one slash-separated part of a PathTemplate. A segment is either literal text or a single named parameter.
+type PathSegment struct {
+	// The text of a literal segment (empty if this segment is a parameter).
+	Literal string
+
+	// The name of the parameter (empty if this segment is literal).
+	Param string
+
+	// The type of the parameter (string, int, uint, float, bool, alpha, uuid or path).
+	Type string
+}
+
+// IsParam returns true if this segment is a parameter rather than literal text.
+func (ps PathSegment) IsParam() bool {
+	return ps.Param != ""
+}
+
+// Matches returns true if the supplied segment of a request path would be matched by this segment of a template.
+func (ps PathSegment) Matches(value string) bool {
+
+	if !ps.IsParam() {
+		return ps.Literal == value
+	}
+
+	return pathParamMatchers[ps.Type].MatchString(value)
+}
+
+/*
+PathTemplate is a parsed representation of a request path declared with named parameters rather than as a regular expression, e.g.
+
+	/users/{id}/orders/{orderId:int}
+
+Each parameter occupies a whole segment of the path and may optionally specify a type after a colon. Supported types are:
+
+	string  any characters other than / (the default)
+	int     an optionally signed integer
+	uint    an unsigned integer
+	float   an optionally signed decimal number
+	bool    true or false (case insensitive)
+	alpha   letters only
+	uuid    a hex-encoded UUID
+	path    the remainder of the path, including / characters (only allowed in the final segment)
+*/
+type PathTemplate struct {
+	// The template as originally declared.
+	Template string
+
+	// The segments of the path, in order.
+	Segments []PathSegment
+
+	// The names of the template's parameters, in the order they appear.
+	Params []string
+}
+
+// ParsePathTemplate parses and validates a path template. An error is returned if the template does not start with a /,
+// has a parameter that does not occupy a whole segment, has a duplicate or invalid parameter name or uses an unsupported
+// parameter type.
+func ParsePathTemplate(template string) (*PathTemplate, error) {
+
+	if !strings.HasPrefix(template, "/") {
+		return nil, fmt.Errorf("path template %s must start with /", template)
+	}
+
+	pt := new(PathTemplate)
+	pt.Template = template
+
+	trimmed := strings.TrimPrefix(template, "/")
+
+	if trimmed == "" {
+		return pt, nil
+	}
+
+	trimmed = strings.TrimSuffix(trimmed, "/")
+
+	parts := strings.Split(trimmed, "/")
+	seen := make(map[string]bool)
+
+	for i, p := range parts {
+
+		if p == "" {
+			return nil, fmt.Errorf("path template %s contains an empty segment", template)
+		}
+
+		if !strings.ContainsAny(p, "{}") {
+			pt.Segments = append(pt.Segments, PathSegment{Literal: p})
+			continue
+		}
+
+		if !strings.HasPrefix(p, "{") || !strings.HasSuffix(p, "}") || strings.Count(p, "{") != 1 || strings.Count(p, "}") != 1 {
+			return nil, fmt.Errorf("path template %s has a parameter that does not occupy a whole segment (%s)", template, p)
+		}
+
+		name, kind := splitParam(strings.Trim(p, "{}"))
+
+		if !validParamName.MatchString(name) {
+			return nil, fmt.Errorf("path template %s has an invalid parameter name '%s'", template, name)
+		}
+
+		if seen[name] {
+			return nil, fmt.Errorf("path template %s uses the parameter name %s more than once", template, name)
+		}
+
+		if _, found := pathParamTypes[kind]; !found {
+			return nil, fmt.Errorf("path template %s uses unsupported parameter type %s", template, kind)
+		}
+
+		if kind == "path" && i != len(parts)-1 {
+			return nil, fmt.Errorf("path template %s uses a path parameter (%s) before the final segment", template, name)
+		}
+
+		seen[name] = true
+		pt.Params = append(pt.Params, name)
+		pt.Segments = append(pt.Segments, PathSegment{Param: name, Type: kind})
+	}
+
+	return pt, nil
+}
+
+func splitParam(p string) (name, kind string) {
+
+	s := strings.SplitN(p, pathParamTypeSep, 2)
+
+	name = strings.TrimSpace(s[0])
+	kind = defaultPathParamType
+
+	if len(s) == 2 {
+		kind = strings.TrimSpace(s[1])
+	}
+
+	return name, kind
+}
+
+// Regex returns a regular expression (suitable for use as a handler's PathPattern) that matches the same paths as the
+// template. Each parameter becomes a capture group (in the same order as Params) and a trailing / is optional.
+func (pt *PathTemplate) Regex() string {
+
+	var b strings.Builder
+
+	b.WriteString("^")
+
+	for _, s := range pt.Segments {
+
+		b.WriteString("/")
+
+		if s.IsParam() {
+			b.WriteString("(" + pathParamTypes[s.Type] + ")")
+		} else {
+			b.WriteString(regexp.QuoteMeta(s.Literal))
+		}
+	}
+
+	if len(pt.Segments) == 0 {
+		b.WriteString("/")
+	} else {
+		b.WriteString("[/]?")
+	}
+
+	b.WriteString("$")
+
+	return b.String()
+}
@@ -0,0 +1,76 @@
+package httpendpoint
+
+import (
+	"github.com/graniticio/granitic/v2/test"
+	"regexp"
+	"testing"
+)
+
+func TestParsePathTemplate(t *testing.T) {
+
+	pt, err := ParsePathTemplate("/users/{id}/orders/{orderId:int}")
+
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, len(pt.Segments), 4)
+	test.ExpectInt(t, len(pt.Params), 2)
+	test.ExpectString(t, pt.Params[0], "id")
+	test.ExpectString(t, pt.Params[1], "orderId")
+
+	test.ExpectString(t, pt.Segments[0].Literal, "users")
+	test.ExpectBool(t, pt.Segments[0].IsParam(), false)
+	test.ExpectString(t, pt.Segments[1].Type, "string")
+	test.ExpectString(t, pt.Segments[3].Type, "int")
+
+	pt, err = ParsePathTemplate("/")
+
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, len(pt.Segments), 0)
+	test.ExpectString(t, pt.Regex(), "^/$")
+}
+
+func TestInvalidPathTemplates(t *testing.T) {
+
+	invalid := []string{
+		"users/{id}",
+		"/users//{id}",
+		"/users/id{id}",
+		"/users/{id}{name}",
+		"/users/{id",
+		"/users/{1d}",
+		"/users/{id}/orders/{id}",
+		"/users/{id:date}",
+		"/files/{name:path}/meta",
+	}
+
+	for _, tmpl := range invalid {
+		if _, err := ParsePathTemplate(tmpl); err == nil {
+			t.Errorf("Expected %s to be rejected", tmpl)
+		}
+	}
+}
+
+func TestPathTemplateRegex(t *testing.T) {
+
+	pt, err := ParsePathTemplate("/users/{id}/orders/{orderId:int}")
+	test.ExpectNil(t, err)
+
+	r := regexp.MustCompile(pt.Regex())
+
+	m := r.FindStringSubmatch("/users/bob/orders/42")
+	test.ExpectInt(t, len(m), 3)
+	test.ExpectString(t, m[1], "bob")
+	test.ExpectString(t, m[2], "42")
+
+	test.ExpectBool(t, r.MatchString("/users/bob/orders/42/"), true)
+	test.ExpectBool(t, r.MatchString("/users/bob/orders/abc"), false)
+	test.ExpectBool(t, r.MatchString("/users/bob/x/orders/42"), false)
+	test.ExpectBool(t, r.MatchString("/users/b.b/orders/42"), true)
+
+	pt, err = ParsePathTemplate("/v1.0/files/{name:path}")
+	test.ExpectNil(t, err)
+
+	r = regexp.MustCompile(pt.Regex())
+
+	test.ExpectBool(t, r.MatchString("/v1.0/files/a/b/c.txt"), true)
+	test.ExpectBool(t, r.MatchString("/v1x0/files/a"), false)
+}
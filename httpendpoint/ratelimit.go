@@ -0,0 +1,319 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package httpendpoint
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// RemoteAddressKey identifies clients by the IP address the request was received from.
+	RemoteAddressKey = "REMOTE"
+
+	// HeaderKey identifies clients by the value of a request header.
+	HeaderKey = "HEADER"
+
+	// UserKey identifies clients by the LoggableUserID of the identity established for the request. As identities are
+	// established by handlers, limits keyed this way are enforced by the handler rather than the server. Requests to
+	// Providers that do not implement DeferredRateLimitEnforcer are limited by remote address instead.
+	UserKey = "USER"
+)
+
+const (
+	// RetryAfterHeader tells a rate limited client how many seconds to wait before making another request.
+	RetryAfterHeader = "Retry-After"
+
+	// RateLimitLimitHeader is the maximum number of requests a client can make in a burst.
+	RateLimitLimitHeader = "RateLimit-Limit"
+
+	// RateLimitRemainingHeader is the number of requests the client can currently make.
+	RateLimitRemainingHeader = "RateLimit-Remaining"
+
+	// RateLimitResetHeader is the number of seconds until the client's allowance is fully restored.
+	RateLimitResetHeader = "RateLimit-Reset"
+)
+
+// How often buckets that have been idle long enough to be full are discarded
+const sweepInterval = time.Minute
+
+// RateLimit describes a token bucket limit on the rate at which a client can make requests.
+type RateLimit struct {
+	// Whether or not requests should be rate limited.
+	Enabled bool
+
+	// The rate (requests per second) at which a client's allowance is replenished.
+	RequestsPerSecond float64
+
+	// The maximum number of requests a client can make in a burst. Defaults to RequestsPerSecond (rounded up) if not set.
+	Burst int
+
+	// How clients are identified: REMOTE (default), HEADER or USER.
+	Key string
+
+	// The request header used to identify clients if Key is HEADER.
+	Header string
+}
+
+// Validate checks that the RateLimit describes a usable limit.
+func (rl *RateLimit) Validate() error {
+
+	if rl.RequestsPerSecond <= 0 {
+		return fmt.Errorf("RequestsPerSecond must be greater than zero")
+	}
+
+	if rl.Burst < 0 {
+		return fmt.Errorf("Burst cannot be negative")
+	}
+
+	switch rl.Key {
+	case "", RemoteAddressKey, UserKey:
+	case HeaderKey:
+		if rl.Header == "" {
+			return fmt.Errorf("Header must be set when Key is %s", HeaderKey)
+		}
+	default:
+		return fmt.Errorf("%s is not a supported Key. Must be one of %s, %s or %s", rl.Key, RemoteAddressKey, HeaderKey, UserKey)
+	}
+
+	return nil
+}
+
+// RateLimitedProvider is implemented by Providers that declare their own rate limit, overriding the limit set on the server.
+type RateLimitedProvider interface {
+	// RequestRateLimit returns the rate limit for requests served by this Provider, or nil if the server's limit applies.
+	RequestRateLimit() *RateLimit
+}
+
+// DeferredRateLimitEnforcer is implemented by Providers that enforce limits keyed by USER (see DeferredRateLimit) once
+// they have established the client's identity.
+type DeferredRateLimitEnforcer interface {
+	// EnforcesDeferredRateLimit returns true if the Provider enforces the RateLimiter stored in the context passed to
+	// ServeHTTP.
+	EnforcesDeferredRateLimit() bool
+}
+
+// RateLimitDecision is the result of a client attempting to make a request.
+type RateLimitDecision struct {
+	// Whether or not the request is allowed.
+	Allowed bool
+
+	// The maximum number of requests the client can make in a burst.
+	Limit int
+
+	// The number of requests the client can make immediately.
+	Remaining int
+
+	// The time until the client's allowance is fully restored.
+	Reset time.Duration
+
+	// If the request was not allowed, the time until the client can make another request.
+	RetryAfter time.Duration
+}
+
+// WriteHeaders sets RateLimit-* headers (and a Retry-After header if the request was not allowed) on the supplied response.
+func (d RateLimitDecision) WriteHeaders(w http.ResponseWriter) {
+
+	h := w.Header()
+
+	h.Set(RateLimitLimitHeader, strconv.Itoa(d.Limit))
+	h.Set(RateLimitRemainingHeader, strconv.Itoa(d.Remaining))
+	h.Set(RateLimitResetHeader, strconv.Itoa(ceilSeconds(d.Reset)))
+
+	if !d.Allowed {
+		h.Set(RetryAfterHeader, strconv.Itoa(ceilSeconds(d.RetryAfter)))
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// BucketStats describes the current state of a single client's token bucket.
+type BucketStats struct {
+	// The key identifying the client.
+	Key string
+
+	// The number of requests the client can currently make.
+	Remaining int
+
+	// The maximum number of requests the client can make in a burst.
+	Limit int
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// RateLimiter maintains a token bucket for each client identified by the keys passed to Take.
+type RateLimiter struct {
+	limit     RateLimit
+	burst     float64
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	mutex     sync.Mutex
+	now       func() time.Time
+}
+
+// NewRateLimiter creates a RateLimiter enforcing the supplied limit.
+func NewRateLimiter(rl RateLimit) *RateLimiter {
+
+	burst := rl.Burst
+
+	if burst == 0 {
+		burst = int(math.Ceil(rl.RequestsPerSecond))
+	}
+
+	r := new(RateLimiter)
+	r.limit = rl
+	r.burst = float64(burst)
+	r.buckets = make(map[string]*bucket)
+	r.now = time.Now
+
+	return r
+}
+
+// Limit returns the RateLimit this limiter enforces.
+func (r *RateLimiter) Limit() RateLimit {
+	return r.limit
+}
+
+// KeyFor returns the key identifying the client that made the supplied request, according to the limit's Key setting.
+// Returns an empty string for limits keyed by USER, which cannot be determined from the request alone.
+func (r *RateLimiter) KeyFor(req *http.Request) string {
+
+	switch r.limit.Key {
+	case UserKey:
+		return ""
+	case HeaderKey:
+		if v := req.Header.Get(r.limit.Header); v != "" {
+			return v
+		}
+	}
+
+	return RemoteHost(req)
+}
+
+// Take attempts to remove a token from the bucket for the supplied key.
+func (r *RateLimiter) Take(key string) RateLimitDecision {
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := r.now()
+
+	r.sweep(now)
+
+	b := r.buckets[key]
+
+	if b == nil {
+		b = &bucket{tokens: r.burst, updated: now}
+		r.buckets[key] = b
+	} else {
+		r.refill(b, now)
+	}
+
+	d := RateLimitDecision{Limit: int(r.burst)}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		d.Allowed = true
+	} else {
+		d.RetryAfter = r.timeToAccrue(1 - b.tokens)
+	}
+
+	d.Remaining = int(b.tokens)
+	d.Reset = r.timeToAccrue(r.burst - b.tokens)
+
+	return d
+}
+
+// Stats returns the state of every client's bucket, ordered by key.
+func (r *RateLimiter) Stats() []BucketStats {
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := r.now()
+
+	var stats []BucketStats
+
+	for k, b := range r.buckets {
+		r.refill(b, now)
+		stats = append(stats, BucketStats{Key: k, Remaining: int(b.tokens), Limit: int(r.burst)})
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Key < stats[j].Key
+	})
+
+	return stats
+}
+
+func (r *RateLimiter) refill(b *bucket, now time.Time) {
+
+	b.tokens = math.Min(r.burst, b.tokens+now.Sub(b.updated).Seconds()*r.limit.RequestsPerSecond)
+	b.updated = now
+}
+
+func (r *RateLimiter) timeToAccrue(tokens float64) time.Duration {
+	return time.Duration(tokens / r.limit.RequestsPerSecond * float64(time.Second))
+}
+
+// sweep discards buckets that have refilled completely, as they are indistinguishable from new buckets
+func (r *RateLimiter) sweep(now time.Time) {
+
+	if now.Sub(r.lastSweep) < sweepInterval {
+		return
+	}
+
+	r.lastSweep = now
+
+	for k, b := range r.buckets {
+
+		r.refill(b, now)
+
+		if b.tokens >= r.burst {
+			delete(r.buckets, k)
+		}
+	}
+}
+
+// RemoteHost returns the host portion of the request's remote address.
+func RemoteHost(req *http.Request) string {
+
+	if host, _, err := net.SplitHostPort(req.RemoteAddr); err == nil {
+		return host
+	}
+
+	return req.RemoteAddr
+}
+
+type deferredLimiterKey string
+
+const deferredLimiter deferredLimiterKey = "GRNCRATELIMITER"
+
+// WithDeferredRateLimit stores a RateLimiter in the supplied context, so that a Provider can enforce it once the client's
+// identity has been established.
+func WithDeferredRateLimit(ctx context.Context, r *RateLimiter) context.Context {
+	return context.WithValue(ctx, deferredLimiter, r)
+}
+
+// DeferredRateLimit returns the RateLimiter stored in the context by WithDeferredRateLimit, or nil if there is none.
+func DeferredRateLimit(ctx context.Context) *RateLimiter {
+
+	if r, found := ctx.Value(deferredLimiter).(*RateLimiter); found {
+		return r
+	}
+
+	return nil
+}
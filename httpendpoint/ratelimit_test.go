@@ -0,0 +1,80 @@
+package httpendpoint
+
+import (
+	"context"
+	"github.com/graniticio/granitic/v2/test"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	r := NewRateLimiter(RateLimit{Enabled: true, RequestsPerSecond: 2, Burst: 3})
+	r.now = func() time.Time { return now }
+
+	for i := 2; i >= 0; i-- {
+		d := r.Take("a")
+
+		test.ExpectBool(t, d.Allowed, true)
+		test.ExpectInt(t, d.Remaining, i)
+		test.ExpectInt(t, d.Limit, 3)
+	}
+
+	d := r.Take("a")
+
+	test.ExpectBool(t, d.Allowed, false)
+	test.ExpectBool(t, d.RetryAfter == 500*time.Millisecond, true)
+	test.ExpectBool(t, d.Reset == 1500*time.Millisecond, true)
+
+	// Other clients have their own bucket
+	test.ExpectBool(t, r.Take("b").Allowed, true)
+
+	now = now.Add(500 * time.Millisecond)
+	test.ExpectBool(t, r.Take("a").Allowed, true)
+	test.ExpectBool(t, r.Take("a").Allowed, false)
+
+	stats := r.Stats()
+
+	test.ExpectInt(t, len(stats), 2)
+	test.ExpectString(t, stats[0].Key, "a")
+	test.ExpectInt(t, stats[0].Remaining, 0)
+	test.ExpectInt(t, stats[1].Remaining, 3)
+
+	// Full buckets are discarded
+	now = now.Add(2 * sweepInterval)
+	r.Take("c")
+
+	test.ExpectInt(t, len(r.Stats()), 1)
+}
+
+func TestDefaultBurst(t *testing.T) {
+
+	r := NewRateLimiter(RateLimit{Enabled: true, RequestsPerSecond: 0.5})
+
+	test.ExpectInt(t, r.Take("a").Limit, 1)
+	test.ExpectBool(t, r.Take("a").Allowed, false)
+}
+
+func TestRateLimitKeys(t *testing.T) {
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "10.0.0.1:5000"
+	req.Header.Set("X-API-Key", "abc")
+
+	test.ExpectString(t, NewRateLimiter(RateLimit{RequestsPerSecond: 1}).KeyFor(req), "10.0.0.1")
+	test.ExpectString(t, NewRateLimiter(RateLimit{RequestsPerSecond: 1, Key: HeaderKey, Header: "X-API-Key"}).KeyFor(req), "abc")
+	test.ExpectString(t, NewRateLimiter(RateLimit{RequestsPerSecond: 1, Key: HeaderKey, Header: "X-Other"}).KeyFor(req), "10.0.0.1")
+	test.ExpectString(t, NewRateLimiter(RateLimit{RequestsPerSecond: 1, Key: UserKey}).KeyFor(req), "")
+
+	test.ExpectNil(t, (&RateLimit{RequestsPerSecond: 1, Key: UserKey}).Validate())
+	test.ExpectBool(t, (&RateLimit{RequestsPerSecond: 0}).Validate() != nil, true)
+	test.ExpectBool(t, (&RateLimit{RequestsPerSecond: 1, Key: HeaderKey}).Validate() != nil, true)
+	test.ExpectBool(t, (&RateLimit{RequestsPerSecond: 1, Key: "COOKIE"}).Validate() != nil, true)
+
+	r := NewRateLimiter(RateLimit{RequestsPerSecond: 1, Key: UserKey})
+	test.ExpectBool(t, DeferredRateLimit(WithDeferredRateLimit(context.Background(), r)) == r, true)
+	test.ExpectBool(t, DeferredRateLimit(context.Background()) == nil, true)
+}
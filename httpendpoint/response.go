@@ -14,8 +14,14 @@ type HTTPResponseWriter struct {
 	// The HTTP status code sent to the response or zero if no code yet sent.
 	Status int
 
-	// How many bytes have been sent to the response so far (excluding headers).
+	// How many bytes have been sent to the response so far (excluding headers). If the response is being compressed, this
+	// is the number of compressed bytes.
 	BytesServed int
+
+	// How many bytes have been written to the response so far (excluding headers), before any compression.
+	UncompressedBytes int
+
+	compressor *compressingWriter
 }
 
 // Header calls through to http.ResponseWriter.Header()
@@ -26,9 +32,13 @@ func (w *HTTPResponseWriter) Header() http.Header {
 // Write calls through to http.ResponseWriter.Write while keeping track of the number of bytes sent.
 func (w *HTTPResponseWriter) Write(b []byte) (int, error) {
 
-	w.BytesServed += len(b)
+	w.UncompressedBytes += len(b)
 	w.DataSent = true
 
+	if w.compressor == nil {
+		w.BytesServed += len(b)
+	}
+
 	return w.rw.Write(b)
 }
 
@@ -45,6 +55,42 @@ func (w *HTTPResponseWriter) WriteHeader(i int) {
 	w.DataSent = true
 }
 
+// Compressed returns true if the response body is being compressed.
+func (w *HTTPResponseWriter) Compressed() bool {
+	return w.compressor != nil && w.compressor.encoder != nil
+}
+
+// Flush sends any data written so far to the client, including data buffered while deciding whether or not to compress
+// the response, then flushes the underlying http.ResponseWriter. Used when a response is streamed to the client over
+// time. Implements http.Flusher; any error writing buffered data will also be returned by the next call to Write.
+func (w *HTTPResponseWriter) Flush() {
+
+	rw := w.rw
+
+	if w.compressor != nil {
+		if err := w.compressor.flush(); err != nil {
+			return
+		}
+
+		rw = w.compressor.ResponseWriter
+	}
+
+	if f, found := rw.(http.Flusher); found {
+		f.Flush()
+	}
+}
+
+// Finish completes the response, writing any data that has been buffered while deciding whether or not to compress the
+// response. Called by Granitic after a request has been processed; no further data can be written to the response.
+func (w *HTTPResponseWriter) Finish() error {
+
+	if w.compressor == nil {
+		return nil
+	}
+
+	return w.compressor.finish()
+}
+
 // NewHTTPResponseWriter creates a new HTTPResponseWriter wrapping the supplied http.ResponseWriter
 func NewHTTPResponseWriter(rw http.ResponseWriter) *HTTPResponseWriter {
 	w := new(HTTPResponseWriter)
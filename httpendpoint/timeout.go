@@ -0,0 +1,144 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package httpendpoint
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// TimeLimitedProvider is implemented by Providers that declare the maximum time they may take to serve a request,
+// overriding the timeout set on the server.
+type TimeLimitedProvider interface {
+	// RequestTimeout returns the maximum time allowed to serve a request. Zero means the server's timeout should be used
+	// and a negative value means no timeout.
+	RequestTimeout() time.Duration
+}
+
+// DeadlineEnforcer is implemented by Providers that respond to the client themselves if the deadline of the context
+// passed to ServeHTTP passes before the request has been served. The HTTP server enforces the deadline on behalf of other
+// Providers.
+type DeadlineEnforcer interface {
+	// EnforcesDeadline returns true if the Provider enforces the deadline of the context passed to ServeHTTP.
+	EnforcesDeadline() bool
+}
+
+type timeoutStatusKey string
+
+const timeoutStatus timeoutStatusKey = "GRNCTIMEOUTSTATUS"
+
+// WithRequestTimeout derives a context whose deadline is the supplied time from now. The HTTP status code that should be
+// returned to the client if the deadline passes is also stored in the context.
+func WithRequestTimeout(ctx context.Context, timeout time.Duration, status int) (context.Context, context.CancelFunc) {
+
+	ctx = context.WithValue(ctx, timeoutStatus, status)
+
+	return context.WithTimeout(ctx, timeout)
+}
+
+// TimeoutStatus returns the HTTP status code stored in the context by WithRequestTimeout, or 504 (Gateway Timeout)
+// if none has been stored.
+func TimeoutStatus(ctx context.Context) int {
+
+	if s, found := ctx.Value(timeoutStatus).(int); found && s != 0 {
+		return s
+	}
+
+	return http.StatusGatewayTimeout
+}
+
+// DeadlineWriter is an http.ResponseWriter used when a request is served in a separate goroutine so that an error can be
+// sent to the client as soon as the request's deadline passes. It passes the response through to an HTTPResponseWriter
+// until it is expired, after which anything written is discarded. Headers are held separately until the response is
+// started so that they are not modified after expiry. Safe for concurrent use.
+type DeadlineWriter struct {
+	w       *HTTPResponseWriter
+	header  http.Header
+	started bool
+	expired bool
+	mutex   sync.Mutex
+}
+
+// NewDeadlineWriter creates a DeadlineWriter passing the response through to the supplied HTTPResponseWriter. Any
+// headers already set on the HTTPResponseWriter are copied.
+func NewDeadlineWriter(w *HTTPResponseWriter) *DeadlineWriter {
+
+	dw := new(DeadlineWriter)
+	dw.w = w
+	dw.header = w.Header().Clone()
+
+	return dw
+}
+
+// Header implements http.ResponseWriter.Header
+func (dw *DeadlineWriter) Header() http.Header {
+	return dw.header
+}
+
+// WriteHeader implements http.ResponseWriter.WriteHeader
+func (dw *DeadlineWriter) WriteHeader(status int) {
+
+	dw.mutex.Lock()
+	defer dw.mutex.Unlock()
+
+	dw.start(status)
+}
+
+// Write implements http.ResponseWriter.Write. Data written after the DeadlineWriter has expired is discarded.
+func (dw *DeadlineWriter) Write(b []byte) (int, error) {
+
+	dw.mutex.Lock()
+	defer dw.mutex.Unlock()
+
+	if dw.expired {
+		// The timeout has already been reported, so late writes are discarded silently
+		return len(b), nil
+	}
+
+	dw.start(http.StatusOK)
+
+	return dw.w.Write(b)
+}
+
+// Flush implements http.Flusher
+func (dw *DeadlineWriter) Flush() {
+
+	dw.mutex.Lock()
+	defer dw.mutex.Unlock()
+
+	if !dw.expired {
+		dw.w.Flush()
+	}
+}
+
+// start sends the headers and status to the client, if that has not already happened. Must be called while holding the mutex.
+func (dw *DeadlineWriter) start(status int) {
+
+	if dw.expired || dw.started {
+		return
+	}
+
+	h := dw.w.Header()
+
+	for k, vs := range dw.header {
+		h[k] = vs
+	}
+
+	dw.w.WriteHeader(status)
+	dw.started = true
+}
+
+// Expire prevents anything else being written and returns true if the response had already been started (in which case
+// it is too late to send an error to the client).
+func (dw *DeadlineWriter) Expire() bool {
+
+	dw.mutex.Lock()
+	defer dw.mutex.Unlock()
+
+	dw.expired = true
+
+	return dw.started
+}
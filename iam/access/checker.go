@@ -0,0 +1,204 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+/*
+Package access provides a ws.AccessChecker that decides whether a caller may use an endpoint by evaluating declarative
+rules against the caller's iam.ClientIdentity.
+
+A RuleChecker can be declared directly in a component definition file:
+
+	"adminOnly": {
+	  "type": "access.RuleChecker",
+	  "Rule": "role:admin OR (role:editor AND scope:articles.write)"
+	},
+
+	"deleteArticleHandler": {
+	  "type": "handler.WsHandler",
+	  "AccessChecker": "ref:adminOnly",
+	  ...
+	}
+
+or created by enabling the AccessControl facility, in which case a single shared RuleChecker reads a rule for each handler
+from configuration:
+
+	{
+	  "AccessControl": {
+		"Rules": {
+		  "deleteArticleHandler": "role:admin",
+		  "listArticlesHandler": "scope:articles.read"
+		}
+	  }
+	}
+
+See ParseExpression for the syntax of rules. Roles and scopes are read from the keys in the ClientIdentity named by
+RolesKey and ScopesKey (Roles and Scopes by default) and may be stored as a slice or as a space separated string (as
+is conventional for OAuth scopes).
+
+Callers that are refused access receive a 403 response containing a Security service error whose code and message are
+set by DeniedCode and DeniedMessage.
+*/
+package access
+
+import (
+	"context"
+	"fmt"
+	"github.com/graniticio/granitic/v2/iam"
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/ws"
+)
+
+// RuleChecker is an implementation of ws.AccessChecker that evaluates a rule for the handler that is serving the request.
+type RuleChecker struct {
+	// Injected by Granitic
+	FrameworkLogger logging.Logger
+
+	// A rule that applies to any handler that does not have a handler-specific rule in Rules.
+	Rule string
+
+	// A map of handler component names to the rule that applies to that handler.
+	Rules map[string]string
+
+	// Roles that a caller must have (in addition to satisfying any rule).
+	RequiredRoles []string
+
+	// Scopes that a caller must have been granted (in addition to satisfying any rule).
+	RequiredScopes []string
+
+	// The key in the iam.ClientIdentity that holds the caller's roles.
+	RolesKey string
+
+	// The key in the iam.ClientIdentity that holds the caller's scopes.
+	ScopesKey string
+
+	// The code of the Security service error returned to callers who are refused access.
+	DeniedCode string
+
+	// The message of the Security service error returned to callers who are refused access.
+	DeniedMessage string
+
+	keys        *IdentityKeys
+	defaultRule Expression
+	byHandler   map[string]Expression
+	required    Expression
+	state       ioc.ComponentState
+}
+
+// Allowed implements ws.AccessChecker. Callers are refused access if no rule applies to the serving handler.
+func (rc *RuleChecker) Allowed(ctx context.Context, r *ws.Request) bool {
+
+	ci := r.UserIdentity
+
+	if ci == nil {
+		ci = iam.NewAnonymousIdentity()
+	}
+
+	if rc.required != nil && !rc.required.Matches(ci, rc.keys) {
+		return false
+	}
+
+	e := rc.byHandler[r.ServingHandler]
+
+	if e == nil {
+		e = rc.defaultRule
+	}
+
+	if e == nil {
+
+		if rc.required != nil {
+			return true
+		}
+
+		rc.FrameworkLogger.LogWarnfCtx(ctx, "No access rule defined for handler %s - refusing access", r.ServingHandler)
+		return false
+	}
+
+	return e.Matches(ci, rc.keys)
+}
+
+// DeniedError implements ws.AccessDeniedErrorSource
+func (rc *RuleChecker) DeniedError(ctx context.Context, r *ws.Request) *ws.CategorisedError {
+	return ws.NewCategorisedError(ws.Security, rc.DeniedCode, rc.DeniedMessage)
+}
+
+// HasRule returns true if a handler-specific rule has been defined for the named handler.
+func (rc *RuleChecker) HasRule(handlerName string) bool {
+	_, found := rc.Rules[handlerName]
+
+	return found
+}
+
+// StartComponent compiles the checker's rules, returning an error if any of them are invalid.
+func (rc *RuleChecker) StartComponent() error {
+
+	if rc.state != ioc.StoppedState {
+		return nil
+	}
+
+	rc.state = ioc.StartingState
+
+	if rc.RolesKey == "" {
+		rc.RolesKey = "Roles"
+	}
+
+	if rc.ScopesKey == "" {
+		rc.ScopesKey = "Scopes"
+	}
+
+	if rc.DeniedCode == "" {
+		rc.DeniedCode = "ACCESS_DENIED"
+	}
+
+	if rc.DeniedMessage == "" {
+		rc.DeniedMessage = "You do not have permission to interact with that resource."
+	}
+
+	rc.keys = &IdentityKeys{Roles: rc.RolesKey, Scopes: rc.ScopesKey}
+
+	var err error
+
+	if rc.Rule != "" {
+		if rc.defaultRule, err = ParseExpression(rc.Rule); err != nil {
+			return err
+		}
+	}
+
+	rc.byHandler = make(map[string]Expression)
+
+	for h, rule := range rc.Rules {
+		if rc.byHandler[h], err = ParseExpression(rule); err != nil {
+			return fmt.Errorf("problem with access rule for handler %s: %s", h, err.Error())
+		}
+	}
+
+	rc.required = rc.requiredExpression()
+
+	rc.state = ioc.RunningState
+
+	return nil
+}
+
+func (rc *RuleChecker) requiredExpression() Expression {
+
+	var terms []Expression
+
+	for _, r := range rc.RequiredRoles {
+		terms = append(terms, &term{kind: roleTerm, name: r})
+	}
+
+	for _, s := range rc.RequiredScopes {
+		terms = append(terms, &term{kind: scopeTerm, name: s})
+	}
+
+	if len(terms) == 0 {
+		return nil
+	}
+
+	e := terms[0]
+
+	for _, t := range terms[1:] {
+		e = &andExpression{e, t}
+	}
+
+	return e
+}
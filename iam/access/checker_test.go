@@ -0,0 +1,107 @@
+package access
+
+import (
+	"context"
+	"github.com/graniticio/granitic/v2/iam"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"github.com/graniticio/granitic/v2/ws"
+	"testing"
+)
+
+func TestCheckerWithHandlerRules(t *testing.T) {
+
+	rc := newTestChecker()
+	rc.Rules = map[string]string{
+		"deleteHandler": "role:admin",
+		"readHandler":   "scope:articles.read",
+	}
+
+	test.ExpectNil(t, rc.StartComponent())
+
+	admin := iam.NewAuthenticatedIdentity("admin")
+	admin["Roles"] = []string{"admin"}
+
+	reader := iam.NewAuthenticatedIdentity("reader")
+	reader["Scopes"] = "articles.read"
+
+	ctx := context.Background()
+
+	test.ExpectBool(t, rc.Allowed(ctx, request("deleteHandler", admin)), true)
+	test.ExpectBool(t, rc.Allowed(ctx, request("deleteHandler", reader)), false)
+	test.ExpectBool(t, rc.Allowed(ctx, request("readHandler", reader)), true)
+	test.ExpectBool(t, rc.Allowed(ctx, request("readHandler", nil)), false)
+
+	// No rule for handler - deny by default
+	test.ExpectBool(t, rc.Allowed(ctx, request("otherHandler", admin)), false)
+
+	test.ExpectBool(t, rc.HasRule("readHandler"), true)
+	test.ExpectBool(t, rc.HasRule("otherHandler"), false)
+
+	e := rc.DeniedError(ctx, request("readHandler", nil))
+
+	test.ExpectString(t, e.Code, "ACCESS_DENIED")
+
+	if e.Category != ws.Security {
+		t.Errorf("Expected a Security error")
+	}
+}
+
+func TestCheckerWithDefaultAndRequired(t *testing.T) {
+
+	rc := newTestChecker()
+	rc.Rule = "role:editor OR role:admin"
+	rc.RequiredScopes = []string{"articles"}
+	rc.RolesKey = "groups"
+	rc.DeniedCode = "NO"
+
+	test.ExpectNil(t, rc.StartComponent())
+
+	ci := iam.NewAuthenticatedIdentity("user")
+	ci["groups"] = "editor"
+
+	ctx := context.Background()
+
+	test.ExpectBool(t, rc.Allowed(ctx, request("any", ci)), false)
+
+	ci["Scopes"] = "articles"
+
+	test.ExpectBool(t, rc.Allowed(ctx, request("any", ci)), true)
+
+	test.ExpectString(t, rc.DeniedError(ctx, nil).Code, "NO")
+
+	// Required roles/scopes on their own are sufficient
+	rc = newTestChecker()
+	rc.RequiredRoles = []string{"admin"}
+
+	test.ExpectNil(t, rc.StartComponent())
+
+	ci["Roles"] = "admin"
+
+	test.ExpectBool(t, rc.Allowed(ctx, request("any", ci)), true)
+}
+
+func TestCheckerInvalidRule(t *testing.T) {
+
+	rc := newTestChecker()
+	rc.Rules = map[string]string{"h": "role:a AND"}
+
+	if rc.StartComponent() == nil {
+		t.Fatalf("Expected invalid rule to be rejected")
+	}
+}
+
+func newTestChecker() *RuleChecker {
+	rc := new(RuleChecker)
+	rc.FrameworkLogger = new(logging.ConsoleErrorLogger)
+
+	return rc
+}
+
+func request(handler string, ci iam.ClientIdentity) *ws.Request {
+	r := new(ws.Request)
+	r.ServingHandler = handler
+	r.UserIdentity = ci
+
+	return r
+}
@@ -0,0 +1,320 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package access
+
+import (
+	"fmt"
+	"github.com/graniticio/granitic/v2/iam"
+	"strings"
+)
+
+const (
+	rolePrefix  = "role:"
+	scopePrefix = "scope:"
+	opAnd       = "AND"
+	opOr        = "OR"
+	opNot       = "NOT"
+)
+
+// Expression is a compiled access rule that can be evaluated against a caller's identity.
+type Expression interface {
+	// Matches returns true if the supplied identity satisfies the rule.
+	Matches(ci iam.ClientIdentity, keys *IdentityKeys) bool
+}
+
+// IdentityKeys records which keys in an iam.ClientIdentity hold the caller's roles and scopes.
+type IdentityKeys struct {
+	Roles  string
+	Scopes string
+}
+
+/*
+ParseExpression compiles a textual access rule. Rules are made up of terms combined with AND, OR, NOT and parentheses
+(operators are case insensitive, AND binds more tightly than OR). A term is one of:
+
+	role:name     the caller has the named role
+	scope:name    the caller has been granted the named scope
+	Key=value     the identity has a value for Key equal to (or, for lists, containing) value
+	Key           the identity has a non-empty, non-false value for Key
+
+For example:
+
+	role:admin OR (role:editor AND scope:articles.write)
+*/
+func ParseExpression(rule string) (Expression, error) {
+
+	p := new(parser)
+	p.tokens = tokenise(rule)
+
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("access rule is empty")
+	}
+
+	e, err := p.parseOr()
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid access rule '%s': %s", rule, err.Error())
+	}
+
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("invalid access rule '%s': unexpected '%s'", rule, p.tokens[p.pos])
+	}
+
+	return e, nil
+}
+
+func tokenise(rule string) []string {
+
+	var tokens []string
+	var current strings.Builder
+
+	flush := func() {
+		if current.Len() > 0 {
+			tokens = append(tokens, current.String())
+			current.Reset()
+		}
+	}
+
+	for _, r := range rule {
+		switch r {
+		case '(', ')':
+			flush()
+			tokens = append(tokens, string(r))
+		case ' ', '\t', '\n', '\r':
+			flush()
+		default:
+			current.WriteRune(r)
+		}
+	}
+
+	flush()
+
+	return tokens
+}
+
+type parser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *parser) peekOperator(op string) bool {
+	return p.pos < len(p.tokens) && strings.EqualFold(p.tokens[p.pos], op)
+}
+
+func (p *parser) parseOr() (Expression, error) {
+
+	left, err := p.parseAnd()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peekOperator(opOr) {
+		p.pos++
+
+		right, err := p.parseAnd()
+
+		if err != nil {
+			return nil, err
+		}
+
+		left = &orExpression{left, right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expression, error) {
+
+	left, err := p.parseNot()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peekOperator(opAnd) {
+		p.pos++
+
+		right, err := p.parseNot()
+
+		if err != nil {
+			return nil, err
+		}
+
+		left = &andExpression{left, right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseNot() (Expression, error) {
+
+	if p.peekOperator(opNot) {
+		p.pos++
+
+		e, err := p.parseNot()
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &notExpression{e}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Expression, error) {
+
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("unexpected end of rule")
+	}
+
+	t := p.tokens[p.pos]
+	p.pos++
+
+	switch {
+	case t == "(":
+		e, err := p.parseOr()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if p.pos >= len(p.tokens) || p.tokens[p.pos] != ")" {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+
+		p.pos++
+
+		return e, nil
+
+	case t == ")" || strings.EqualFold(t, opAnd) || strings.EqualFold(t, opOr):
+		return nil, fmt.Errorf("unexpected '%s'", t)
+
+	case strings.HasPrefix(t, rolePrefix):
+		return newTerm(t, roleTerm, strings.TrimPrefix(t, rolePrefix), "")
+
+	case strings.HasPrefix(t, scopePrefix):
+		return newTerm(t, scopeTerm, strings.TrimPrefix(t, scopePrefix), "")
+
+	case strings.Contains(t, "="):
+		kv := strings.SplitN(t, "=", 2)
+		return newTerm(t, valueTerm, kv[0], kv[1])
+
+	default:
+		return newTerm(t, presentTerm, t, "")
+	}
+}
+
+type andExpression struct {
+	left, right Expression
+}
+
+func (e *andExpression) Matches(ci iam.ClientIdentity, k *IdentityKeys) bool {
+	return e.left.Matches(ci, k) && e.right.Matches(ci, k)
+}
+
+type orExpression struct {
+	left, right Expression
+}
+
+func (e *orExpression) Matches(ci iam.ClientIdentity, k *IdentityKeys) bool {
+	return e.left.Matches(ci, k) || e.right.Matches(ci, k)
+}
+
+type notExpression struct {
+	e Expression
+}
+
+func (e *notExpression) Matches(ci iam.ClientIdentity, k *IdentityKeys) bool {
+	return !e.e.Matches(ci, k)
+}
+
+type termType int
+
+const (
+	roleTerm termType = iota
+	scopeTerm
+	valueTerm
+	presentTerm
+)
+
+type term struct {
+	kind  termType
+	name  string
+	value string
+}
+
+func newTerm(raw string, kind termType, name, value string) (Expression, error) {
+
+	if name == "" || (kind == valueTerm && value == "") {
+		return nil, fmt.Errorf("incomplete term '%s'", raw)
+	}
+
+	return &term{kind: kind, name: name, value: value}, nil
+}
+
+func (t *term) Matches(ci iam.ClientIdentity, k *IdentityKeys) bool {
+
+	switch t.kind {
+	case roleTerm:
+		return contains(ci[k.Roles], t.name)
+	case scopeTerm:
+		return contains(ci[k.Scopes], t.name)
+	case valueTerm:
+		return contains(ci[t.name], t.value)
+	default:
+		return present(ci[t.name])
+	}
+}
+
+// contains checks whether v (a string, a space separated list of strings or a slice) contains s
+func contains(v interface{}, s string) bool {
+
+	switch c := v.(type) {
+	case string:
+		for _, e := range strings.Fields(c) {
+			if e == s {
+				return true
+			}
+		}
+	case []string:
+		for _, e := range c {
+			if e == s {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, e := range c {
+			if fmt.Sprint(e) == s {
+				return true
+			}
+		}
+	case nil:
+		return false
+	default:
+		return fmt.Sprint(c) == s
+	}
+
+	return false
+}
+
+func present(v interface{}) bool {
+
+	switch c := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return c
+	case string:
+		return c != ""
+	case []string:
+		return len(c) > 0
+	case []interface{}:
+		return len(c) > 0
+	}
+
+	return true
+}
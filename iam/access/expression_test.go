@@ -0,0 +1,56 @@
+package access
+
+import (
+	"github.com/graniticio/granitic/v2/iam"
+	"testing"
+)
+
+func TestExpressionEvaluation(t *testing.T) {
+
+	keys := &IdentityKeys{Roles: "Roles", Scopes: "Scopes"}
+
+	ci := iam.NewAuthenticatedIdentity("user")
+	ci["Roles"] = []interface{}{"editor", "reader"}
+	ci["Scopes"] = "articles.read articles.write"
+	ci["Tenant"] = "acme"
+	ci["Verified"] = true
+
+	rules := map[string]bool{
+		"role:editor":                    true,
+		"role:admin":                     false,
+		"scope:articles.write":           true,
+		"role:admin OR role:editor":      true,
+		"role:admin and role:editor":     false,
+		"role:editor AND NOT role:admin": true,
+		"role:admin OR (role:editor AND scope:articles.write)":  true,
+		"(role:admin OR role:reader) AND scope:articles.delete": false,
+		"Tenant=acme":                           true,
+		"Tenant=other":                          false,
+		"Verified AND Tenant=acme":              true,
+		"Missing":                               false,
+		"NOT Missing OR role:admin":             true,
+		"role:reader OR role:admin AND scope:x": true,
+	}
+
+	for rule, expected := range rules {
+
+		e, err := ParseExpression(rule)
+
+		if err != nil {
+			t.Fatalf("Unexpected error parsing %q: %s", rule, err.Error())
+		}
+
+		if e.Matches(ci, keys) != expected {
+			t.Errorf("Expected %q to evaluate to %v", rule, expected)
+		}
+	}
+}
+
+func TestInvalidExpressions(t *testing.T) {
+
+	for _, rule := range []string{"", "   ", "role:", "scope:", "Key=", "(role:a", "role:a)", "role:a AND", "OR role:a", "role:a role:b", "NOT"} {
+		if _, err := ParseExpression(rule); err == nil {
+			t.Errorf("Expected %q to be rejected", rule)
+		}
+	}
+}
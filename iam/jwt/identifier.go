@@ -0,0 +1,142 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package jwt
+
+import (
+	"context"
+	"errors"
+	"github.com/graniticio/granitic/v2/iam"
+	"github.com/graniticio/granitic/v2/logging"
+	"net/http"
+	"strings"
+)
+
+// Identifier is an implementation of ws.Identifier that extracts a bearer token from a request header and, if the token
+// is valid, creates an authenticated iam.ClientIdentity from its claims.
+type Identifier struct {
+	// Injected by Granitic
+	FrameworkLogger logging.Logger
+
+	// The name of the HTTP header containing the token (normally Authorization)
+	Header string
+
+	// The authentication scheme that must prefix the token in the header (normally Bearer). If empty, the whole header
+	// value is treated as the token.
+	Scheme string
+
+	// The claim to use as the identity's loggable user ID (normally sub)
+	LoggableUserIDClaim string
+
+	// A map of ClientIdentity keys to the names of claims whose values should be copied into the identity.
+	ClaimMapping map[string]string
+
+	// Checks the non-signature claims in the token.
+	Validator *Validator
+
+	// The keys that tokens may be signed with.
+	Keys *KeySet
+}
+
+// Identify implements ws.Identifier. If a valid token is found, an authenticated identity is returned along with a
+// context containing the token's claims. Otherwise an anonymous identity and the unmodified context are returned.
+func (id *Identifier) Identify(ctx context.Context, req *http.Request) (iam.ClientIdentity, context.Context) {
+
+	raw := id.extractToken(req)
+
+	if raw == "" {
+		return iam.NewAnonymousIdentity(), ctx
+	}
+
+	c, err := id.Verify(raw)
+
+	if err != nil {
+		id.FrameworkLogger.LogDebugfCtx(ctx, "Rejected bearer token: %s", err.Error())
+		return iam.NewAnonymousIdentity(), ctx
+	}
+
+	return id.identityFromClaims(c), contextWithClaims(ctx, c)
+}
+
+// Verify parses the supplied token, checks its signature against the configured keys and validates its claims.
+func (id *Identifier) Verify(raw string) (Claims, error) {
+
+	t, err := Parse(raw)
+
+	if err != nil {
+		return nil, err
+	}
+
+	keys := id.Keys.Candidates(t.Header)
+
+	if len(keys) == 0 {
+		return nil, errors.New("no key available to verify the token's signature")
+	}
+
+	for _, k := range keys {
+
+		if err = t.VerifySignature(k); err == nil {
+			break
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = id.Validator.Validate(t.Claims); err != nil {
+		return nil, err
+	}
+
+	return t.Claims, nil
+}
+
+func (id *Identifier) extractToken(req *http.Request) string {
+
+	v := strings.TrimSpace(req.Header.Get(id.Header))
+
+	if v == "" || id.Scheme == "" {
+		return v
+	}
+
+	p := strings.SplitN(v, " ", 2)
+
+	if len(p) != 2 || !strings.EqualFold(p[0], id.Scheme) {
+		return ""
+	}
+
+	return strings.TrimSpace(p[1])
+}
+
+func (id *Identifier) identityFromClaims(c Claims) iam.ClientIdentity {
+
+	uid, _ := c.String(id.LoggableUserIDClaim)
+
+	ci := iam.NewAuthenticatedIdentity(uid)
+
+	for k, claim := range id.ClaimMapping {
+		if v, found := c[claim]; found {
+			ci[k] = v
+		}
+	}
+
+	return ci
+}
+
+// StartComponent checks that the Identifier has enough configuration to verify tokens.
+func (id *Identifier) StartComponent() error {
+
+	if id.Keys == nil || id.Keys.Empty() {
+		return errors.New("no keys have been configured for verifying JWTs. Set JWTIdentifier.Keys or JWTIdentifier.JWKSFile in configuration")
+	}
+
+	if id.Header == "" {
+		return errors.New("no header has been configured to read JWTs from")
+	}
+
+	if id.Validator == nil {
+		id.Validator = new(Validator)
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package jwt
+
+import (
+	"context"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestIdentifyValidToken(t *testing.T) {
+
+	id := newTestIdentifier(t)
+
+	exp := time.Now().Add(time.Hour).Unix()
+
+	raw := signHS256(t, []byte("secret"), Header{Algorithm: HS256, KeyID: "k1"}, Claims{"sub": "user1", "exp": exp, "roles": []string{"admin"}})
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer "+raw)
+
+	ci, ctx := id.Identify(context.Background(), req)
+
+	test.ExpectBool(t, ci.Authenticated(), true)
+	test.ExpectString(t, ci.LoggableUserID(), "user1")
+
+	if ci["Roles"] == nil {
+		t.Fatalf("Expected roles claim to be mapped into identity")
+	}
+
+	c := ClaimsFromContext(ctx)
+
+	if c == nil {
+		t.Fatalf("Expected claims to be stored in context")
+	}
+
+	s, _ := c.String("sub")
+	test.ExpectString(t, s, "user1")
+}
+
+func TestIdentifyRejectsInvalidTokens(t *testing.T) {
+
+	id := newTestIdentifier(t)
+
+	exp := time.Now().Add(time.Hour).Unix()
+	expired := time.Now().Add(-time.Hour).Unix()
+
+	headers := []string{
+		"",
+		"Basic dXNlcjpwYXNz",
+		"Bearer not-a-token",
+		"Bearer " + signHS256(t, []byte("wrong"), Header{Algorithm: HS256, KeyID: "k1"}, Claims{"sub": "u", "exp": exp}),
+		"Bearer " + signHS256(t, []byte("secret"), Header{Algorithm: HS256, KeyID: "k1"}, Claims{"sub": "u", "exp": expired}),
+		"Bearer " + signHS256(t, []byte("secret"), Header{Algorithm: HS256, KeyID: "unknown"}, Claims{"sub": "u", "exp": exp}),
+	}
+
+	for _, h := range headers {
+		req, _ := http.NewRequest("GET", "/", nil)
+
+		if h != "" {
+			req.Header.Set("Authorization", h)
+		}
+
+		ci, ctx := id.Identify(context.Background(), req)
+
+		if ci.Authenticated() {
+			t.Errorf("Expected header %q to result in an unauthenticated identity", h)
+		}
+
+		if ClaimsFromContext(ctx) != nil {
+			t.Errorf("Expected no claims in context for header %q", h)
+		}
+	}
+}
+
+func TestIdentifierStart(t *testing.T) {
+
+	id := new(Identifier)
+	id.Header = "Authorization"
+
+	if id.StartComponent() == nil {
+		t.Fatalf("Expected an error when no keys are set")
+	}
+
+	id.Keys = NewKeySet()
+	id.Keys.Add(NewHMACKey("", []byte("secret")))
+
+	test.ExpectNil(t, id.StartComponent())
+}
+
+func newTestIdentifier(t *testing.T) *Identifier {
+	id := new(Identifier)
+	id.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	id.Header = "Authorization"
+	id.Scheme = "Bearer"
+	id.LoggableUserIDClaim = "sub"
+	id.ClaimMapping = map[string]string{"Roles": "roles"}
+	id.Keys = NewKeySet()
+	id.Keys.Add(NewHMACKey("k1", []byte("secret")))
+
+	v := new(Validator)
+	v.RequireExpiry = true
+	id.Validator = v
+
+	test.ExpectNil(t, id.StartComponent())
+
+	return id
+}
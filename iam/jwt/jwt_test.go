@@ -0,0 +1,271 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestHMACVerification(t *testing.T) {
+
+	k := NewHMACKey("", []byte("secret"))
+
+	tok, err := Parse(signHS256(t, []byte("secret"), Header{Algorithm: HS256}, Claims{"sub": "user"}))
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if err = tok.VerifySignature(k); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	tok, _ = Parse(signHS256(t, []byte("other"), Header{Algorithm: HS256}, Claims{"sub": "user"}))
+
+	if err = tok.VerifySignature(k); err == nil {
+		t.Fatalf("Expected signature with wrong secret to be rejected")
+	}
+}
+
+func TestRSAVerification(t *testing.T) {
+
+	pk, err := rsa.GenerateKey(rand.Reader, 2048)
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	raw := signRS256(t, pk, Header{Algorithm: RS256}, Claims{"sub": "user"})
+
+	tok, err := Parse(raw)
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if err = tok.VerifySignature(NewRSAKey("", &pk.PublicKey)); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// An HMAC key must not accept a token claiming to be RS256
+	if err = tok.VerifySignature(NewHMACKey("", []byte("secret"))); err == nil {
+		t.Fatalf("Expected algorithm mismatch to be rejected")
+	}
+}
+
+func TestECDSAVerification(t *testing.T) {
+
+	pk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	tok, err := Parse(signES256(t, pk, Header{Algorithm: ES256}, Claims{"sub": "user"}))
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	k, err := NewECDSAKey("", &pk.PublicKey)
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if err = tok.VerifySignature(k); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	tok.signature[0] ^= 0xff
+
+	if err = tok.VerifySignature(k); err == nil {
+		t.Fatalf("Expected tampered signature to be rejected")
+	}
+}
+
+func TestParseMalformed(t *testing.T) {
+
+	for _, raw := range []string{"", "a.b", "a.b.c.d", "!!!.e30.", "e30.!!!."} {
+		if _, err := Parse(raw); err == nil {
+			t.Errorf("Expected %q to be rejected", raw)
+		}
+	}
+}
+
+func TestValidatorTimes(t *testing.T) {
+
+	now := time.Unix(1000, 0)
+
+	v := new(Validator)
+	v.RequireExpiry = true
+	v.now = func() time.Time { return now }
+
+	if err := v.Validate(Claims{"exp": json.Number("1001")}); err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+
+	if err := v.Validate(Claims{"exp": json.Number("1000")}); err == nil {
+		t.Errorf("Expected expired token to be rejected")
+	}
+
+	if err := v.Validate(Claims{}); err == nil {
+		t.Errorf("Expected token without exp to be rejected")
+	}
+
+	if err := v.Validate(Claims{"exp": json.Number("2000"), "nbf": json.Number("1010")}); err == nil {
+		t.Errorf("Expected token before nbf to be rejected")
+	}
+
+	v.ClockSkewSeconds = 20
+
+	if err := v.Validate(Claims{"exp": json.Number("990"), "nbf": json.Number("1010")}); err != nil {
+		t.Errorf("Expected skew to allow token. Got %s", err.Error())
+	}
+
+	if err := v.Validate(Claims{"exp": "soon"}); err == nil {
+		t.Errorf("Expected non-numeric exp to be rejected")
+	}
+}
+
+func TestValidatorIssuerAudience(t *testing.T) {
+
+	v := new(Validator)
+	v.Issuer = "https://issuer"
+	v.Audience = []string{"api", "admin"}
+
+	if err := v.Validate(Claims{"iss": "https://issuer", "aud": "api"}); err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+
+	if err := v.Validate(Claims{"iss": "https://issuer", "aud": []interface{}{"other", "admin"}}); err != nil {
+		t.Errorf("Unexpected error %s", err.Error())
+	}
+
+	if err := v.Validate(Claims{"iss": "https://other", "aud": "api"}); err == nil {
+		t.Errorf("Expected wrong issuer to be rejected")
+	}
+
+	if err := v.Validate(Claims{"iss": "https://issuer", "aud": "other"}); err == nil {
+		t.Errorf("Expected wrong audience to be rejected")
+	}
+
+	if err := v.Validate(Claims{"iss": "https://issuer"}); err == nil {
+		t.Errorf("Expected missing audience to be rejected")
+	}
+}
+
+func TestJWKS(t *testing.T) {
+
+	rk, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ek, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	enc := base64.RawURLEncoding
+
+	set := map[string]interface{}{
+		"keys": []map[string]string{
+			{"kty": "RSA", "kid": "r1", "n": enc.EncodeToString(rk.N.Bytes()), "e": enc.EncodeToString(big.NewInt(int64(rk.E)).Bytes())},
+			{"kty": "EC", "kid": "e1", "crv": "P-256", "x": enc.EncodeToString(ek.X.Bytes()), "y": enc.EncodeToString(ek.Y.Bytes())},
+			{"kty": "oct", "kid": "h1", "k": enc.EncodeToString([]byte("secret"))},
+			{"kty": "RSA", "kid": "enc", "use": "enc", "n": "", "e": ""},
+		},
+	}
+
+	b, _ := json.Marshal(set)
+
+	ks := NewKeySet()
+
+	if err := ks.AddJWKS(b); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	tok, _ := Parse(signES256(t, ek, Header{Algorithm: ES256, KeyID: "e1"}, Claims{}))
+
+	c := ks.Candidates(tok.Header)
+
+	if len(c) != 1 || tok.VerifySignature(c[0]) != nil {
+		t.Fatalf("Expected EC key from JWKS to verify token")
+	}
+
+	tok, _ = Parse(signRS256(t, rk, Header{Algorithm: RS256, KeyID: "r1"}, Claims{}))
+
+	if tok.VerifySignature(ks.Candidates(tok.Header)[0]) != nil {
+		t.Fatalf("Expected RSA key from JWKS to verify token")
+	}
+
+	if len(ks.Candidates(Header{Algorithm: RS256, KeyID: "enc"})) != 0 {
+		t.Fatalf("Expected encryption key to be ignored")
+	}
+
+	if err := NewKeySet().AddJWKS([]byte(`{"keys":[{"kty":"EC","crv":"P-384","x":"AA","y":"AA"}]}`)); err == nil {
+		t.Fatalf("Expected unsupported curve to be rejected")
+	}
+}
+
+func encodeUnsigned(t *testing.T, h Header, c Claims) string {
+
+	hb, err := json.Marshal(h)
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	cb, err := json.Marshal(c)
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	return base64.RawURLEncoding.EncodeToString(hb) + "." + base64.RawURLEncoding.EncodeToString(cb)
+}
+
+func signHS256(t *testing.T, secret []byte, h Header, c Claims) string {
+
+	u := encodeUnsigned(t, h, c)
+
+	m := hmac.New(sha256.New, secret)
+	m.Write([]byte(u))
+
+	return u + "." + base64.RawURLEncoding.EncodeToString(m.Sum(nil))
+}
+
+func signRS256(t *testing.T, pk *rsa.PrivateKey, h Header, c Claims) string {
+
+	u := encodeUnsigned(t, h, c)
+	d := sha256.Sum256([]byte(u))
+
+	s, err := rsa.SignPKCS1v15(rand.Reader, pk, crypto.SHA256, d[:])
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	return u + "." + base64.RawURLEncoding.EncodeToString(s)
+}
+
+func signES256(t *testing.T, pk *ecdsa.PrivateKey, h Header, c Claims) string {
+
+	u := encodeUnsigned(t, h, c)
+	d := sha256.Sum256([]byte(u))
+
+	r, s, err := ecdsa.Sign(rand.Reader, pk, d[:])
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+
+	return u + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
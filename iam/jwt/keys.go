@@ -0,0 +1,367 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+)
+
+// Key is a secret or public key that can be used to verify the signature of a token.
+type Key struct {
+	// The key ID that tokens will reference in their kid header. May be empty.
+	ID string
+
+	// The single algorithm this key can be used to verify.
+	Algorithm string
+
+	secret []byte
+	rsaKey *rsa.PublicKey
+	ecKey  *ecdsa.PublicKey
+}
+
+// NewHMACKey creates an HS256 key using the supplied shared secret.
+func NewHMACKey(id string, secret []byte) *Key {
+	return &Key{ID: id, Algorithm: HS256, secret: secret}
+}
+
+// NewRSAKey creates an RS256 key from an RSA public key.
+func NewRSAKey(id string, pk *rsa.PublicKey) *Key {
+	return &Key{ID: id, Algorithm: RS256, rsaKey: pk}
+}
+
+// NewECDSAKey creates an ES256 key from an ECDSA public key. The key must use the P-256 curve.
+func NewECDSAKey(id string, pk *ecdsa.PublicKey) (*Key, error) {
+
+	if pk.Curve != elliptic.P256() {
+		return nil, fmt.Errorf("key %s does not use the P-256 curve required for %s", id, ES256)
+	}
+
+	return &Key{ID: id, Algorithm: ES256, ecKey: pk}, nil
+}
+
+// KeyDefinition is the configuration-friendly declaration of a Key.
+type KeyDefinition struct {
+	// The ID tokens will use to reference this key in their kid header. Optional.
+	ID string
+
+	// One of HS256, RS256 or ES256.
+	Algorithm string
+
+	// The shared secret for HS256 keys.
+	Secret string
+
+	// The path to a PEM file containing a public key or certificate for RS256 and ES256 keys.
+	PublicKeyFile string
+}
+
+// KeySet is the collection of keys an Identifier will accept signatures from.
+type KeySet struct {
+	byID      map[string]*Key
+	anonymous []*Key
+}
+
+// NewKeySet creates an empty KeySet
+func NewKeySet() *KeySet {
+	ks := new(KeySet)
+	ks.byID = make(map[string]*Key)
+
+	return ks
+}
+
+// Add stores a key in the set. Returns an error if a key with the same non-empty ID already exists.
+func (ks *KeySet) Add(k *Key) error {
+
+	if k.ID == "" {
+		ks.anonymous = append(ks.anonymous, k)
+		return nil
+	}
+
+	if ks.byID[k.ID] != nil {
+		return fmt.Errorf("more than one key has the ID %s", k.ID)
+	}
+
+	ks.byID[k.ID] = k
+
+	return nil
+}
+
+// Candidates returns the keys that might have been used to sign a token with the supplied header. If the header
+// references a key ID, only that key is returned, otherwise all keys without an ID that support the header's algorithm
+// are returned.
+func (ks *KeySet) Candidates(h Header) []*Key {
+
+	if h.KeyID != "" {
+		if k := ks.byID[h.KeyID]; k != nil {
+			return []*Key{k}
+		}
+
+		return nil
+	}
+
+	var c []*Key
+
+	for _, k := range ks.anonymous {
+		if k.Algorithm == h.Algorithm {
+			c = append(c, k)
+		}
+	}
+
+	return c
+}
+
+// Empty returns true if the set contains no keys.
+func (ks *KeySet) Empty() bool {
+	return len(ks.byID) == 0 && len(ks.anonymous) == 0
+}
+
+// AddDefinitions converts each definition to a Key (loading public keys from files as required) and adds it to the set.
+func (ks *KeySet) AddDefinitions(defs []KeyDefinition) error {
+
+	for _, d := range defs {
+
+		k, err := keyFromDefinition(d)
+
+		if err != nil {
+			return err
+		}
+
+		if err = ks.Add(k); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func keyFromDefinition(d KeyDefinition) (*Key, error) {
+
+	switch d.Algorithm {
+	case HS256:
+		if d.Secret == "" {
+			return nil, fmt.Errorf("key %s uses %s but no Secret has been set", d.ID, HS256)
+		}
+
+		return NewHMACKey(d.ID, []byte(d.Secret)), nil
+
+	case RS256, ES256:
+		if d.PublicKeyFile == "" {
+			return nil, fmt.Errorf("key %s uses %s but no PublicKeyFile has been set", d.ID, d.Algorithm)
+		}
+
+		pk, err := loadPublicKey(d.PublicKeyFile)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return keyForPublicKey(d.ID, d.Algorithm, pk)
+
+	default:
+		return nil, fmt.Errorf("key %s has an unsupported algorithm '%s'. Must be one of %s, %s or %s", d.ID, d.Algorithm, HS256, RS256, ES256)
+	}
+}
+
+func keyForPublicKey(id, alg string, pk interface{}) (*Key, error) {
+
+	switch k := pk.(type) {
+	case *rsa.PublicKey:
+		if alg == RS256 {
+			return NewRSAKey(id, k), nil
+		}
+	case *ecdsa.PublicKey:
+		if alg == ES256 {
+			return NewECDSAKey(id, k)
+		}
+	}
+
+	return nil, fmt.Errorf("key %s is a %T which cannot be used with %s", id, pk, alg)
+}
+
+func loadPublicKey(path string) (interface{}, error) {
+
+	b, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to read public key file %s: %s", path, err.Error())
+	}
+
+	block, _ := pem.Decode(b)
+
+	if block == nil {
+		return nil, fmt.Errorf("%s does not contain a PEM encoded key", path)
+	}
+
+	switch block.Type {
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		c, err := x509.ParseCertificate(block.Bytes)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return c.PublicKey, nil
+	}
+
+	return nil, fmt.Errorf("%s contains an unsupported PEM block type %s", path, block.Type)
+}
+
+type jsonWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	Curve     string `json:"crv"`
+	N         string `json:"n"`
+	E         string `json:"e"`
+	X         string `json:"x"`
+	Y         string `json:"y"`
+	K         string `json:"k"`
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+// LoadJWKSFile reads a JSON Web Key Set from a local file and adds each signature key it contains to the set. Keys
+// marked for encryption (use=enc) are ignored.
+func (ks *KeySet) LoadJWKSFile(path string) error {
+
+	b, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return fmt.Errorf("unable to read JWKS file %s: %s", path, err.Error())
+	}
+
+	return ks.AddJWKS(b)
+}
+
+// AddJWKS parses a JSON Web Key Set document and adds each signature key it contains to the set.
+func (ks *KeySet) AddJWKS(b []byte) error {
+
+	var set jsonWebKeySet
+
+	if err := json.Unmarshal(b, &set); err != nil {
+		return fmt.Errorf("unable to parse JWKS: %s", err.Error())
+	}
+
+	for _, jwk := range set.Keys {
+
+		if jwk.Use == "enc" {
+			continue
+		}
+
+		k, err := jwk.toKey()
+
+		if err != nil {
+			return err
+		}
+
+		if err = ks.Add(k); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (jwk jsonWebKey) toKey() (*Key, error) {
+
+	switch jwk.KeyType {
+	case "oct":
+		s, err := base64.RawURLEncoding.DecodeString(jwk.K)
+
+		if err != nil || len(s) == 0 {
+			return nil, fmt.Errorf("JWK %s has an invalid k value", jwk.KeyID)
+		}
+
+		if err = jwk.checkAlgorithm(HS256); err != nil {
+			return nil, err
+		}
+
+		return NewHMACKey(jwk.KeyID, s), nil
+
+	case "RSA":
+		if err := jwk.checkAlgorithm(RS256); err != nil {
+			return nil, err
+		}
+
+		n, err := decodeBigInt(jwk.N)
+
+		if err != nil {
+			return nil, fmt.Errorf("JWK %s has an invalid n value", jwk.KeyID)
+		}
+
+		e, err := decodeBigInt(jwk.E)
+
+		if err != nil || !e.IsInt64() {
+			return nil, fmt.Errorf("JWK %s has an invalid e value", jwk.KeyID)
+		}
+
+		return NewRSAKey(jwk.KeyID, &rsa.PublicKey{N: n, E: int(e.Int64())}), nil
+
+	case "EC":
+		if err := jwk.checkAlgorithm(ES256); err != nil {
+			return nil, err
+		}
+
+		if jwk.Curve != "P-256" {
+			return nil, fmt.Errorf("JWK %s uses unsupported curve %s", jwk.KeyID, jwk.Curve)
+		}
+
+		x, errX := decodeBigInt(jwk.X)
+		y, errY := decodeBigInt(jwk.Y)
+
+		if errX != nil || errY != nil {
+			return nil, fmt.Errorf("JWK %s has invalid x or y values", jwk.KeyID)
+		}
+
+		pk := &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+
+		if !pk.Curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("JWK %s is not a valid point on the P-256 curve", jwk.KeyID)
+		}
+
+		return NewECDSAKey(jwk.KeyID, pk)
+	}
+
+	return nil, fmt.Errorf("JWK %s has unsupported key type '%s'", jwk.KeyID, jwk.KeyType)
+}
+
+func (jwk jsonWebKey) checkAlgorithm(expected string) error {
+
+	if jwk.Algorithm != "" && jwk.Algorithm != expected {
+		return fmt.Errorf("JWK %s declares algorithm %s which is not supported for key type %s", jwk.KeyID, jwk.Algorithm, jwk.KeyType)
+	}
+
+	return nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+
+	b, err := base64.RawURLEncoding.DecodeString(s)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(b) == 0 {
+		return nil, errors.New("empty value")
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,60 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package types
+
+import (
+	"mime"
+	"mime/multipart"
+	"strings"
+)
+
+// NewUploadedFile creates an UploadedFile from a file submitted in the named field of a multipart form.
+func NewUploadedFile(field string, fh *multipart.FileHeader) *UploadedFile {
+	uf := new(UploadedFile)
+	uf.FieldName = field
+	uf.FileName = fh.Filename
+	uf.ContentType = fh.Header.Get("Content-Type")
+	uf.Size = fh.Size
+	uf.header = fh
+
+	return uf
+}
+
+// UploadedFile is a file that was submitted as part of a multipart HTTP request. The contents of the file are not held
+// on this type, but can be read as a stream using Open.
+type UploadedFile struct {
+	// The name of the form field in which the file was submitted.
+	FieldName string
+
+	// The name of the file, as supplied by the client.
+	FileName string
+
+	// The content type of the file, as declared by the client.
+	ContentType string
+
+	// The size of the file in bytes.
+	Size int64
+
+	header *multipart.FileHeader
+}
+
+// Open returns a stream from which the contents of the file can be read. Callers must close the stream when they have finished
+// with it. Depending on the size of the file, the contents may be held in memory or in a temporary file that is removed
+// once the request has been processed.
+func (uf *UploadedFile) Open() (multipart.File, error) {
+	return uf.header.Open()
+}
+
+// MediaType returns the lower-case media type of the file's declared content type, without any parameters (e.g. image/png),
+// or an empty string if the client did not declare a valid content type.
+func (uf *UploadedFile) MediaType() string {
+
+	mt, _, err := mime.ParseMediaType(uf.ContentType)
+
+	if err != nil {
+		return ""
+	}
+
+	return strings.ToLower(mt)
+}
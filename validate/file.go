@@ -0,0 +1,449 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"github.com/graniticio/granitic/v2/ioc"
+	rt "github.com/graniticio/granitic/v2/reflecttools"
+	"github.com/graniticio/granitic/v2/types"
+	"regexp"
+	"strings"
+)
+
+const fileRuleCode = "FILE"
+
+const (
+	fileOpRequiredCode = commonOpRequired
+	fileOpStopAllCode  = commonOpStopAll
+	fileOpMexCode      = commonOpMex
+	fileOpSizeCode     = "SIZE"
+	fileOpTypeCode     = "TYPE"
+)
+
+type fileValidationOperation uint
+
+const (
+	fileOpUnsupported = iota
+	fileOpRequired
+	fileOpStopAll
+	fileOpMex
+	fileOpSize
+	fileOpType
+)
+
+type fileOperation struct {
+	OpType    fileValidationOperation
+	ErrCode   string
+	MExFields types.StringSet
+}
+
+// NewFileValidationRule creates a new FileValidationRule to check the specified field.
+func NewFileValidationRule(field, defaultErrorCode string) *FileValidationRule {
+	fv := new(FileValidationRule)
+	fv.defaultErrorCode = defaultErrorCode
+	fv.field = field
+	fv.codesInUse = types.NewOrderedStringSet([]string{})
+	fv.dependsFields = determinePathFields(field)
+	fv.operations = make([]*fileOperation, 0)
+	fv.codesInUse.Add(fv.defaultErrorCode)
+	fv.minSize = noBound
+	fv.maxSize = noBound
+
+	return fv
+}
+
+// FileValidationRule is a ValidationRule for checking a *types.UploadedFile field on an object. See the method definitions on this type for
+// the supported operations.
+type FileValidationRule struct {
+	stopAll             bool
+	codesInUse          types.StringSet
+	dependsFields       types.StringSet
+	defaultErrorCode    string
+	field               string
+	missingRequiredCode string
+	required            bool
+	operations          []*fileOperation
+	minSize             int
+	maxSize             int
+	contentTypes        []string
+}
+
+// IsSet returns true if the field to be validated is a non-nil *types.UploadedFile.
+func (fv *FileValidationRule) IsSet(field string, subject interface{}) (bool, error) {
+
+	value, err := fv.extractValue(field, subject)
+
+	if err != nil {
+		return false, err
+	}
+
+	return value != nil, nil
+}
+
+// Validate implements ValidationRule.Validate
+func (fv *FileValidationRule) Validate(vc *ValidationContext) (result *ValidationResult, unexpected error) {
+
+	f := fv.field
+
+	if vc.OverrideField != "" {
+		f = vc.OverrideField
+	}
+
+	var value *types.UploadedFile
+
+	sub := vc.Subject
+	r := NewValidationResult()
+
+	if vc.DirectSubject {
+
+		uf, err := fv.toFile(f, sub)
+
+		if err != nil {
+			return nil, err
+		}
+
+		value = uf
+
+	} else {
+
+		set, err := fv.IsSet(f, sub)
+
+		if err != nil {
+			return nil, err
+
+		} else if !set {
+			r.Unset = true
+
+			if fv.required {
+				r.AddForField(f, []string{fv.missingRequiredCode})
+			}
+
+			return r, nil
+		}
+
+		//Ignoring error as called previously during IsSet
+		value, _ = fv.extractValue(f, sub)
+	}
+
+	err := fv.runOperations(f, value, vc, r)
+
+	return r, err
+}
+
+func (fv *FileValidationRule) runOperations(field string, uf *types.UploadedFile, vc *ValidationContext, r *ValidationResult) error {
+
+	ec := types.NewEmptyOrderedStringSet()
+
+	for _, op := range fv.operations {
+
+		switch op.OpType {
+		case fileOpMex:
+			checkMExFields(op.MExFields, vc, ec, op.ErrCode)
+		case fileOpSize:
+			if !fv.sizeOkay(uf) {
+				ec.Add(op.ErrCode)
+			}
+		case fileOpType:
+			if !fv.typeOkay(uf) {
+				ec.Add(op.ErrCode)
+			}
+		}
+	}
+
+	r.AddForField(field, ec.Contents())
+
+	return nil
+
+}
+
+func (fv *FileValidationRule) sizeOkay(uf *types.UploadedFile) bool {
+
+	s := uf.Size
+
+	minOkay := fv.minSize == noBound || s >= int64(fv.minSize)
+	maxOkay := fv.maxSize == noBound || s <= int64(fv.maxSize)
+
+	return minOkay && maxOkay
+}
+
+func (fv *FileValidationRule) typeOkay(uf *types.UploadedFile) bool {
+
+	mt := uf.MediaType()
+
+	if mt == "" {
+		return false
+	}
+
+	for _, ct := range fv.contentTypes {
+
+		if ct == mt || (strings.HasSuffix(ct, "/*") && strings.HasPrefix(mt, ct[:len(ct)-1])) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (fv *FileValidationRule) extractValue(f string, s interface{}) (*types.UploadedFile, error) {
+
+	v, err := rt.FindNestedField(rt.ExtractDotPath(f), s)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if rt.NilPointer(v) {
+		return nil, nil
+	}
+
+	return fv.toFile(f, v.Interface())
+}
+
+func (fv *FileValidationRule) toFile(f string, i interface{}) (*types.UploadedFile, error) {
+
+	uf, found := i.(*types.UploadedFile)
+
+	if !found {
+		m := fmt.Sprintf("%s is not a *types.UploadedFile", f)
+		return nil, errors.New(m)
+	}
+
+	return uf, nil
+}
+
+// StopAllOnFail implements ValidationRule.StopAllOnFail
+func (fv *FileValidationRule) StopAllOnFail() bool {
+	return fv.stopAll
+}
+
+// CodesInUse implements ValidationRule.CodesInUse
+func (fv *FileValidationRule) CodesInUse() types.StringSet {
+	return fv.codesInUse
+}
+
+// DependsOnFields implements ValidationRule.DependsOnFields
+func (fv *FileValidationRule) DependsOnFields() types.StringSet {
+
+	return fv.dependsFields
+}
+
+// StopAll indicates that no further rules should be rule if this one fails.
+func (fv *FileValidationRule) StopAll() *FileValidationRule {
+
+	fv.stopAll = true
+
+	return fv
+}
+
+// Required adds a check to see if a file was uploaded in the field under validation.
+func (fv *FileValidationRule) Required(code ...string) *FileValidationRule {
+
+	fv.required = true
+	fv.missingRequiredCode = fv.chooseErrorCode(code)
+
+	return fv
+}
+
+// MEx adds a check to see if any other of the fields with which this field is mutually exclusive have been set.
+func (fv *FileValidationRule) MEx(fields types.StringSet, code ...string) *FileValidationRule {
+	op := new(fileOperation)
+	op.ErrCode = fv.chooseErrorCode(code)
+	op.OpType = fileOpMex
+	op.MExFields = fields
+
+	fv.addOperation(op)
+
+	return fv
+}
+
+// Size adds a check to see if the file's size in bytes is between the supplied min and max values. Either value may be
+// set to -1 to indicate that there is no bound.
+func (fv *FileValidationRule) Size(min, max int, code ...string) *FileValidationRule {
+
+	fv.minSize = min
+	fv.maxSize = max
+
+	o := new(fileOperation)
+	o.OpType = fileOpSize
+	o.ErrCode = fv.chooseErrorCode(code)
+
+	fv.addOperation(o)
+
+	return fv
+}
+
+// ContentType adds a check to see if the content type declared for the file matches one of the supplied media types. A
+// media type ending in /* (e.g. image/*) matches any subtype. Note that the content type of an uploaded file is supplied by
+// the client and is not checked against the file's contents.
+func (fv *FileValidationRule) ContentType(mediaTypes []string, code ...string) *FileValidationRule {
+
+	for _, mt := range mediaTypes {
+		fv.contentTypes = append(fv.contentTypes, strings.ToLower(strings.TrimSpace(mt)))
+	}
+
+	o := new(fileOperation)
+	o.OpType = fileOpType
+	o.ErrCode = fv.chooseErrorCode(code)
+
+	fv.addOperation(o)
+
+	return fv
+}
+
+func (fv *FileValidationRule) addOperation(o *fileOperation) {
+	fv.operations = append(fv.operations, o)
+}
+
+func (fv *FileValidationRule) chooseErrorCode(v []string) string {
+
+	if len(v) > 0 {
+		fv.codesInUse.Add(v[0])
+		return v[0]
+	}
+
+	return fv.defaultErrorCode
+}
+
+func (fv *FileValidationRule) operation(c string) (fileValidationOperation, error) {
+	switch c {
+	case fileOpRequiredCode:
+		return fileOpRequired, nil
+	case fileOpStopAllCode:
+		return fileOpStopAll, nil
+	case fileOpMexCode:
+		return fileOpMex, nil
+	case fileOpSizeCode:
+		return fileOpSize, nil
+	case fileOpTypeCode:
+		return fileOpType, nil
+	}
+
+	m := fmt.Sprintf("Unsupported file validation operation %s", c)
+	return fileOpUnsupported, errors.New(m)
+
+}
+
+func newFileValidationRuleBuilder(ec string, cf ioc.ComponentLookup) *fileValidationRuleBuilder {
+	fb := new(fileValidationRuleBuilder)
+	fb.componentFinder = cf
+	fb.defaultErrorCode = ec
+	fb.sizeRegex = regexp.MustCompile(lengthPattern)
+
+	return fb
+}
+
+type fileValidationRuleBuilder struct {
+	defaultErrorCode string
+	componentFinder  ioc.ComponentLookup
+	sizeRegex        *regexp.Regexp
+}
+
+func (vb *fileValidationRuleBuilder) parseRule(field string, rule []string) (ValidationRule, error) {
+
+	defaultErrorcode := determineDefaultErrorCode(fileRuleCode, rule, vb.defaultErrorCode)
+	fv := NewFileValidationRule(field, defaultErrorcode)
+
+	for _, v := range rule {
+
+		ops := decomposeOperation(v)
+		opCode := ops[0]
+
+		if isTypeIndicator(fileRuleCode, opCode) {
+			continue
+		}
+
+		op, err := fv.operation(opCode)
+
+		if err != nil {
+			return nil, err
+		}
+
+		switch op {
+		case fileOpRequired:
+			err = vb.markRequired(field, ops, fv)
+		case fileOpStopAll:
+			fv.StopAll()
+		case fileOpMex:
+			err = vb.captureExclusiveFields(field, ops, fv)
+		case fileOpSize:
+			err = vb.addSizeOperation(field, ops, fv)
+		case fileOpType:
+			err = vb.addTypeOperation(field, ops, fv)
+		}
+
+		if err != nil {
+
+			return nil, err
+		}
+
+	}
+
+	return fv, nil
+
+}
+
+func (vb *fileValidationRuleBuilder) addSizeOperation(field string, ops []string, fv *FileValidationRule) error {
+
+	_, err := paramCount(ops, "Size", field, 2, 3)
+
+	if err != nil {
+		return err
+	}
+
+	min, max, err := extractLengthParams(field, ops[1], vb.sizeRegex)
+
+	if err != nil {
+		return err
+	}
+
+	fv.Size(min, max, extractVargs(ops, 3)...)
+
+	return nil
+}
+
+func (vb *fileValidationRuleBuilder) addTypeOperation(field string, ops []string, fv *FileValidationRule) error {
+
+	_, err := paramCount(ops, "Type", field, 2, 3)
+
+	if err != nil {
+		return err
+	}
+
+	members := strings.SplitN(ops[1], setMemberSep, -1)
+
+	fv.ContentType(members, extractVargs(ops, 3)...)
+
+	return nil
+}
+
+func (vb *fileValidationRuleBuilder) captureExclusiveFields(field string, ops []string, fv *FileValidationRule) error {
+	_, err := paramCount(ops, "MEX", field, 2, 3)
+
+	if err != nil {
+		return err
+	}
+
+	members := strings.SplitN(ops[1], setMemberSep, -1)
+	fields := types.NewOrderedStringSet(members)
+
+	fv.MEx(fields, extractVargs(ops, 3)...)
+
+	return nil
+
+}
+
+func (vb *fileValidationRuleBuilder) markRequired(field string, ops []string, fv *FileValidationRule) error {
+
+	_, err := paramCount(ops, "Required", field, 1, 2)
+
+	if err != nil {
+		return err
+	}
+
+	fv.Required(extractVargs(ops, 2)...)
+
+	return nil
+}
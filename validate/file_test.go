@@ -0,0 +1,146 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package validate
+
+import (
+	"context"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"github.com/graniticio/granitic/v2/types"
+	"mime/multipart"
+	"net/textproto"
+	"testing"
+)
+
+type FileTest struct {
+	Avatar      *types.UploadedFile
+	Attachments []*types.UploadedFile
+	Name        string
+}
+
+func uploadedFile(field, contentType string, size int64) *types.UploadedFile {
+
+	fh := new(multipart.FileHeader)
+	fh.Filename = "upload.bin"
+	fh.Size = size
+	fh.Header = textproto.MIMEHeader{}
+	fh.Header.Set("Content-Type", contentType)
+
+	return types.NewUploadedFile(field, fh)
+}
+
+func TestFileRequiredSizeAndType(t *testing.T) {
+
+	vb := newFileValidationRuleBuilder("DEF", nil)
+
+	fv, err := vb.parseRule("Avatar", []string{"FILE", "REQ:MISSING", "SIZE:10-100:SIZE", "TYPE:image/png,image/jpeg:TYPE"})
+
+	test.ExpectNil(t, err)
+
+	sub := new(FileTest)
+	vc := new(ValidationContext)
+	vc.Subject = sub
+
+	r, err := fv.Validate(vc)
+	test.ExpectNil(t, err)
+	test.ExpectBool(t, r.Unset, true)
+	test.ExpectString(t, r.ErrorCodes["Avatar"][0], "MISSING")
+
+	sub.Avatar = uploadedFile("Avatar", "image/png", 50)
+
+	r, err = fv.Validate(vc)
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, len(r.ErrorCodes["Avatar"]), 0)
+
+	sub.Avatar = uploadedFile("Avatar", "image/gif", 500)
+
+	r, err = fv.Validate(vc)
+	test.ExpectNil(t, err)
+
+	c := r.ErrorCodes["Avatar"]
+	test.ExpectInt(t, len(c), 2)
+	test.ExpectString(t, c[0], "SIZE")
+	test.ExpectString(t, c[1], "TYPE")
+
+	sub.Avatar = uploadedFile("Avatar", "", 50)
+
+	r, err = fv.Validate(vc)
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, len(r.ErrorCodes["Avatar"]), 1)
+}
+
+func TestFileTypeWildcard(t *testing.T) {
+
+	fv := NewFileValidationRule("Avatar", "DEF").ContentType([]string{"image/*"})
+
+	sub := new(FileTest)
+	vc := new(ValidationContext)
+	vc.Subject = sub
+
+	sub.Avatar = uploadedFile("Avatar", "IMAGE/PNG; name=x.png", 50)
+
+	r, err := fv.Validate(vc)
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, len(r.ErrorCodes["Avatar"]), 0)
+
+	sub.Avatar = uploadedFile("Avatar", "application/pdf", 50)
+
+	r, err = fv.Validate(vc)
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, len(r.ErrorCodes["Avatar"]), 1)
+}
+
+func TestFileRuleErrors(t *testing.T) {
+
+	vb := newFileValidationRuleBuilder("DEF", nil)
+
+	_, err := vb.parseRule("Avatar", []string{"FILE", "LEN:1-2"})
+
+	if err == nil {
+		t.Errorf("Expected unsupported operation to be rejected")
+	}
+
+	_, err = vb.parseRule("Avatar", []string{"FILE", "SIZE:big"})
+
+	if err == nil {
+		t.Errorf("Expected invalid size to be rejected")
+	}
+
+	fv, _ := vb.parseRule("Name", []string{"FILE", "REQ"})
+
+	vc := new(ValidationContext)
+	vc.Subject = new(FileTest)
+
+	if _, err := fv.Validate(vc); err == nil {
+		t.Errorf("Expected validation of a non-file field to fail")
+	}
+}
+
+func TestFileSliceElements(t *testing.T) {
+
+	rv := new(RuleValidator)
+	rv.DefaultErrorCode = "DEF"
+	rv.Log = new(logging.ConsoleErrorLogger)
+	rv.RuleManager = new(UnparsedRuleManager)
+	rv.RuleManager.Rules = map[string][]string{
+		"attachment": {"FILE", "SIZE:-100"},
+	}
+	rv.Rules = [][]string{
+		{"Attachments", "SLICE", "LEN:1-", "ELEM:attachment:ATTACH"},
+	}
+
+	if err := rv.StartComponent(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	sub := new(FileTest)
+	sub.Attachments = []*types.UploadedFile{uploadedFile("Attachments", "text/plain", 10), uploadedFile("Attachments", "text/plain", 1000)}
+
+	fe, err := rv.Validate(context.Background(), &SubjectContext{Subject: sub})
+
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, len(fe), 1)
+	test.ExpectString(t, fe[0].Field, "Attachments[1]")
+	test.ExpectString(t, fe[0].ErrorCodes[0], "ATTACH")
+}
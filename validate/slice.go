@@ -182,6 +182,8 @@ func (sv *SliceValidationRule) checkElementContents(field string, slice reflect.
 			vc.Subject, err = tv.toFloat64(fa, e.Interface())
 		case *BoolValidationRule:
 			vc.Subject, err = sv.boolValue(e, fa)
+		case *FileValidationRule:
+			vc.Subject, err = tv.toFile(fa, e.Interface())
 		}
 
 		if err != nil {
@@ -489,11 +491,11 @@ func (vb *sliceValidationRuleBuilder) addElementValidationOperation(field string
 	sv.codesInUse.AddAll(v.CodesInUse())
 
 	switch v.(type) {
-	case *StringValidationRule, *BoolValidationRule, *IntValidationRule, *FloatValidationRule:
+	case *StringValidationRule, *BoolValidationRule, *IntValidationRule, *FloatValidationRule, *FileValidationRule:
 		break
 	default:
-		m := fmt.Sprintf("Only %s, %s, %s and %s rules may be used to validate slice elements. Field %s is trying to use %s",
-			intRuleCode, floatRuleCode, boolRuleCode, stringRuleCode, field, rule[0])
+		m := fmt.Sprintf("Only %s, %s, %s, %s and %s rules may be used to validate slice elements. Field %s is trying to use %s",
+			intRuleCode, floatRuleCode, boolRuleCode, stringRuleCode, fileRuleCode, field, rule[0])
 		return errors.New(m)
 	}
 
@@ -146,6 +146,7 @@ const (
 	boolRuleType
 	floatRuleType
 	sliceRuleType
+	fileRuleType
 )
 
 const commandSep = ":"
@@ -320,6 +321,7 @@ type RuleValidator struct {
 	intValidatorBuilder    *intValidationRuleBuilder
 	floatValidatorBuilder  *floatValidationRuleBuilder
 	sliceValidatorBuilder  *sliceValidationRuleBuilder
+	fileValidatorBuilder   *fileValidationRuleBuilder
 	validatorChain         []*validatorLink
 	componentName          string
 	codesInUse             types.StringSet
@@ -501,6 +503,7 @@ func (ov *RuleValidator) StartComponent() error {
 	ov.floatValidatorBuilder = newFloatValidationRuleBuilder(ov.DefaultErrorCode, ov.ComponentFinder)
 
 	ov.sliceValidatorBuilder = newSliceValidationRuleBuilder(ov.DefaultErrorCode, ov.ComponentFinder, ov)
+	ov.fileValidatorBuilder = newFileValidationRuleBuilder(ov.DefaultErrorCode, ov.ComponentFinder)
 
 	return ov.parseRules()
 
@@ -550,6 +553,32 @@ func (ov *RuleValidator) parseRules() error {
 	return err
 }
 
+// ResolvedRules returns the text representation of this validator's rules with any references to shared rules (RULE:name)
+// replaced by the rule they refer to. The first element of each returned rule is the name of the field it applies to.
+func (ov *RuleValidator) ResolvedRules() ([][]string, error) {
+
+	var resolved [][]string
+
+	for _, rule := range ov.Rules {
+
+		if len(rule) >= 2 && ov.isRuleRef(rule[1]) {
+
+			shared, err := ov.findRule(rule[0], rule[1])
+
+			if err != nil {
+				return nil, err
+			}
+
+			resolved = append(resolved, append([]string{rule[0]}, shared...))
+
+		} else {
+			resolved = append(resolved, rule)
+		}
+	}
+
+	return resolved, nil
+}
+
 func (ov *RuleValidator) addValidator(field string, v ValidationRule) {
 
 	vl := new(validatorLink)
@@ -617,6 +646,8 @@ func (ov *RuleValidator) parseRule(field string, rule []string) (ValidationRule,
 		v, err = ov.parse(field, rule, ov.floatValidatorBuilder.parseRule)
 	case sliceRuleType:
 		v, err = ov.parse(field, rule, ov.sliceValidatorBuilder.parseRule)
+	case fileRuleType:
+		v, err = ov.parse(field, rule, ov.fileValidatorBuilder.parseRule)
 
 	default:
 		m := fmt.Sprintf("Unsupported rule type for field %s\n", field)
@@ -656,6 +687,8 @@ func (ov *RuleValidator) extractType(field string, rule []string) (validationRul
 			return floatRuleType, nil
 		case sliceRuleCode:
 			return sliceRuleType, nil
+		case fileRuleCode:
+			return fileRuleType, nil
 		}
 	}
 
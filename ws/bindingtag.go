@@ -0,0 +1,91 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package ws
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// BindingTag is the name of the struct tag used to declare where in an HTTP request the value of a request target's field
+// should be taken from, e.g.
+//
+//	type ListOrders struct {
+//		CustomerID int64  `ws:"path=id"`
+//		Page       int    `ws:"query=page"`
+//		Tenant     string `ws:"header=X-Tenant"`
+//		Session    string `ws:"cookie=session"`
+//	}
+const BindingTag = "ws"
+
+const (
+	// QuerySource indicates that a field is bound to the value of a query parameter.
+	QuerySource = "query"
+
+	// PathSource indicates that a field is bound to a path parameter, identified by its name in a path template, the name
+	// of a named capture group in a path regex or the index (starting at zero) of a capture group.
+	PathSource = "path"
+
+	// HeaderSource indicates that a field is bound to the value of a request header.
+	HeaderSource = "header"
+
+	// CookieSource indicates that a field is bound to the value of a cookie.
+	CookieSource = "cookie"
+)
+
+// TaggedBindings examines the fields of the supplied request target (a struct or pointer to a struct) for BindingTag
+// tags. The result is keyed by source (QuerySource etc) with each value being a map of field names to the names of the
+// parameters, headers etc they are bound to. An error is returned if a tag is malformed or names an unsupported source.
+func TaggedBindings(target interface{}) (map[string]map[string]string, error) {
+
+	bindings := make(map[string]map[string]string)
+
+	if target == nil {
+		return bindings, nil
+	}
+
+	t := reflect.TypeOf(target)
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return bindings, nil
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+
+		f := t.Field(i)
+		tag, found := f.Tag.Lookup(BindingTag)
+
+		if !found {
+			continue
+		}
+
+		kv := strings.SplitN(tag, "=", 2)
+
+		if len(kv) != 2 || strings.TrimSpace(kv[1]) == "" {
+			return nil, fmt.Errorf("the %s tag on field %s should be in the format source=name (e.g. %s=X-Tenant)", BindingTag, f.Name, HeaderSource)
+		}
+
+		source, name := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+
+		switch source {
+		case QuerySource, PathSource, HeaderSource, CookieSource:
+		default:
+			return nil, fmt.Errorf("the %s tag on field %s uses the unsupported source %s. Must be one of %s, %s, %s or %s", BindingTag, f.Name, source,
+				QuerySource, PathSource, HeaderSource, CookieSource)
+		}
+
+		if bindings[source] == nil {
+			bindings[source] = make(map[string]string)
+		}
+
+		bindings[source][f.Name] = name
+	}
+
+	return bindings, nil
+}
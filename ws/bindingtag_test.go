@@ -0,0 +1,51 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package ws
+
+import (
+	"github.com/graniticio/granitic/v2/test"
+	"testing"
+)
+
+func TestTaggedBindings(t *testing.T) {
+
+	tar := struct {
+		ID      int64  `ws:"path=id"`
+		Page    int    `ws:"query=page"`
+		Tenant  string `ws:"header=X-Tenant"`
+		Session string `ws:"cookie= session "`
+		Name    string `json:"name"`
+	}{}
+
+	b, err := TaggedBindings(&tar)
+
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, len(b), 4)
+	test.ExpectString(t, b[PathSource]["ID"], "id")
+	test.ExpectString(t, b[QuerySource]["Page"], "page")
+	test.ExpectString(t, b[HeaderSource]["Tenant"], "X-Tenant")
+	test.ExpectString(t, b[CookieSource]["Session"], "session")
+
+	b, err = TaggedBindings(nil)
+
+	test.ExpectNil(t, err)
+	test.ExpectInt(t, len(b), 0)
+}
+
+func TestInvalidTaggedBindings(t *testing.T) {
+
+	malformed := struct {
+		Tenant string `ws:"header"`
+	}{}
+
+	_, err := TaggedBindings(&malformed)
+	test.ExpectBool(t, err == nil, false)
+
+	unsupported := struct {
+		Tenant string `ws:"body=tenant"`
+	}{}
+
+	_, err = TaggedBindings(&unsupported)
+	test.ExpectBool(t, err == nil, false)
+}
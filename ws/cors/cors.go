@@ -0,0 +1,372 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+/*
+Package cors provides support for Cross-Origin Resource Sharing (CORS), allowing web services to be called from web pages
+served from other origins.
+
+The main type in this package is Manager, which is normally created by the CORS facility. A Manager is used in two ways:
+
+1. The HTTPServer facility asks the Manager for the response headers for CORS preflight requests (OPTIONS requests with
+Origin and Access-Control-Request-Method headers) before the request is matched to a handler.
+
+2. The Manager is set as (or chained in front of) the ws.CommonResponseHeaderBuilder of the JSON and XML response
+writers, so that the Access-Control-Allow-Origin and related headers are added to the responses to actual requests.
+
+Policies
+
+A Policy defines which origins, methods and headers are allowed. Origins may contain the wildcard * which matches any
+sequence of characters other than /, e.g.
+
+	https://*.example.com
+
+or may be the single value * which allows any origin. The Manager has a default Policy and, optionally, a Policy for
+individual handlers (keyed by the handler's component name) which replaces the default Policy for that handler.
+*/
+package cors
+
+import (
+	"context"
+	"fmt"
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/ws"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Names of the headers used in CORS requests and responses
+const (
+	OriginHeader           = "Origin"
+	RequestMethodHeader    = "Access-Control-Request-Method"
+	RequestHeadersHeader   = "Access-Control-Request-Headers"
+	AllowOriginHeader      = "Access-Control-Allow-Origin"
+	AllowMethodsHeader     = "Access-Control-Allow-Methods"
+	AllowHeadersHeader     = "Access-Control-Allow-Headers"
+	AllowCredentialsHeader = "Access-Control-Allow-Credentials"
+	ExposeHeadersHeader    = "Access-Control-Expose-Headers"
+	MaxAgeHeader           = "Access-Control-Max-Age"
+	varyHeader             = "Vary"
+)
+
+const anyValue = "*"
+
+type ctxKey string
+
+const originKey ctxKey = "GRNCCORSORIGIN"
+
+// IsPreflight returns true if the request is a CORS preflight request.
+func IsPreflight(req *http.Request) bool {
+	return req.Method == http.MethodOptions && req.Header.Get(OriginHeader) != "" && req.Header.Get(RequestMethodHeader) != ""
+}
+
+// Policy defines the cross-origin requests that will be allowed.
+type Policy struct {
+	// The origins (scheme, host and optional port) that are allowed to make requests. May contain * wildcards or be the
+	// single value *. If empty, no cross-origin requests are allowed.
+	AllowedOrigins []string
+
+	// The HTTP methods that may be used. If empty, the method requested in a preflight request is allowed.
+	AllowedMethods []string
+
+	// The request headers that may be sent. The value * allows any header.
+	AllowedHeaders []string
+
+	// Response headers (other than the CORS-safelisted headers) that browsers should make available to callers.
+	ExposedHeaders []string
+
+	// Whether or not requests may include credentials (cookies, HTTP authentication).
+	AllowCredentials bool
+
+	// How long (in seconds) browsers may cache the result of a preflight request. Zero or less means no header is sent.
+	MaxAge int
+
+	origins []*regexp.Regexp
+	any     bool
+}
+
+func (p *Policy) compile() error {
+
+	p.origins = nil
+	p.any = false
+
+	for _, o := range p.AllowedOrigins {
+
+		o = strings.TrimSpace(o)
+
+		if o == anyValue {
+			p.any = true
+			continue
+		}
+
+		pattern := "(?i)^" + strings.Replace(regexp.QuoteMeta(o), "\\*", "[^/]*", -1) + "$"
+
+		r, err := regexp.Compile(pattern)
+
+		if err != nil {
+			return fmt.Errorf("unable to use origin %s: %s", o, err.Error())
+		}
+
+		p.origins = append(p.origins, r)
+	}
+
+	return nil
+}
+
+// OriginAllowed returns true if the supplied origin matches one of the policy's AllowedOrigins
+func (p *Policy) OriginAllowed(origin string) bool {
+
+	if origin == "" {
+		return false
+	}
+
+	if p.any {
+		return true
+	}
+
+	for _, r := range p.origins {
+		if r.MatchString(origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *Policy) methodAllowed(method string) bool {
+
+	if len(p.AllowedMethods) == 0 {
+		return true
+	}
+
+	for _, m := range p.AllowedMethods {
+		if strings.EqualFold(m, method) || m == anyValue {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *Policy) headersAllowed(requested []string) bool {
+
+	if contains(p.AllowedHeaders, anyValue) {
+		return true
+	}
+
+	for _, r := range requested {
+		if !contains(p.AllowedHeaders, r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// originHeaders builds the headers common to preflight and actual responses
+func (p *Policy) originHeaders(origin string) map[string]string {
+
+	h := make(map[string]string)
+
+	if p.any && !p.AllowCredentials {
+		h[AllowOriginHeader] = anyValue
+	} else {
+		h[AllowOriginHeader] = origin
+		h[varyHeader] = OriginHeader
+	}
+
+	if p.AllowCredentials {
+		h[AllowCredentialsHeader] = "true"
+	}
+
+	return h
+}
+
+/*
+Manager decides whether or not cross-origin requests are allowed and builds the CORS headers that are added to responses.
+*/
+type Manager struct {
+	// The policy applied to handlers that do not have their own policy.
+	Policy
+
+	// Policies for individual handlers, keyed by the component name of the handler. A handler's policy replaces the default policy.
+	Handlers map[string]*Policy
+
+	// Injected by Granitic
+	FrameworkLogger logging.Logger
+
+	state ioc.ComponentState
+}
+
+// PolicyFor returns the policy that applies to the named handler.
+func (m *Manager) PolicyFor(handler string) *Policy {
+
+	if p := m.Handlers[handler]; p != nil {
+		return p
+	}
+
+	return &m.Policy
+}
+
+// PreflightHeaders determines whether or not the supplied preflight request (for an actual request that would be handled
+// by the named handler) is allowed and, if it is, returns the headers that should be sent in the response.
+func (m *Manager) PreflightHeaders(req *http.Request, handler string) (map[string]string, bool) {
+
+	p := m.PolicyFor(handler)
+
+	origin := req.Header.Get(OriginHeader)
+	method := req.Header.Get(RequestMethodHeader)
+	requested := splitHeaderList(req.Header.Get(RequestHeadersHeader))
+
+	if !p.OriginAllowed(origin) || !p.methodAllowed(method) || !p.headersAllowed(requested) {
+		m.FrameworkLogger.LogDebugf("Rejected CORS preflight request from %s for %s %s", origin, method, req.URL.Path)
+		return nil, false
+	}
+
+	h := p.originHeaders(origin)
+	h[varyHeader] = strings.Join([]string{OriginHeader, RequestMethodHeader, RequestHeadersHeader}, ", ")
+
+	if len(p.AllowedMethods) == 0 || contains(p.AllowedMethods, anyValue) {
+		h[AllowMethodsHeader] = method
+	} else {
+		h[AllowMethodsHeader] = strings.Join(p.AllowedMethods, ", ")
+	}
+
+	if len(requested) > 0 {
+		if contains(p.AllowedHeaders, anyValue) {
+			h[AllowHeadersHeader] = strings.Join(requested, ", ")
+		} else {
+			h[AllowHeadersHeader] = strings.Join(p.AllowedHeaders, ", ")
+		}
+	}
+
+	if p.MaxAge > 0 {
+		h[MaxAgeHeader] = strconv.Itoa(p.MaxAge)
+	}
+
+	return h, true
+}
+
+// WithOrigin stores the Origin of a cross-origin request in the supplied context so that CORS headers can be added to
+// the response by BuildHeaders. The context is returned unmodified if the request has no Origin header.
+func (m *Manager) WithOrigin(ctx context.Context, req *http.Request) context.Context {
+
+	origin := req.Header.Get(OriginHeader)
+
+	if origin == "" {
+		return ctx
+	}
+
+	return context.WithValue(ctx, originKey, origin)
+}
+
+// BuildHeaders implements ws.CommonResponseHeaderBuilder, returning the CORS headers for the response to an actual
+// (not preflight) cross-origin request.
+func (m *Manager) BuildHeaders(ctx context.Context, state *ws.ProcessState) map[string]string {
+
+	origin, found := ctx.Value(originKey).(string)
+
+	if !found {
+		return nil
+	}
+
+	handler := ""
+
+	if state != nil && state.WsRequest != nil {
+		handler = state.WsRequest.ServingHandler
+	}
+
+	p := m.PolicyFor(handler)
+
+	if !p.OriginAllowed(origin) {
+
+		if p.any {
+			return nil
+		}
+
+		return map[string]string{varyHeader: OriginHeader}
+	}
+
+	h := p.originHeaders(origin)
+
+	if len(p.ExposedHeaders) > 0 {
+		h[ExposeHeadersHeader] = strings.Join(p.ExposedHeaders, ", ")
+	}
+
+	return h
+}
+
+// StartComponent compiles the origin patterns of all policies. Returns an error if a pattern is invalid.
+func (m *Manager) StartComponent() error {
+
+	if m.state != ioc.StoppedState {
+		return nil
+	}
+
+	m.state = ioc.StartingState
+
+	if err := m.Policy.compile(); err != nil {
+		return err
+	}
+
+	for name, p := range m.Handlers {
+		if err := p.compile(); err != nil {
+			return fmt.Errorf("problem with the CORS policy for %s: %s", name, err.Error())
+		}
+	}
+
+	m.state = ioc.RunningState
+
+	return nil
+}
+
+// ChainedHeaderBuilder adds CORS headers to the headers built by another ws.CommonResponseHeaderBuilder.
+type ChainedHeaderBuilder struct {
+	// The builder whose headers are merged with the CORS headers.
+	Next ws.CommonResponseHeaderBuilder
+
+	// The component that builds CORS headers.
+	Manager *Manager
+}
+
+// BuildHeaders implements ws.CommonResponseHeaderBuilder. CORS headers take precedence over the headers built by Next.
+func (cb *ChainedHeaderBuilder) BuildHeaders(ctx context.Context, state *ws.ProcessState) map[string]string {
+
+	h := cb.Next.BuildHeaders(ctx, state)
+
+	if h == nil {
+		h = make(map[string]string)
+	}
+
+	for k, v := range cb.Manager.BuildHeaders(ctx, state) {
+		h[k] = v
+	}
+
+	return h
+}
+
+func splitHeaderList(v string) []string {
+
+	var l []string
+
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			l = append(l, s)
+		}
+	}
+
+	return l
+}
+
+func contains(l []string, v string) bool {
+
+	for _, s := range l {
+		if strings.EqualFold(s, v) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,141 @@
+package cors
+
+import (
+	"context"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"github.com/graniticio/granitic/v2/ws"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newManager(t *testing.T) *Manager {
+
+	m := new(Manager)
+	m.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	m.AllowedOrigins = []string{"https://*.example.com", "http://localhost:8080"}
+	m.AllowedMethods = []string{"GET", "POST"}
+	m.AllowedHeaders = []string{"Content-Type", "Authorization"}
+	m.ExposedHeaders = []string{"X-Total"}
+	m.AllowCredentials = true
+	m.MaxAge = 300
+
+	m.Handlers = map[string]*Policy{
+		"publicHandler": {AllowedOrigins: []string{"*"}, AllowedHeaders: []string{"*"}},
+		"closedHandler": {},
+	}
+
+	if err := m.StartComponent(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	return m
+}
+
+func preflight(origin, method, headers string) *http.Request {
+
+	r := httptest.NewRequest(http.MethodOptions, "/articles", nil)
+	r.Header.Set(OriginHeader, origin)
+	r.Header.Set(RequestMethodHeader, method)
+
+	if headers != "" {
+		r.Header.Set(RequestHeadersHeader, headers)
+	}
+
+	return r
+}
+
+func TestOriginMatching(t *testing.T) {
+
+	m := newManager(t)
+
+	test.ExpectBool(t, m.OriginAllowed("https://api.example.com"), true)
+	test.ExpectBool(t, m.OriginAllowed("HTTPS://API.EXAMPLE.COM"), true)
+	test.ExpectBool(t, m.OriginAllowed("https://example.com"), false)
+	test.ExpectBool(t, m.OriginAllowed("http://api.example.com"), false)
+	test.ExpectBool(t, m.OriginAllowed("https://api.example.com.evil.org"), false)
+	test.ExpectBool(t, m.OriginAllowed("http://localhost:8080"), true)
+	test.ExpectBool(t, m.OriginAllowed("http://localhost:8081"), false)
+	test.ExpectBool(t, m.OriginAllowed(""), false)
+
+	test.ExpectBool(t, m.PolicyFor("publicHandler").OriginAllowed("https://anywhere.org"), true)
+	test.ExpectBool(t, m.PolicyFor("closedHandler").OriginAllowed("https://api.example.com"), false)
+}
+
+func TestPreflight(t *testing.T) {
+
+	m := newManager(t)
+
+	h, allowed := m.PreflightHeaders(preflight("https://api.example.com", "POST", "content-type"), "articleHandler")
+
+	test.ExpectBool(t, allowed, true)
+	test.ExpectString(t, h[AllowOriginHeader], "https://api.example.com")
+	test.ExpectString(t, h[AllowMethodsHeader], "GET, POST")
+	test.ExpectString(t, h[AllowHeadersHeader], "Content-Type, Authorization")
+	test.ExpectString(t, h[AllowCredentialsHeader], "true")
+	test.ExpectString(t, h[MaxAgeHeader], "300")
+
+	_, allowed = m.PreflightHeaders(preflight("https://api.example.com", "DELETE", ""), "articleHandler")
+	test.ExpectBool(t, allowed, false)
+
+	_, allowed = m.PreflightHeaders(preflight("https://api.example.com", "POST", "X-Custom"), "articleHandler")
+	test.ExpectBool(t, allowed, false)
+
+	_, allowed = m.PreflightHeaders(preflight("https://other.org", "POST", ""), "articleHandler")
+	test.ExpectBool(t, allowed, false)
+
+	h, allowed = m.PreflightHeaders(preflight("https://other.org", "PATCH", "X-Custom, X-Other"), "publicHandler")
+
+	test.ExpectBool(t, allowed, true)
+	test.ExpectString(t, h[AllowOriginHeader], "*")
+	test.ExpectString(t, h[AllowMethodsHeader], "PATCH")
+	test.ExpectString(t, h[AllowHeadersHeader], "X-Custom, X-Other")
+	test.ExpectString(t, h[AllowCredentialsHeader], "")
+	test.ExpectString(t, h[MaxAgeHeader], "")
+}
+
+func TestActualRequestHeaders(t *testing.T) {
+
+	m := newManager(t)
+
+	r := httptest.NewRequest(http.MethodGet, "/articles", nil)
+
+	if h := m.BuildHeaders(m.WithOrigin(context.Background(), r), new(ws.ProcessState)); h != nil {
+		t.Errorf("Did not expect headers for a same-origin request")
+	}
+
+	r.Header.Set(OriginHeader, "https://api.example.com")
+	ctx := m.WithOrigin(context.Background(), r)
+
+	state := new(ws.ProcessState)
+	state.WsRequest = new(ws.Request)
+	state.WsRequest.ServingHandler = "articleHandler"
+
+	h := m.BuildHeaders(ctx, state)
+
+	test.ExpectString(t, h[AllowOriginHeader], "https://api.example.com")
+	test.ExpectString(t, h[varyHeader], OriginHeader)
+	test.ExpectString(t, h[ExposeHeadersHeader], "X-Total")
+	test.ExpectString(t, h[AllowCredentialsHeader], "true")
+
+	state.WsRequest.ServingHandler = "closedHandler"
+	h = m.BuildHeaders(ctx, state)
+
+	test.ExpectString(t, h[AllowOriginHeader], "")
+	test.ExpectString(t, h[varyHeader], OriginHeader)
+
+	cb := &ChainedHeaderBuilder{Next: new(fixedHeaders), Manager: m}
+
+	state.WsRequest.ServingHandler = "articleHandler"
+	h = cb.BuildHeaders(ctx, state)
+
+	test.ExpectString(t, h["X-Server"], "test")
+	test.ExpectString(t, h[AllowOriginHeader], "https://api.example.com")
+}
+
+type fixedHeaders struct{}
+
+func (fh *fixedHeaders) BuildHeaders(ctx context.Context, state *ws.ProcessState) map[string]string {
+	return map[string]string{"X-Server": "test"}
+}
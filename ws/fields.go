@@ -0,0 +1,649 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package ws
+
+import (
+	"encoding"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// FieldSelection is a tree of the names of the fields that should be included when a response body is serialised (a
+// 'sparse fieldset'). A field whose entry is nil is included in full, otherwise only the fields named in its entry
+// are included.
+type FieldSelection map[string]FieldSelection
+
+// ParseFieldSelection converts a comma separated list of field names into a FieldSelection. Fields of nested objects
+// are selected using a dot separated path, e.g. name,address.city,albums.title  Selecting a field in full (e.g.
+// address) takes precedence over selecting some of its fields (e.g. address.city).
+func ParseFieldSelection(s string) FieldSelection {
+
+	fs := make(FieldSelection)
+
+	for _, p := range strings.Split(s, ",") {
+
+		if p = strings.TrimSpace(p); p == "" {
+			continue
+		}
+
+		fs.add(strings.Split(p, "."))
+	}
+
+	return fs
+}
+
+func (fs FieldSelection) add(path []string) {
+
+	name := path[0]
+	sub, found := fs[name]
+
+	if len(path) == 1 {
+		fs[name] = nil
+		return
+	}
+
+	if found && sub == nil {
+		// Already selected in full
+		return
+	}
+
+	if sub == nil {
+		sub = make(FieldSelection)
+		fs[name] = sub
+	}
+
+	sub.add(path[1:])
+}
+
+// UnknownFieldError is returned by SelectFields when a FieldSelection names a field that does not exist.
+type UnknownFieldError struct {
+	// The dot separated path of the unknown field.
+	Path string
+}
+
+// Error implements error.Error
+func (ufe *UnknownFieldError) Error() string {
+	return fmt.Sprintf("no field named %s exists", ufe.Path)
+}
+
+/*
+SelectFields creates a copy of the supplied value (normally the Body of a Response) that only contains the fields in the
+supplied FieldSelection. The copy is built from new struct types (and slices and maps of those types), so when it is
+serialised as JSON or XML the fields that were not selected are omitted entirely, while the names, tags and order of the
+selected fields are preserved.
+
+Fields are selected by the name they are serialised with: a name matches a field if it is the field's Go name, or the
+name in the field's json or xml tag. The fields of embedded structs are treated as fields of the embedding struct and
+XMLName fields are always retained. Types that serialise themselves (those implementing json.Marshaler, xml.Marshaler or
+encoding.TextMarshaler, such as time.Time and Granitic's nilable types) cannot have fields selected from them.
+
+An UnknownFieldError is returned if the selection names a field that does not exist.
+*/
+func SelectFields(v interface{}, fs FieldSelection) (interface{}, error) {
+
+	if v == nil || fs == nil {
+		return v, nil
+	}
+
+	pv, err := selectValue(reflect.ValueOf(v), fs, "")
+
+	if err != nil || !pv.IsValid() {
+		return nil, err
+	}
+
+	return pv.Interface(), nil
+}
+
+/*
+CheckFieldSelection returns an UnknownFieldError if the supplied FieldSelection names a field that values of the supplied
+type (normally the type of a Response's Body) can never have, allowing a selection to be rejected before a response
+is built. Fields of interfaces and the keys of maps are only known once a value exists, so any names are accepted for
+them; SelectFields may still return an UnknownFieldError for such names.
+*/
+func CheckFieldSelection(t reflect.Type, fs FieldSelection) error {
+
+	if t == nil || fs == nil {
+		return nil
+	}
+
+	return checkType(t, fs, "")
+}
+
+// checkType mirrors selectValue for a type rather than a value
+func checkType(t reflect.Type, fs FieldSelection, path string) error {
+
+	switch t.Kind() {
+	case reflect.Interface:
+		return nil
+
+	case reflect.Ptr:
+
+		if !selectable(t) {
+			return unknownField(fs, path)
+		}
+
+		return checkType(t.Elem(), fs, path)
+
+	case reflect.Struct:
+
+		if !selectable(t) {
+			return unknownField(fs, path)
+		}
+
+		return checkStruct(t, fs, path)
+
+	case reflect.Slice, reflect.Array:
+		return checkType(t.Elem(), fs, path)
+
+	case reflect.Map:
+
+		if t.Key().Kind() != reflect.String {
+			return unknownField(fs, path)
+		}
+
+		return nil
+
+	default:
+		return unknownField(fs, path)
+	}
+}
+
+// checkStruct mirrors selectStruct for a type rather than a value
+func checkStruct(t reflect.Type, fs FieldSelection, path string) error {
+
+	matched := make(map[string]bool)
+
+	flattened := flattenFields(t, reflect.Value{})
+	owners := nameOwners(flattened)
+
+	for i, f := range flattened {
+
+		name, sub, found := f.selectedBy(fs, owners, i)
+
+		if !found {
+			continue
+		}
+
+		matched[name] = true
+
+		if sub == nil {
+			continue
+		}
+
+		if err := checkType(f.field.Type, sub, join(path, name)); err != nil {
+			return err
+		}
+	}
+
+	for name := range fs {
+		if !matched[name] {
+			return &UnknownFieldError{Path: join(path, name)}
+		}
+	}
+
+	return nil
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	xmlMarshalerType  = reflect.TypeOf((*xml.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	xmlNameType       = reflect.TypeOf(xml.Name{})
+	emptyInterface    = reflect.TypeOf((*interface{})(nil)).Elem()
+)
+
+// selectable returns true if fields can be selected from values of the supplied type
+func selectable(t reflect.Type) bool {
+
+	for _, mt := range []reflect.Type{jsonMarshalerType, xmlMarshalerType, textMarshalerType} {
+		if t.Implements(mt) || reflect.PtrTo(t).Implements(mt) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// selectValue returns a copy of the supplied value containing only the selected fields. The path is the location of
+// the value in the overall selection, used in errors.
+func selectValue(v reflect.Value, fs FieldSelection, path string) (reflect.Value, error) {
+
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+
+		if v.IsNil() {
+			return reflect.Zero(emptyInterface), nil
+		}
+
+		if v.Kind() == reflect.Ptr && !selectable(v.Type()) {
+			return v, unknownField(fs, path)
+		}
+
+		return selectValue(v.Elem(), fs, path)
+
+	case reflect.Struct:
+
+		if !selectable(v.Type()) {
+			return v, unknownField(fs, path)
+		}
+
+		return selectStruct(v, fs, path)
+
+	case reflect.Slice, reflect.Array:
+
+		out := reflect.MakeSlice(reflect.SliceOf(emptyInterface), v.Len(), v.Len())
+
+		for i := 0; i < v.Len(); i++ {
+
+			ev, err := selectValue(v.Index(i), fs, path)
+
+			if err != nil {
+				return ev, err
+			}
+
+			out.Index(i).Set(ev)
+		}
+
+		return out, nil
+
+	case reflect.Map:
+
+		if v.Type().Key().Kind() != reflect.String {
+			return v, unknownField(fs, path)
+		}
+
+		out := reflect.MakeMap(reflect.MapOf(v.Type().Key(), emptyInterface))
+
+		for name, sub := range fs {
+
+			k := reflect.ValueOf(name).Convert(v.Type().Key())
+			mv := v.MapIndex(k)
+
+			if !mv.IsValid() {
+				return mv, &UnknownFieldError{Path: join(path, name)}
+			}
+
+			sv, err := selectChild(mv, sub, join(path, name))
+
+			if err != nil {
+				return sv, err
+			}
+
+			out.SetMapIndex(k, sv)
+		}
+
+		return out, nil
+
+	default:
+		return v, unknownField(fs, path)
+	}
+}
+
+// selectChild copies a selected field, either in full or restricted to the fields in its own selection
+func selectChild(v reflect.Value, fs FieldSelection, path string) (reflect.Value, error) {
+
+	if fs == nil {
+		return v, nil
+	}
+
+	return selectValue(v, fs, path)
+}
+
+// selectStruct builds a struct type containing only the selected fields of the supplied struct and a value of that type
+func selectStruct(v reflect.Value, fs FieldSelection, path string) (reflect.Value, error) {
+
+	var fields []reflect.StructField
+	var values []reflect.Value
+
+	matched := make(map[string]bool)
+	goNames := make(map[string]bool)
+
+	flattened := flattenFields(v.Type(), v)
+	owners := nameOwners(flattened)
+
+	for i, f := range flattened {
+
+		sf := f.field
+
+		if sf.Type == xmlNameType && sf.Name == "XMLName" {
+			fields = append(fields, sf)
+			values = append(values, f.value)
+			continue
+		}
+
+		name, sub, found := f.selectedBy(fs, owners, i)
+
+		if !found {
+			continue
+		}
+
+		matched[name] = true
+
+		sv, err := selectChild(f.value, sub, join(path, name))
+
+		if err != nil {
+			return sv, err
+		}
+
+		if sub != nil {
+			sf.Type = emptyInterface
+		}
+
+		sf.Index = nil
+		sf.Offset = 0
+		sf.Anonymous = false
+
+		if goNames[sf.Name] {
+			// Promoted fields may share a Go name with a field serialised under a different name, which
+			// reflect.StructOf does not allow
+			sf = renamed(sf, fmt.Sprintf("%s%d", sf.Name, i))
+		}
+
+		goNames[sf.Name] = true
+
+		fields = append(fields, sf)
+		values = append(values, sv)
+	}
+
+	for name := range fs {
+		if !matched[name] {
+			return v, &UnknownFieldError{Path: join(path, name)}
+		}
+	}
+
+	out := reflect.New(reflect.StructOf(fields)).Elem()
+
+	for i, fv := range values {
+		if fv.IsValid() {
+			out.Field(i).Set(fv)
+		}
+	}
+
+	return out, nil
+}
+
+type structField struct {
+	field reflect.StructField
+	value reflect.Value
+	// The number of embedded structs the field was promoted through
+	depth int
+}
+
+// selectedBy returns the name by which the field was selected and the selection of its own fields. Only names that
+// refer to this field (the field at index i in owners) are considered.
+func (sf structField) selectedBy(fs FieldSelection, owners map[string]int, i int) (string, FieldSelection, bool) {
+
+	for _, name := range sf.names() {
+
+		if owners[name] != i {
+			continue
+		}
+
+		if sub, found := fs[name]; found {
+			return name, sub, true
+		}
+	}
+
+	return "", nil, false
+}
+
+// names returns the Go name of the field and the names given to it in its json and xml tags. Fields that are
+// excluded from both JSON and XML (tagged with -) have no names.
+func (sf structField) names() []string {
+
+	names := []string{sf.field.Name}
+	excluded := 0
+
+	for _, tag := range []string{"json", "xml"} {
+
+		t, found := sf.field.Tag.Lookup(tag)
+
+		if !found {
+			continue
+		}
+
+		if t == "-" {
+			excluded++
+			continue
+		}
+
+		if n := strings.Split(t, ",")[0]; n != "" {
+			// XML names may include a namespace or a path (a>b)
+			n = n[strings.LastIndex(n, " ")+1:]
+			names = append(names, n[strings.LastIndex(n, ">")+1:])
+		}
+	}
+
+	if excluded == 2 {
+		return nil
+	}
+
+	return names
+}
+
+// nameOwners maps each name a field can be selected by to the index of that field. Where a name could refer to more
+// than one field (a Go name shared with a field serialised under that name), the field serialised under the name is
+// preferred, then the field promoted through the fewest embedded structs.
+func nameOwners(fields []structField) map[string]int {
+
+	owners := make(map[string]int)
+
+	for i, f := range fields {
+		if f.field.Tag.Get("json") != "-" {
+			owners[f.jsonName()] = i
+		}
+	}
+
+	others := make(map[string]int)
+
+	for i, f := range fields {
+		for _, name := range f.names() {
+
+			if _, found := owners[name]; found {
+				continue
+			}
+
+			if j, found := others[name]; !found || f.depth < fields[j].depth {
+				others[name] = i
+			}
+		}
+	}
+
+	for name, i := range others {
+		owners[name] = i
+	}
+
+	return owners
+}
+
+// renamed returns a copy of the supplied field with a new Go name, tagged so that it is still serialised under its
+// original name
+func renamed(f reflect.StructField, name string) reflect.StructField {
+
+	tag := string(f.Tag)
+
+	for _, t := range []string{"json", "xml"} {
+		if _, found := f.Tag.Lookup(t); !found {
+			tag = strings.TrimSpace(fmt.Sprintf(`%s %s:"%s"`, tag, t, f.Name))
+		}
+	}
+
+	f.Name = name
+	f.Tag = reflect.StructTag(tag)
+
+	return f
+}
+
+// flattenFields returns the exported fields of a struct, with the fields of embedded structs in place of the embedded
+// struct. Where more than one field would be serialised with the same name, the field that encoding/json would use is
+// chosen (see dominantFields). If v is not valid, the fields of the type are returned, including those of embedded
+// pointers that might be nil.
+func flattenFields(t reflect.Type, v reflect.Value) []structField {
+	return dominantFields(embeddedFields(t, v, 0))
+}
+
+// embeddedFields returns the exported fields of a struct and, recursively, those of its embedded structs
+func embeddedFields(t reflect.Type, v reflect.Value, depth int) []structField {
+
+	var fields []structField
+
+	for i := 0; i < t.NumField(); i++ {
+
+		f := t.Field(i)
+
+		var fv reflect.Value
+
+		if v.IsValid() {
+			fv = v.Field(i)
+		}
+
+		if f.Anonymous && !hasTagName(f, "json") {
+
+			et := f.Type
+			ev := fv
+
+			if et.Kind() == reflect.Ptr {
+
+				if fv.IsValid() {
+
+					if fv.IsNil() {
+						continue
+					}
+
+					ev = fv.Elem()
+				}
+
+				et = et.Elem()
+			}
+
+			if et.Kind() == reflect.Struct && selectable(et) {
+				fields = append(fields, embeddedFields(et, ev, depth+1)...)
+				continue
+			}
+		}
+
+		if f.PkgPath != "" {
+			// Unexported
+			continue
+		}
+
+		fields = append(fields, structField{field: f, value: fv, depth: depth})
+	}
+
+	return fields
+}
+
+// dominantFields applies encoding/json's rules to fields that would be serialised with the same name: the field
+// promoted through the fewest embedded structs wins, then a field whose name comes from a json tag. If there is still
+// more than one candidate, none of them are serialised.
+func dominantFields(fields []structField) []structField {
+
+	byName := make(map[string][]int)
+	keep := make([]bool, len(fields))
+
+	for i, f := range fields {
+
+		if f.field.Tag.Get("json") == "-" {
+			// Not serialised by encoding/json, so does not shadow other fields
+			keep[i] = true
+			continue
+		}
+
+		n := f.jsonName()
+		byName[n] = append(byName[n], i)
+	}
+
+	for _, candidates := range byName {
+		if i := dominantField(fields, candidates); i >= 0 {
+			keep[i] = true
+		}
+	}
+
+	var out []structField
+
+	for i, f := range fields {
+		if keep[i] {
+			out = append(out, f)
+		}
+	}
+
+	return out
+}
+
+// dominantField returns the index of the field that encoding/json would serialise from the supplied candidates, or -1
+// if the candidates are ambiguous.
+func dominantField(fields []structField, candidates []int) int {
+
+	shallowest := -1
+
+	for _, i := range candidates {
+		if shallowest < 0 || fields[i].depth < shallowest {
+			shallowest = fields[i].depth
+		}
+	}
+
+	var dominant, tagged []int
+
+	for _, i := range candidates {
+
+		if fields[i].depth != shallowest {
+			continue
+		}
+
+		dominant = append(dominant, i)
+
+		if hasTagName(fields[i].field, "json") {
+			tagged = append(tagged, i)
+		}
+	}
+
+	switch {
+	case len(dominant) == 1:
+		return dominant[0]
+	case len(tagged) == 1:
+		return tagged[0]
+	default:
+		return -1
+	}
+}
+
+// jsonName returns the name the field is serialised with by encoding/json
+func (sf structField) jsonName() string {
+
+	if hasTagName(sf.field, "json") {
+		return strings.Split(sf.field.Tag.Get("json"), ",")[0]
+	}
+
+	return sf.field.Name
+}
+
+// hasTagName returns true if the field has a tag of the supplied type that sets the field's name
+func hasTagName(f reflect.StructField, tag string) bool {
+
+	n := strings.Split(f.Tag.Get(tag), ",")[0]
+
+	return n != "" && n != "-"
+}
+
+// unknownField returns an error for the first field in the selection, as the value at the supplied path has no fields.
+func unknownField(fs FieldSelection, path string) error {
+
+	for name := range fs {
+		return &UnknownFieldError{Path: join(path, name)}
+	}
+
+	return nil
+}
+
+func join(path, name string) string {
+
+	if path == "" {
+		return name
+	}
+
+	return path + "." + name
+}
@@ -0,0 +1,209 @@
+package ws
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"github.com/graniticio/granitic/v2/test"
+	"github.com/graniticio/granitic/v2/types"
+	"reflect"
+	"testing"
+)
+
+type fieldsAudit struct {
+	CreatedBy string `json:"createdBy"`
+	Revision  int    `json:"revision"`
+}
+
+type fieldsAddress struct {
+	Street string `json:"street" xml:"street"`
+	City   string `json:"city" xml:"city"`
+}
+
+type fieldsAlbum struct {
+	Title string `json:"title" xml:"title"`
+	Year  int    `json:"year" xml:"year"`
+}
+
+type fieldsArtist struct {
+	fieldsAudit
+	XMLName  xml.Name             `json:"-" xml:"artist"`
+	ID       int                  `json:"id" xml:"id,attr"`
+	Name     string               `json:"name" xml:"name"`
+	Genre    *types.NilableString `json:"genre,omitempty" xml:"-"`
+	Address  *fieldsAddress       `json:"address" xml:"address"`
+	Albums   []fieldsAlbum        `json:"albums" xml:"albums>album"`
+	Secret   string               `json:"-" xml:"-"`
+	Extra    map[string]string    `json:"extra,omitempty" xml:"-"`
+	internal string
+}
+
+func newFieldsArtist() *fieldsArtist {
+
+	a := new(fieldsArtist)
+	a.CreatedBy = "admin"
+	a.Revision = 3
+	a.ID = 12
+	a.Name = "Nico"
+	a.Genre = types.NewNilableString("Rock")
+	a.Address = &fieldsAddress{Street: "Berliner Str", City: "Cologne"}
+	a.Albums = []fieldsAlbum{{Title: "Chelsea Girl", Year: 1967}, {Title: "The Marble Index", Year: 1968}}
+	a.Secret = "hidden"
+	a.Extra = map[string]string{"label": "Verve", "producer": "Tom Wilson"}
+	a.internal = "internal"
+
+	return a
+}
+
+func selectJSON(t *testing.T, v interface{}, fields string) string {
+
+	s, err := SelectFields(v, ParseFieldSelection(fields))
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	b, err := json.Marshal(s)
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	return string(b)
+}
+
+func TestParseFieldSelection(t *testing.T) {
+
+	fs := ParseFieldSelection("name, address.city,albums.title,albums, ,address.street")
+
+	test.ExpectInt(t, len(fs), 3)
+	test.ExpectBool(t, fs["name"] == nil, true)
+	test.ExpectBool(t, fs["albums"] == nil, true)
+	test.ExpectInt(t, len(fs["address"]), 2)
+
+	test.ExpectInt(t, len(ParseFieldSelection("")), 0)
+}
+
+func TestSelectFieldsJSON(t *testing.T) {
+
+	a := newFieldsArtist()
+
+	test.ExpectString(t, selectJSON(t, a, "name,id"), `{"id":12,"name":"Nico"}`)
+	test.ExpectString(t, selectJSON(t, a, "address.city,Name"), `{"name":"Nico","address":{"city":"Cologne"}}`)
+	test.ExpectString(t, selectJSON(t, a, "albums.title"), `{"albums":[{"title":"Chelsea Girl"},{"title":"The Marble Index"}]}`)
+	test.ExpectString(t, selectJSON(t, a, "address,address.city"), `{"address":{"street":"Berliner Str","city":"Cologne"}}`)
+	test.ExpectString(t, selectJSON(t, a, "createdBy,genre"), `{"createdBy":"admin","genre":"Rock"}`)
+	test.ExpectString(t, selectJSON(t, a, "extra.label"), `{"extra":{"label":"Verve"}}`)
+
+	a.Address = nil
+	test.ExpectString(t, selectJSON(t, a, "address.city"), `{"address":null}`)
+
+	test.ExpectString(t, selectJSON(t, []*fieldsArtist{a, a}, "id"), `[{"id":12},{"id":12}]`)
+
+	test.ExpectString(t, selectJSON(t, map[string]interface{}{"a": 1, "b": fieldsAlbum{Title: "X"}}, "b.title"), `{"b":{"title":"X"}}`)
+
+	// The original is not modified
+	test.ExpectString(t, a.Name, "Nico")
+	test.ExpectString(t, a.Secret, "hidden")
+}
+
+type fieldsBase struct {
+	ID    int
+	Name  string
+	Label string `json:"label"`
+	Year  int
+	Title string `json:"subtitle"`
+}
+
+type fieldsOther struct {
+	Year  int
+	Label string
+}
+
+type fieldsRecord struct {
+	fieldsBase
+	*fieldsOther
+	Name  string
+	Title string `json:"Label"`
+}
+
+func TestSelectShadowedFields(t *testing.T) {
+
+	r := &fieldsRecord{fieldsBase: fieldsBase{ID: 1, Name: "inner", Label: "Verve", Year: 1967, Title: "Deluxe"}, fieldsOther: &fieldsOther{Year: 1968, Label: "MGM"}, Name: "outer", Title: "Chelsea Girl"}
+
+	// Select every field to compare with encoding/json's own choice of fields
+	expected, _ := json.Marshal(r)
+	test.ExpectString(t, selectJSON(t, r, "ID,Name,label,subtitle,Label"), string(expected))
+
+	test.ExpectString(t, selectJSON(t, r, "Name"), `{"Name":"outer"}`)
+	test.ExpectString(t, selectJSON(t, r, "label,Label"), `{"label":"Verve","Label":"Chelsea Girl"}`)
+	test.ExpectString(t, selectJSON(t, r, "Title"), `{"Label":"Chelsea Girl"}`)
+	test.ExpectString(t, selectJSON(t, r, "subtitle,Label"), `{"subtitle":"Deluxe","Label":"Chelsea Girl"}`)
+
+	// Fields that are ambiguous at the same depth are not serialised
+	_, err := SelectFields(r, ParseFieldSelection("Year"))
+
+	if _, unknown := err.(*UnknownFieldError); !unknown {
+		t.Fatalf("Expected an UnknownFieldError selecting an ambiguous field")
+	}
+}
+
+func TestSelectFieldsXML(t *testing.T) {
+
+	s, err := SelectFields(newFieldsArtist(), ParseFieldSelection("id,albums.year"))
+	test.ExpectNil(t, err)
+
+	b, err := xml.Marshal(s)
+	test.ExpectNil(t, err)
+
+	test.ExpectString(t, string(b), `<artist id="12"><albums><album><year>1967</year></album><album><year>1968</year></album></albums></artist>`)
+}
+
+func TestSelectUnknownFields(t *testing.T) {
+
+	a := newFieldsArtist()
+
+	for _, fields := range []string{"unknown", "address.country", "secret", "Secret", "internal", "name.first", "genre.value", "albums..title", "extra.unknown"} {
+
+		_, err := SelectFields(a, ParseFieldSelection(fields))
+
+		if _, unknown := err.(*UnknownFieldError); !unknown {
+			t.Fatalf("Expected an UnknownFieldError selecting %s", fields)
+		}
+	}
+
+	_, err := SelectFields(a, ParseFieldSelection("address.country"))
+	test.ExpectString(t, err.Error(), "no field named address.country exists")
+
+	v, err := SelectFields(nil, ParseFieldSelection("name"))
+	test.ExpectNil(t, err)
+	test.ExpectNil(t, v)
+}
+
+func TestCheckFieldSelection(t *testing.T) {
+
+	at := reflect.TypeOf(newFieldsArtist())
+
+	for _, fields := range []string{"name,albums.title", "address.city", "createdBy", "extra.unknown", "genre"} {
+		if err := CheckFieldSelection(at, ParseFieldSelection(fields)); err != nil {
+			t.Fatalf("Unexpected error checking %s: %s", fields, err.Error())
+		}
+	}
+
+	for _, fields := range []string{"unknown", "address.country", "Secret", "internal", "name.first", "genre.value", "albums.label"} {
+
+		err := CheckFieldSelection(at, ParseFieldSelection(fields))
+
+		if _, unknown := err.(*UnknownFieldError); !unknown {
+			t.Fatalf("Expected an UnknownFieldError checking %s", fields)
+		}
+	}
+
+	// The fields of embedded pointers are known from the type, even if the pointer is nil
+	rt := reflect.TypeOf(new(fieldsRecord))
+	test.ExpectNil(t, CheckFieldSelection(rt, ParseFieldSelection("Name,label,subtitle,Label")))
+
+	_, unknown := CheckFieldSelection(rt, ParseFieldSelection("Year")).(*UnknownFieldError)
+	test.ExpectBool(t, unknown, true)
+
+	test.ExpectNil(t, CheckFieldSelection(nil, ParseFieldSelection("name")))
+}
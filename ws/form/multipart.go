@@ -0,0 +1,258 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+/*
+Package form provides Unmarshallers for HTTP requests containing HTML form data.
+
+The components in this package are registered by the JSONWs and XMLWs facilities and can be used instead of a facility's
+default Unmarshaller by setting the Unmarshaller field on a handler.WsHandler:
+
+	"uploadHandler": {
+	  "type": "handler.WsHandler",
+	  "HTTPMethod": "POST",
+	  "Logic": "ref:uploadLogic",
+	  "PathPattern": "^/upload$",
+	  "Unmarshaller": "ref:grncMultipartUnmarshaller"
+	}
+
+URLEncodedUnmarshaller (grncFormUnmarshaller) supports application/x-www-form-urlencoded requests and MultipartUnmarshaller
+(grncMultipartUnmarshaller) supports multipart/form-data requests.
+
+Form fields are bound into fields of the same name on the request's target object using the same rules (and supporting the
+same types) as query parameter binding. Files uploaded as part of a multipart request are bound into fields of type
+*types.UploadedFile or []*types.UploadedFile.
+*/
+package form
+
+import (
+	"context"
+	"errors"
+	"github.com/graniticio/granitic/v2/logging"
+	rt "github.com/graniticio/granitic/v2/reflecttools"
+	"github.com/graniticio/granitic/v2/types"
+	"github.com/graniticio/granitic/v2/ws"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"reflect"
+)
+
+const defaultMaxMemoryBytes = 10 << 20
+
+var (
+	fileType      = reflect.TypeOf((*types.UploadedFile)(nil))
+	fileSliceType = reflect.TypeOf([]*types.UploadedFile{})
+)
+
+// MultipartUnmarshaller parses multipart/form-data requests, binding form fields and uploaded files into the request's
+// target object.
+type MultipartUnmarshaller struct {
+	// Injected by Granitic
+	FrameworkLogger logging.Logger
+
+	// Source of service errors for files that exceed the configured limits.
+	FrameworkErrors *ws.FrameworkErrorGenerator
+
+	// Binds form fields into the target object.
+	ParamBinder *ws.ParamBinder
+
+	// The maximum number of bytes of uploaded files that will be held in memory. Files beyond this limit are written to
+	// temporary files, which are removed by the handler after the request has been processed.
+	MaxMemoryBytes int64
+
+	// The maximum size in bytes of any individual uploaded file. Zero means no limit.
+	MaxFileBytes int64
+
+	// The maximum number of files that may be uploaded in a single request. Zero means no limit.
+	MaxFiles int
+}
+
+// Unmarshall parses the multipart form in the request's body. Any problems binding fields or files into the target object are recorded
+// as framework errors on the supplied ws.Request.
+//
+// The limits on the number and size of uploaded files are checked as the body is read, so parsing stops as soon as a limit
+// is exceeded. The parsed form is stored as the request's MultipartForm so that any temporary files can be removed once
+// the request has been processed.
+func (mu *MultipartUnmarshaller) Unmarshall(ctx context.Context, req *http.Request, wsReq *ws.Request) error {
+
+	mm := mu.MaxMemoryBytes
+
+	if mm <= 0 {
+		mm = defaultMaxMemoryBytes
+	}
+
+	mr, err := req.MultipartReader()
+
+	if err != nil {
+		return err
+	}
+
+	// Parts that are within the limits are copied to a standard multipart reader, which holds the files in memory or
+	// in temporary files
+	pr, pw := io.Pipe()
+	fw := multipart.NewWriter(pw)
+
+	parsed := make(chan parsedForm, 1)
+
+	go func() {
+		mf, err := multipart.NewReader(pr, fw.Boundary()).ReadForm(mm)
+
+		pr.CloseWithError(err)
+		parsed <- parsedForm{mf, err}
+	}()
+
+	exceeded, err := mu.copyParts(mr, fw)
+
+	if err == nil && exceeded == nil {
+		err = fw.Close()
+	}
+
+	if err != nil {
+		pw.CloseWithError(err)
+	} else if exceeded != nil {
+		pw.CloseWithError(errLimitExceeded)
+	} else {
+		pw.Close()
+	}
+
+	pf := <-parsed
+
+	if exceeded != nil {
+		wsReq.AddFrameworkError(exceeded)
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if pf.err != nil {
+		return pf.err
+	}
+
+	mf := pf.form
+	req.MultipartForm = mf
+
+	mu.ParamBinder.BindFormParameters(wsReq, ws.NewParamsForQuery(url.Values(mf.Value)))
+	mu.bindFiles(wsReq, mf.File)
+
+	return nil
+}
+
+var errLimitExceeded = errors.New("multipart request exceeds the limits on uploaded files")
+
+type parsedForm struct {
+	form *multipart.Form
+	err  error
+}
+
+// copyParts copies each part of the request's body to the supplied writer, stopping as soon as the number or size of
+// uploaded files exceeds the configured limits. A framework error is returned if a limit is exceeded.
+func (mu *MultipartUnmarshaller) copyParts(mr *multipart.Reader, fw *multipart.Writer) (*ws.FrameworkError, error) {
+
+	files := 0
+
+	for {
+
+		p, err := mr.NextPart()
+
+		if err == io.EOF {
+			return nil, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		file := p.FileName() != ""
+
+		if file {
+			files++
+
+			if mu.MaxFiles > 0 && files > mu.MaxFiles {
+				m, c := mu.FrameworkErrors.MessageCode(ws.TooManyFiles, mu.MaxFiles)
+
+				return mu.tooLargeError(m, c, ""), nil
+			}
+		}
+
+		w, err := fw.CreatePart(p.Header)
+
+		if err != nil {
+			return nil, err
+		}
+
+		var r io.Reader = p
+
+		limited := file && mu.MaxFileBytes > 0
+
+		if limited {
+			// Read one byte more than the limit to detect files that are too large
+			r = io.LimitReader(p, mu.MaxFileBytes+1)
+		}
+
+		n, err := io.Copy(w, r)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if limited && n > mu.MaxFileBytes {
+			m, c := mu.FrameworkErrors.MessageCode(ws.FileTooLarge, p.FileName(), mu.MaxFileBytes)
+
+			return mu.tooLargeError(m, c, p.FormName()), nil
+		}
+	}
+}
+
+func (mu *MultipartUnmarshaller) tooLargeError(message, code, field string) *ws.FrameworkError {
+
+	f := ws.NewUnmarshallFrameworkError(message, code)
+	f.ClientField = field
+	f.HTTPStatus = http.StatusRequestEntityTooLarge
+
+	return f
+}
+
+func (mu *MultipartUnmarshaller) bindFiles(wsReq *ws.Request, files map[string][]*multipart.FileHeader) {
+
+	t := wsReq.RequestBody
+
+	for field, fhs := range files {
+
+		if !rt.HasFieldOfName(t, field) {
+			continue
+		}
+
+		fv := rt.FieldValue(t, field)
+
+		switch fv.Type() {
+		case fileType:
+
+			if len(fhs) > 1 {
+				m, c := mu.FrameworkErrors.MessageCode(ws.FormTargetNotArray, field)
+				wsReq.AddFrameworkError(ws.NewQueryBindFrameworkError(m, c, field, field))
+				continue
+			}
+
+			fv.Set(reflect.ValueOf(types.NewUploadedFile(field, fhs[0])))
+
+		case fileSliceType:
+
+			uf := make([]*types.UploadedFile, len(fhs))
+
+			for i, fh := range fhs {
+				uf[i] = types.NewUploadedFile(field, fh)
+			}
+
+			fv.Set(reflect.ValueOf(uf))
+
+		default:
+			mu.FrameworkLogger.LogErrorf("Field %s is not a valid type for binding an uploaded file into - make sure it is a *types.UploadedFile or []*types.UploadedFile", field)
+			continue
+		}
+
+		wsReq.RecordFieldAsBound(field)
+	}
+}
@@ -0,0 +1,264 @@
+package form
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"github.com/graniticio/granitic/v2/types"
+	"github.com/graniticio/granitic/v2/ws"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"testing"
+)
+
+type uploadTarget struct {
+	Title       string
+	Count       int
+	Tag         *types.NilableString
+	Avatar      *types.UploadedFile
+	Attachments []*types.UploadedFile
+}
+
+type upload struct {
+	field       string
+	name        string
+	contentType string
+	content     string
+}
+
+func multipartRequest(t *testing.T, values map[string]string, files []upload) *http.Request {
+
+	b := new(bytes.Buffer)
+	w := multipart.NewWriter(b)
+
+	for k, v := range values {
+		w.WriteField(k, v)
+	}
+
+	for _, f := range files {
+
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Disposition", `form-data; name="`+f.field+`"; filename="`+f.name+`"`)
+		h.Set("Content-Type", f.contentType)
+
+		pw, err := w.CreatePart(h)
+
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		pw.Write([]byte(f.content))
+	}
+
+	w.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", b)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+
+	return req
+}
+
+func multipartUnmarshaller() *MultipartUnmarshaller {
+
+	fl := new(logging.ConsoleErrorLogger)
+
+	feg := new(ws.FrameworkErrorGenerator)
+	feg.FrameworkLogger = fl
+	feg.Messages = map[ws.FrameworkErrorEvent][]string{
+		ws.FormWrongType:      {"QUERYBIND", "Unable to convert the value of form field %s to type %s. Value provided was %s"},
+		ws.FormTargetNotArray: {"QUERYBIND", "Multiple values for form field %s. Only one value supported"},
+		ws.TooManyFiles:       {"TOOLARGE", "The request contains more than %d files."},
+		ws.FileTooLarge:       {"TOOLARGE", "The file %s is larger than the maximum permitted size of %d bytes."},
+	}
+
+	pb := new(ws.ParamBinder)
+	pb.FrameworkLogger = fl
+	pb.FrameworkErrors = feg
+
+	mu := new(MultipartUnmarshaller)
+	mu.FrameworkLogger = fl
+	mu.FrameworkErrors = feg
+	mu.ParamBinder = pb
+
+	return mu
+}
+
+func TestMultipartBinding(t *testing.T) {
+
+	req := multipartRequest(t, map[string]string{"Title": "Holiday", "Count": "2"}, []upload{
+		{"Avatar", "me.png", "image/png", "PNGDATA"},
+		{"Attachments", "a.txt", "text/plain", "first"},
+		{"Attachments", "b.txt", "text/plain", "second"},
+	})
+
+	tar := new(uploadTarget)
+	wsReq := &ws.Request{RequestBody: tar}
+
+	if err := multipartUnmarshaller().Unmarshall(context.Background(), req, wsReq); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	test.ExpectInt(t, len(wsReq.FrameworkErrors), 0)
+	test.ExpectBool(t, req.MultipartForm != nil, true)
+	test.ExpectString(t, tar.Title, "Holiday")
+	test.ExpectInt(t, tar.Count, 2)
+	test.ExpectBool(t, tar.Tag != nil && !tar.Tag.IsSet(), true)
+	test.ExpectBool(t, wsReq.WasFieldBound("Avatar"), true)
+
+	a := tar.Avatar
+
+	test.ExpectString(t, a.FieldName, "Avatar")
+	test.ExpectString(t, a.FileName, "me.png")
+	test.ExpectString(t, a.MediaType(), "image/png")
+	test.ExpectInt(t, int(a.Size), 7)
+
+	f, err := a.Open()
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	defer f.Close()
+
+	b, _ := ioutil.ReadAll(f)
+	test.ExpectString(t, string(b), "PNGDATA")
+
+	test.ExpectInt(t, len(tar.Attachments), 2)
+	test.ExpectString(t, tar.Attachments[1].FileName, "b.txt")
+}
+
+func TestMultipartBindingErrors(t *testing.T) {
+
+	req := multipartRequest(t, map[string]string{"Count": "many"}, []upload{
+		{"Avatar", "1.png", "image/png", "1"},
+		{"Avatar", "2.png", "image/png", "2"},
+	})
+
+	tar := new(uploadTarget)
+	wsReq := &ws.Request{RequestBody: tar}
+
+	if err := multipartUnmarshaller().Unmarshall(context.Background(), req, wsReq); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	fe := wsReq.FrameworkErrors
+
+	test.ExpectInt(t, len(fe), 2)
+
+	for _, e := range fe {
+		test.ExpectString(t, e.Code, "QUERYBIND")
+		test.ExpectInt(t, int(e.Phase), ws.QueryBind)
+	}
+
+	test.ExpectBool(t, tar.Avatar == nil, true)
+}
+
+func TestMultipartLimits(t *testing.T) {
+
+	files := []upload{
+		{"Attachments", "a.txt", "text/plain", "small"},
+		{"Attachments", "b.txt", "text/plain", "much larger"},
+	}
+
+	mu := multipartUnmarshaller()
+	mu.MaxFiles = 1
+
+	wsReq := &ws.Request{RequestBody: new(uploadTarget)}
+
+	if err := mu.Unmarshall(context.Background(), multipartRequest(t, nil, files), wsReq); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	test.ExpectInt(t, len(wsReq.FrameworkErrors), 1)
+	test.ExpectString(t, wsReq.FrameworkErrors[0].Message, "The request contains more than 1 files.")
+	test.ExpectInt(t, wsReq.FrameworkErrors[0].HTTPStatus, http.StatusRequestEntityTooLarge)
+
+	mu = multipartUnmarshaller()
+	mu.MaxFileBytes = 8
+
+	tar := new(uploadTarget)
+	wsReq = &ws.Request{RequestBody: tar}
+
+	if err := mu.Unmarshall(context.Background(), multipartRequest(t, nil, files), wsReq); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	test.ExpectInt(t, len(wsReq.FrameworkErrors), 1)
+	test.ExpectString(t, wsReq.FrameworkErrors[0].ClientField, "Attachments")
+	test.ExpectInt(t, wsReq.FrameworkErrors[0].HTTPStatus, http.StatusRequestEntityTooLarge)
+	test.ExpectInt(t, len(tar.Attachments), 0)
+}
+
+type countingReader struct {
+	r    io.Reader
+	read int
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.read += n
+
+	return n, err
+}
+
+func (cr *countingReader) Close() error {
+	return nil
+}
+
+func TestMultipartLimitsStopReading(t *testing.T) {
+
+	large := string(make([]byte, 1<<20))
+
+	check := func(mu *MultipartUnmarshaller, files []upload) {
+
+		req := multipartRequest(t, nil, files)
+		cr := &countingReader{r: req.Body}
+		req.Body = cr
+
+		wsReq := &ws.Request{RequestBody: new(uploadTarget)}
+
+		if err := mu.Unmarshall(context.Background(), req, wsReq); err != nil {
+			t.Fatal(err.Error())
+		}
+
+		test.ExpectInt(t, len(wsReq.FrameworkErrors), 1)
+		test.ExpectBool(t, cr.read < len(large), true)
+	}
+
+	mu := multipartUnmarshaller()
+	mu.MaxFiles = 1
+
+	check(mu, []upload{{"Attachments", "a.txt", "text/plain", "small"}, {"Attachments", "b.txt", "text/plain", large}})
+
+	mu = multipartUnmarshaller()
+	mu.MaxFileBytes = 1024
+
+	check(mu, []upload{{"Avatar", "big.png", "image/png", large}})
+}
+
+func TestMultipartInvalidRequests(t *testing.T) {
+
+	mu := multipartUnmarshaller()
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", bytes.NewBufferString(`{"Title":"x"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	if err := mu.Unmarshall(context.Background(), req, &ws.Request{RequestBody: new(uploadTarget)}); err == nil {
+		t.Errorf("Expected a non-multipart request to be rejected")
+	}
+
+	req = multipartRequest(t, nil, []upload{{"Avatar", "big.png", "image/png", string(make([]byte, 2048))}})
+	req.Body = http.MaxBytesReader(httptest.NewRecorder(), req.Body, 1024)
+
+	err := mu.Unmarshall(context.Background(), req, &ws.Request{RequestBody: new(uploadTarget)})
+
+	var tooLarge *http.MaxBytesError
+
+	test.ExpectBool(t, errors.As(err, &tooLarge), true)
+}
@@ -0,0 +1,45 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package form
+
+import (
+	"context"
+	"fmt"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/ws"
+	"mime"
+	"net/http"
+)
+
+const urlEncodedContentType = "application/x-www-form-urlencoded"
+
+// URLEncodedUnmarshaller parses application/x-www-form-urlencoded requests (normally submitted by HTML forms), binding
+// the form's fields into the request's target object.
+type URLEncodedUnmarshaller struct {
+	// Injected by Granitic
+	FrameworkLogger logging.Logger
+
+	// Binds form fields into the target object.
+	ParamBinder *ws.ParamBinder
+}
+
+// Unmarshall parses the form in the request's body. Problems converting the value of a field to the type of the
+// corresponding field on the target object are recorded as framework errors on the supplied ws.Request.
+func (uu *URLEncodedUnmarshaller) Unmarshall(ctx context.Context, req *http.Request, wsReq *ws.Request) error {
+	defer req.Body.Close()
+
+	ct := req.Header.Get("Content-Type")
+
+	if mt, _, err := mime.ParseMediaType(ct); err != nil || mt != urlEncodedContentType {
+		return fmt.Errorf("expected a request with content type %s, but content type was %s", urlEncodedContentType, ct)
+	}
+
+	if err := req.ParseForm(); err != nil {
+		return err
+	}
+
+	uu.ParamBinder.BindFormParameters(wsReq, ws.NewParamsForQuery(req.PostForm))
+
+	return nil
+}
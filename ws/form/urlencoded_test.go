@@ -0,0 +1,91 @@
+package form
+
+import (
+	"context"
+	"github.com/graniticio/granitic/v2/test"
+	"github.com/graniticio/granitic/v2/types"
+	"github.com/graniticio/granitic/v2/ws"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type formTarget struct {
+	Name   string
+	Age    *types.NilableInt64
+	Active *types.NilableBool
+	Score  *types.NilableFloat64
+	Note   *types.NilableString
+	Tags   []string
+	IDs    []int64
+}
+
+func formRequest(contentType, body string) *http.Request {
+
+	req := httptest.NewRequest(http.MethodPost, "/form?Name=fromQuery", strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+
+	return req
+}
+
+func formUnmarshaller() *URLEncodedUnmarshaller {
+
+	uu := new(URLEncodedUnmarshaller)
+	uu.ParamBinder = multipartUnmarshaller().ParamBinder
+
+	return uu
+}
+
+func TestURLEncodedBinding(t *testing.T) {
+
+	req := formRequest("application/x-www-form-urlencoded; charset=UTF-8", "Name=Jane+Doe&Age=42&Active=true&Score=9.5&Tags=a,b,c&IDs=1,2")
+
+	tar := new(formTarget)
+	wsReq := &ws.Request{RequestBody: tar}
+
+	if err := formUnmarshaller().Unmarshall(context.Background(), req, wsReq); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	test.ExpectInt(t, len(wsReq.FrameworkErrors), 0)
+	test.ExpectString(t, tar.Name, "Jane Doe")
+	test.ExpectInt(t, int(tar.Age.Int64()), 42)
+	test.ExpectBool(t, tar.Active.Bool(), true)
+	test.ExpectBool(t, tar.Score.Float64() == 9.5, true)
+	test.ExpectBool(t, tar.Note.IsSet(), false)
+	test.ExpectInt(t, len(tar.Tags), 3)
+	test.ExpectInt(t, int(tar.IDs[1]), 2)
+	test.ExpectBool(t, wsReq.WasFieldBound("Age"), true)
+	test.ExpectBool(t, wsReq.WasFieldBound("Note"), false)
+}
+
+func TestURLEncodedBindingErrors(t *testing.T) {
+
+	req := formRequest("application/x-www-form-urlencoded", "Age=old&Name=a&Name=b")
+
+	wsReq := &ws.Request{RequestBody: new(formTarget)}
+
+	if err := formUnmarshaller().Unmarshall(context.Background(), req, wsReq); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	fe := wsReq.FrameworkErrors
+
+	test.ExpectInt(t, len(fe), 2)
+
+	for _, e := range fe {
+		test.ExpectString(t, e.Code, "QUERYBIND")
+		test.ExpectInt(t, int(e.Phase), ws.QueryBind)
+
+		if e.ClientField == "Age" {
+			test.ExpectString(t, e.Message, "Unable to convert the value of form field Age to type int64. Value provided was old")
+		}
+	}
+
+	req = formRequest("application/json", `{"Name":"x"}`)
+
+	if err := formUnmarshaller().Unmarshall(context.Background(), req, &ws.Request{RequestBody: new(formTarget)}); err == nil {
+		t.Errorf("Expected a request that is not URL encoded to be rejected")
+	}
+}
@@ -16,11 +16,14 @@ const (
 	// Unmarshall indicates an error was encountered while trying to parse an HTTP request body into a struct
 	Unmarshall = iota
 
-	// QueryBind indicates an error was encountered while mapping HTTP query parameters to fields on a struct
+	// QueryBind indicates an error was encountered while mapping HTTP query parameters or form fields to fields on a struct
 	QueryBind
 
 	//PathBind indicates an error was encountered while mapping elements of an HTTP request's path to fields on a struct
 	PathBind
+
+	// HeaderBind indicates an error was encountered while mapping HTTP request headers or cookies to fields on a struct
+	HeaderBind
 )
 
 // FrameworkError an error encountered in early phases of request processing, before application code is invoked.
@@ -45,6 +48,10 @@ type FrameworkError struct {
 
 	// A system generated code for the error.
 	Code string
+
+	// The HTTP status that should be sent to the caller if this error causes request processing to stop. Zero means
+	// 400 (Bad Request).
+	HTTPStatus int
 }
 
 // RecordField implements FieldAssociatedError
@@ -93,6 +100,19 @@ func NewPathBindFrameworkError(message, code, target string) *FrameworkError {
 	return f
 }
 
+// NewHeaderBindFrameworkError creates a FrameworkError with fields set appropriate for an error
+// encountered during mapping of HTTP request headers or cookies to fields on a Request's Body
+func NewHeaderBindFrameworkError(message, code, name, target string) *FrameworkError {
+	f := new(FrameworkError)
+	f.Phase = HeaderBind
+	f.Message = message
+	f.ClientField = name
+	f.TargetField = target
+	f.Code = code
+
+	return f
+}
+
 // FrameworkErrorEvent uniquely identifies a 'handled' failure during the parsing and binding phases
 type FrameworkErrorEvent string
 
@@ -110,6 +130,58 @@ const (
 
 	// QueryNoTargetField indicates that no field on the target can be matched to the a named query parameter
 	QueryNoTargetField = "QueryNoTargetField"
+
+	// FormTargetNotArray indicates that a form field with multiple values has been bound to a target field that is not an array
+	FormTargetNotArray = "FormTargetNotArray"
+
+	// FormWrongType indicates that the value of a form field is not compatible with the type of field to which it is bound
+	FormWrongType = "FormWrongType"
+
+	// HeaderTargetNotArray indicates that a request header with multiple values has been bound to a target field that is not an array
+	HeaderTargetNotArray = "HeaderTargetNotArray"
+
+	// HeaderWrongType indicates that the value of a request header is not compatible with the type of field to which it is bound
+	HeaderWrongType = "HeaderWrongType"
+
+	// HeaderNoTargetField indicates that no field on the target can be matched to a named request header
+	HeaderNoTargetField = "HeaderNoTargetField"
+
+	// CookieTargetNotArray indicates that a cookie sent more than once has been bound to a target field that is not an array
+	CookieTargetNotArray = "CookieTargetNotArray"
+
+	// CookieWrongType indicates that the value of a cookie is not compatible with the type of field to which it is bound
+	CookieWrongType = "CookieWrongType"
+
+	// CookieNoTargetField indicates that no field on the target can be matched to a named cookie
+	CookieNoTargetField = "CookieNoTargetField"
+
+	// TooManyFiles indicates that a multipart request contains more uploaded files than are allowed
+	TooManyFiles = "TooManyFiles"
+
+	// FileTooLarge indicates that a file uploaded in a multipart request is larger than the maximum size allowed
+	FileTooLarge = "FileTooLarge"
+
+	// UnsupportedContentType indicates that no Unmarshaller is available for the content type of an HTTP request's body
+	UnsupportedContentType = "UnsupportedContentType"
+
+	// RequestTooLarge indicates that the HTTP request's body is larger than the maximum size allowed
+	RequestTooLarge = "RequestTooLarge"
+
+	// IdempotencyKeyReused indicates that an Idempotency-Key has already been used for a request with a different payload
+	IdempotencyKeyReused = "IdempotencyKeyReused"
+
+	// IdempotencyKeyInProgress indicates that a request with the same Idempotency-Key is still being processed
+	IdempotencyKeyInProgress = "IdempotencyKeyInProgress"
+
+	// UnknownResponseField indicates that a sparse fieldset requested a field that does not exist in the response
+	UnknownResponseField = "UnknownResponseField"
+
+	// PageNumberInvalid indicates that the page number requested by a client is not a whole number greater than zero, or is
+	// too large for the offset of the page to be calculated
+	PageNumberInvalid = "PageNumberInvalid"
+
+	// PageSizeInvalid indicates that the page size requested by a client is not a whole number between one and the maximum allowed
+	PageSizeInvalid = "PageSizeInvalid"
 )
 
 // A FrameworkErrorGenerator can create error messages for errors that occur outside of application code and messages
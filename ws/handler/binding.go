@@ -0,0 +1,123 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package handler
+
+import (
+	"context"
+	"fmt"
+	"github.com/graniticio/granitic/v2/ws"
+	"net/http"
+	"strconv"
+)
+
+// processHeaders binds request headers and cookies into the request target according to FieldHeader and FieldCookie
+func (wh *WsHandler) processHeaders(ctx context.Context, req *http.Request, wsReq *ws.Request) {
+
+	if len(wh.FieldHeader) == 0 && len(wh.FieldCookie) == 0 {
+		return
+	}
+
+	if wsReq.RequestBody == nil {
+		wh.Log.LogErrorfCtx(ctx, "Header or cookie binding is enabled, but no target available to bind into. Does your Logic component implement the WsUnmarshallTarget interface?")
+		return
+	}
+
+	if len(wh.FieldHeader) > 0 {
+		wh.ParamBinder.BindHeaders(wsReq, req.Header, wh.FieldHeader)
+	}
+
+	if len(wh.FieldCookie) > 0 {
+		wh.ParamBinder.BindCookies(wsReq, req.Cookies(), wh.FieldCookie)
+	}
+}
+
+// addTaggedBindings adds fields on the request target with ws struct tags to FieldQueryParam, FieldHeader and
+// FieldCookie. Bindings explicitly set in configuration take precedence over tags. Fields tagged with a path parameter
+// are returned, as they can only be resolved once the handler's path has been parsed.
+func (wh *WsHandler) addTaggedBindings() (map[string]string, error) {
+
+	bindings, err := ws.TaggedBindings(wh.RequestTarget())
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to bind request target fields for %s: %s", wh.ComponentName(), err.Error())
+	}
+
+	wh.FieldQueryParam = mergeBindings(wh.FieldQueryParam, bindings[ws.QuerySource])
+	wh.FieldHeader = mergeBindings(wh.FieldHeader, bindings[ws.HeaderSource])
+	wh.FieldCookie = mergeBindings(wh.FieldCookie, bindings[ws.CookieSource])
+
+	return bindings[ws.PathSource], nil
+}
+
+// addTaggedPathParams sets the entries in BindPathParams for fields tagged with the name (or index) of a path parameter.
+// Tags override the default binding of path template parameters to fields with the same name.
+func (wh *WsHandler) addTaggedPathParams(tagged map[string]string) error {
+
+	if len(tagged) == 0 {
+		return nil
+	}
+
+	var names []string
+
+	if pt := wh.ParsedPathTemplate(); pt != nil {
+		names = pt.Params
+	} else {
+		names = wh.pathRegex.SubexpNames()[1:]
+	}
+
+	fields := make([]string, len(names))
+	copy(fields, wh.BindPathParams)
+
+	for field, name := range tagged {
+
+		i := pathParamIndex(names, name)
+
+		if i < 0 {
+			return fmt.Errorf("field %s on the request target for %s is bound to the path parameter %s, but the handler's path has no such parameter",
+				field, wh.ComponentName(), name)
+		}
+
+		fields[i] = field
+	}
+
+	wh.BindPathParams = fields
+
+	return nil
+}
+
+// pathParamIndex finds the position of the path parameter with the supplied name, or if no parameter has that name,
+// treats the name as the index of a parameter. Returns -1 if no parameter matches.
+func pathParamIndex(names []string, name string) int {
+
+	for i, n := range names {
+		if n == name {
+			return i
+		}
+	}
+
+	if i, err := strconv.Atoi(name); err == nil && i >= 0 && i < len(names) {
+		return i
+	}
+
+	return -1
+}
+
+func mergeBindings(configured map[string]string, tagged map[string]string) map[string]string {
+
+	if len(tagged) == 0 {
+		return configured
+	}
+
+	merged := make(map[string]string)
+
+	for field, name := range tagged {
+		merged[field] = name
+	}
+
+	for field, name := range configured {
+		merged[field] = name
+	}
+
+	return merged
+}
@@ -0,0 +1,152 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package handler
+
+import (
+	"context"
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"github.com/graniticio/granitic/v2/ws"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type tenantRequest struct {
+	Tenant  string `ws:"header=X-Tenant"`
+	Session string `ws:"cookie=session"`
+	Trace   string
+}
+
+type tenantLogic struct {
+	received *tenantRequest
+}
+
+func (tl *tenantLogic) Process(ctx context.Context, request *ws.Request, response *ws.Response) {
+	tl.received = request.RequestBody.(*tenantRequest)
+}
+
+func (tl *tenantLogic) UnmarshallTarget() interface{} {
+	return new(tenantRequest)
+}
+
+func TestHeaderAndCookieBinding(t *testing.T) {
+
+	l := new(tenantLogic)
+
+	h := new(WsHandler)
+	h.PathPattern = "/tenant$"
+	h.HTTPMethod = http.MethodGet
+	h.Logic = l
+	h.Log = new(logging.ConsoleErrorLogger)
+	h.ResponseWriter = jsonResponseWriter()
+	h.FieldHeader = map[string]string{"Trace": "X-Trace"}
+	h.ParamBinder = paramBinder()
+
+	test.ExpectNil(t, h.StartComponent())
+	test.ExpectString(t, h.FieldHeader["Tenant"], "X-Tenant")
+	test.ExpectString(t, h.FieldCookie["Session"], "session")
+
+	req := httptest.NewRequest(http.MethodGet, "/tenant", nil)
+	req.Header.Set("X-Tenant", "acme")
+	req.Header.Set("X-Trace", "t1")
+	req.AddCookie(&http.Cookie{Name: "session", Value: "s1"})
+
+	rec := httptest.NewRecorder()
+
+	h.ServeHTTP(context.Background(), httpendpoint.NewHTTPResponseWriter(rec), req)
+
+	test.ExpectInt(t, rec.Code, http.StatusOK)
+	test.ExpectString(t, l.received.Tenant, "acme")
+	test.ExpectString(t, l.received.Trace, "t1")
+	test.ExpectString(t, l.received.Session, "s1")
+}
+
+type orderRequest struct {
+	CustomerID int64 `ws:"path=id"`
+	OrderID    int64 `ws:"path=1"`
+	Page       int   `ws:"query=page"`
+	Size       int
+}
+
+type orderLogic struct {
+	received *orderRequest
+}
+
+func (ol *orderLogic) ProcessPayload(ctx context.Context, request *ws.Request, response *ws.Response, o *orderRequest) {
+	ol.received = o
+}
+
+func paramBinder() *ws.ParamBinder {
+
+	fl := new(logging.ConsoleErrorLogger)
+
+	return &ws.ParamBinder{FrameworkLogger: fl, FrameworkErrors: &ws.FrameworkErrorGenerator{FrameworkLogger: fl}}
+}
+
+func orderHandler(l *orderLogic) *WsHandler {
+
+	h := new(WsHandler)
+	h.HTTPMethod = http.MethodGet
+	h.Logic = l
+	h.Log = new(logging.ConsoleErrorLogger)
+	h.ResponseWriter = jsonResponseWriter()
+	h.ParamBinder = paramBinder()
+
+	return h
+}
+
+func TestTaggedPathAndQueryBinding(t *testing.T) {
+
+	for _, path := range []string{"/customer/{id:int}/order/{order:int}", ""} {
+
+		l := new(orderLogic)
+
+		h := orderHandler(l)
+		h.FieldQueryParam = map[string]string{"Size": "size"}
+
+		if path == "" {
+			h.PathPattern = "^/customer/(?P<id>\\d+)/order/(\\d+)$"
+		} else {
+			h.PathTemplate = path
+		}
+
+		test.ExpectNil(t, h.StartComponent())
+		test.ExpectInt(t, len(h.BindPathParams), 2)
+		test.ExpectString(t, h.BindPathParams[0], "CustomerID")
+		test.ExpectString(t, h.BindPathParams[1], "OrderID")
+
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/customer/12/order/34?page=2&size=50", nil)
+
+		h.ServeHTTP(context.Background(), httpendpoint.NewHTTPResponseWriter(rec), req)
+
+		test.ExpectInt(t, rec.Code, http.StatusOK)
+		test.ExpectInt(t, int(l.received.CustomerID), 12)
+		test.ExpectInt(t, int(l.received.OrderID), 34)
+		test.ExpectInt(t, l.received.Page, 2)
+		test.ExpectInt(t, l.received.Size, 50)
+	}
+}
+
+func TestConfiguredBindingOverridesTags(t *testing.T) {
+
+	h := orderHandler(new(orderLogic))
+	h.PathPattern = "^/customer/(\\d+)/order/(\\d+)$"
+	h.BindPathParams = []string{"OrderID", "CustomerID"}
+	h.FieldQueryParam = map[string]string{"Page": "p"}
+
+	test.ExpectNil(t, h.StartComponent())
+	test.ExpectString(t, h.BindPathParams[0], "OrderID")
+	test.ExpectString(t, h.FieldQueryParam["Page"], "p")
+}
+
+func TestTaggedUnknownPathParam(t *testing.T) {
+
+	h := orderHandler(new(orderLogic))
+	h.PathTemplate = "/customer/{customer}/order/{order}"
+
+	test.ExpectBool(t, h.StartComponent() == nil, false)
+}
@@ -0,0 +1,284 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/ws"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	etagHeader            = "ETag"
+	lastModifiedHeader    = "Last-Modified"
+	ifMatchHeader         = "If-Match"
+	ifNoneMatchHeader     = "If-None-Match"
+	ifModifiedSinceHeader = "If-Modified-Since"
+	weakPrefix            = "W/"
+)
+
+// WsCurrentETag is implemented by Logic components that are able to supply the entity tag of the current representation
+// of the resource targeted by a request. If the Logic for a PUT, PATCH or DELETE handler implements this interface, requests
+// with an If-Match header are rejected with an HTTP 412 response (before the Logic is invoked) unless the precondition holds.
+type WsCurrentETag interface {
+	// CurrentETag returns the entity tag of the resource targeted by the request (including quotes) and true, or false if
+	// the resource does not exist.
+	CurrentETag(ctx context.Context, request *ws.Request) (string, bool)
+}
+
+// checkPreconditions evaluates any If-Match header on the request, writing an HTTP 412 response and returning false if the
+// precondition fails.
+func (wh *WsHandler) checkPreconditions(ctx context.Context, w *httpendpoint.HTTPResponseWriter, req *http.Request, wsReq *ws.Request) bool {
+
+	ifMatch := req.Header.Get(ifMatchHeader)
+
+	if ifMatch == "" || wh.currentETag == nil {
+		return true
+	}
+
+	switch req.Method {
+	case http.MethodPut, http.MethodPatch, http.MethodDelete:
+	default:
+		return true
+	}
+
+	current, exists := wh.currentETag.CurrentETag(ctx, wsReq)
+
+	if exists && etagListMatches(ifMatch, current, false) {
+		return true
+	}
+
+	state := ws.NewAbnormalState(http.StatusPreconditionFailed, w)
+	state.WsRequest = wsReq
+
+	if err := wh.ResponseWriter.Write(ctx, state, ws.Abnormal); err != nil {
+		wh.Log.LogErrorfCtx(ctx, "Problem writing response: %s", err.Error())
+	}
+
+	return false
+}
+
+// conditionalGet returns true if the response to the supplied request should carry validators (ETag and/or Last-Modified)
+// and be subject to If-None-Match/If-Modified-Since checks.
+func (wh *WsHandler) conditionalGet(req *http.Request, res *ws.Response) bool {
+
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+
+	if res.HTTPStatus != 0 && res.HTTPStatus != http.StatusOK {
+		return false
+	}
+
+	if res.Errors.HasErrors() {
+		return false
+	}
+
+	if _, streamed := res.Body.(*ws.StreamedResponse); streamed {
+		return false
+	}
+
+	return wh.GenerateETag || res.ETag != "" || !res.LastModified.IsZero()
+}
+
+// writeConditionally writes a successful response to a GET or HEAD request, adding validators and replacing the response
+// with an HTTP 304 if the client's cached copy is still valid.
+func (wh *WsHandler) writeConditionally(ctx context.Context, state *ws.ProcessState, req *http.Request) error {
+
+	res := state.WsResponse
+	w := state.HTTPResponseWriter
+
+	etag := res.ETag
+
+	var buffered *bufferedResponse
+
+	if etag == "" && wh.GenerateETag {
+
+		// Marshal the response to memory so that an entity tag can be computed over the body
+		buffered = newBufferedResponse()
+
+		bs := *state
+		bs.HTTPResponseWriter = httpendpoint.NewHTTPResponseWriter(buffered)
+
+		if err := wh.ResponseWriter.Write(ctx, &bs, ws.Normal); err != nil {
+			return err
+		}
+
+		if buffered.status >= 300 {
+			// Response was changed to an error while being written
+			return buffered.replay(w, nil)
+		}
+
+		etag = computeETag(buffered.body.Bytes(), wh.WeakETag)
+	}
+
+	validators := make(map[string]string)
+
+	if etag != "" {
+		validators[etagHeader] = etag
+	}
+
+	if !res.LastModified.IsZero() {
+		validators[lastModifiedHeader] = res.LastModified.UTC().Format(http.TimeFormat)
+	}
+
+	if notModified(req, etag, res.LastModified) {
+
+		ws.WriteHeaders(w, validators)
+
+		for k, v := range res.Headers {
+			w.Header().Set(k, v)
+		}
+
+		if buffered != nil {
+			copyHeaders(w, buffered.header, "Cache-Control", "Expires", "Vary")
+		}
+
+		w.WriteHeader(http.StatusNotModified)
+
+		return nil
+	}
+
+	if buffered != nil {
+		return buffered.replay(w, validators)
+	}
+
+	for k, v := range validators {
+		res.Headers[k] = v
+	}
+
+	return wh.ResponseWriter.Write(ctx, state, ws.Normal)
+}
+
+// notModified evaluates If-None-Match and If-Modified-Since headers (in that order of precedence, as defined in RFC 7232)
+// against the current validators of the resource.
+func notModified(req *http.Request, etag string, lastModified time.Time) bool {
+
+	if inm := req.Header.Get(ifNoneMatchHeader); inm != "" {
+		return etag != "" && etagListMatches(inm, etag, true)
+	}
+
+	ims := req.Header.Get(ifModifiedSinceHeader)
+
+	if ims == "" || lastModified.IsZero() {
+		return false
+	}
+
+	t, err := http.ParseTime(ims)
+
+	if err != nil {
+		return false
+	}
+
+	return !lastModified.Truncate(time.Second).After(t)
+}
+
+// etagListMatches checks the supplied entity tag against a comma separated list of entity tags (or *) from an If-Match or
+// If-None-Match header. Weak comparison ignores any weakness indicators, strong comparison fails if either tag is weak.
+func etagListMatches(list, etag string, weak bool) bool {
+
+	for _, candidate := range strings.Split(list, ",") {
+
+		candidate = strings.TrimSpace(candidate)
+
+		if candidate == "*" {
+			return true
+		}
+
+		if weak {
+			if strings.TrimPrefix(candidate, weakPrefix) == strings.TrimPrefix(etag, weakPrefix) {
+				return true
+			}
+
+			continue
+		}
+
+		if !strings.HasPrefix(candidate, weakPrefix) && !strings.HasPrefix(etag, weakPrefix) && candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+func computeETag(body []byte, weak bool) string {
+
+	sum := sha256.Sum256(body)
+	etag := `"` + base64.RawURLEncoding.EncodeToString(sum[:]) + `"`
+
+	if weak {
+		return weakPrefix + etag
+	}
+
+	return etag
+}
+
+func copyHeaders(w http.ResponseWriter, from http.Header, names ...string) {
+
+	for _, n := range names {
+		for _, v := range from.Values(n) {
+			w.Header().Add(n, v)
+		}
+	}
+}
+
+// bufferedResponse is an http.ResponseWriter that holds a response in memory.
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newBufferedResponse() *bufferedResponse {
+	return &bufferedResponse{header: make(http.Header)}
+}
+
+func (br *bufferedResponse) Header() http.Header {
+	return br.header
+}
+
+func (br *bufferedResponse) Write(b []byte) (int, error) {
+	return br.body.Write(b)
+}
+
+func (br *bufferedResponse) WriteHeader(status int) {
+
+	if br.status == 0 {
+		br.status = status
+	}
+}
+
+// replay writes the buffered response (with any additional headers) to the supplied HTTPResponseWriter.
+func (br *bufferedResponse) replay(w *httpendpoint.HTTPResponseWriter, additional map[string]string) error {
+
+	for k, vs := range br.header {
+		for _, v := range vs {
+			w.Header().Add(k, v)
+		}
+	}
+
+	ws.WriteHeaders(w, additional)
+
+	status := br.status
+
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	w.WriteHeader(status)
+
+	if br.body.Len() == 0 {
+		return nil
+	}
+
+	_, err := w.Write(br.body.Bytes())
+
+	return err
+}
@@ -0,0 +1,162 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package handler
+
+import (
+	"context"
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"github.com/graniticio/granitic/v2/ws"
+	"github.com/graniticio/granitic/v2/ws/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type resourceLogic struct {
+	ETag         string
+	LastModified time.Time
+	Current      string
+	Called       bool
+}
+
+func (rl *resourceLogic) Process(ctx context.Context, request *ws.Request, response *ws.Response) {
+	rl.Called = true
+
+	response.Body = map[string]string{"Name": "Granitic"}
+	response.ETag = rl.ETag
+	response.LastModified = rl.LastModified
+}
+
+func (rl *resourceLogic) CurrentETag(ctx context.Context, request *ws.Request) (string, bool) {
+	return rl.Current, rl.Current != ""
+}
+
+func jsonResponseWriter() *ws.MarshallingResponseWriter {
+
+	rw := new(ws.MarshallingResponseWriter)
+	rw.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	rw.StatusDeterminer = &ws.GraniticHTTPStatusCodeDeterminer{NoError: http.StatusOK}
+	rw.FrameworkErrors = &ws.FrameworkErrorGenerator{HTTPMessages: map[string]string{"412": "Modified", "429": "Too many"}}
+	rw.DefaultHeaders = map[string]string{"Content-Type": "application/json", "Cache-Control": "max-age=60"}
+	rw.ErrorFormatter = new(json.GraniticJSONErrorFormatter)
+	rw.ResponseWrapper = new(json.BodyOrErrorWrapper)
+	rw.MarshalingWriter = new(json.MarshalingWriter)
+
+	return rw
+}
+
+func conditionalHandler(t *testing.T, method string, l *resourceLogic) *WsHandler {
+
+	h := new(WsHandler)
+	h.PathPattern = "/resource$"
+	h.HTTPMethod = method
+	h.Logic = l
+	h.Log = new(logging.ConsoleErrorLogger)
+	h.ResponseWriter = jsonResponseWriter()
+
+	test.ExpectNil(t, h.StartComponent())
+
+	return h
+}
+
+func serve(h *WsHandler, method string, headers map[string]string) *httptest.ResponseRecorder {
+
+	req := httptest.NewRequest(method, "/resource", nil)
+
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	rec := httptest.NewRecorder()
+
+	h.ServeHTTP(context.Background(), httpendpoint.NewHTTPResponseWriter(rec), req)
+
+	return rec
+}
+
+func TestGeneratedETag(t *testing.T) {
+
+	h := conditionalHandler(t, http.MethodGet, new(resourceLogic))
+	h.GenerateETag = true
+
+	rec := serve(h, http.MethodGet, nil)
+
+	etag := rec.Header().Get("ETag")
+
+	test.ExpectInt(t, rec.Code, http.StatusOK)
+	test.ExpectString(t, rec.Body.String(), `{"Name":"Granitic"}`)
+	test.ExpectString(t, rec.Header().Get("Content-Type"), "application/json")
+	test.ExpectBool(t, len(etag) > 2 && etag[0] == '"', true)
+
+	rec = serve(h, http.MethodGet, map[string]string{"If-None-Match": `"other", ` + etag})
+
+	test.ExpectInt(t, rec.Code, http.StatusNotModified)
+	test.ExpectInt(t, rec.Body.Len(), 0)
+	test.ExpectString(t, rec.Header().Get("ETag"), etag)
+	test.ExpectString(t, rec.Header().Get("Cache-Control"), "max-age=60")
+
+	h.WeakETag = true
+
+	rec = serve(h, http.MethodGet, map[string]string{"If-None-Match": etag})
+
+	test.ExpectString(t, rec.Header().Get("ETag"), "W/"+etag)
+	test.ExpectInt(t, rec.Code, http.StatusNotModified)
+}
+
+func TestLogicSuppliedValidators(t *testing.T) {
+
+	modified := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	h := conditionalHandler(t, http.MethodGet, &resourceLogic{ETag: `"v2"`, LastModified: modified})
+
+	rec := serve(h, http.MethodGet, map[string]string{"If-None-Match": `"v1"`})
+
+	test.ExpectInt(t, rec.Code, http.StatusOK)
+	test.ExpectString(t, rec.Header().Get("ETag"), `"v2"`)
+	test.ExpectString(t, rec.Header().Get("Last-Modified"), "Fri, 01 May 2020 12:00:00 GMT")
+
+	rec = serve(h, http.MethodGet, map[string]string{"If-None-Match": `W/"v2"`})
+	test.ExpectInt(t, rec.Code, http.StatusNotModified)
+
+	rec = serve(h, http.MethodGet, map[string]string{"If-Modified-Since": "Fri, 01 May 2020 12:00:00 GMT"})
+	test.ExpectInt(t, rec.Code, http.StatusNotModified)
+
+	rec = serve(h, http.MethodGet, map[string]string{"If-Modified-Since": "Thu, 30 Apr 2020 12:00:00 GMT"})
+	test.ExpectInt(t, rec.Code, http.StatusOK)
+
+	// If-None-Match takes precedence over If-Modified-Since
+	rec = serve(h, http.MethodGet, map[string]string{"If-None-Match": `"v1"`, "If-Modified-Since": "Fri, 01 May 2020 12:00:00 GMT"})
+	test.ExpectInt(t, rec.Code, http.StatusOK)
+}
+
+func TestIfMatchPrecondition(t *testing.T) {
+
+	l := &resourceLogic{Current: `"v2"`}
+	h := conditionalHandler(t, http.MethodPut, l)
+
+	rec := serve(h, http.MethodPut, map[string]string{"If-Match": `"v1"`})
+
+	test.ExpectInt(t, rec.Code, http.StatusPreconditionFailed)
+	test.ExpectBool(t, l.Called, false)
+
+	// Strong comparison is used for If-Match
+	rec = serve(h, http.MethodPut, map[string]string{"If-Match": `W/"v2"`})
+	test.ExpectInt(t, rec.Code, http.StatusPreconditionFailed)
+
+	rec = serve(h, http.MethodPut, map[string]string{"If-Match": `"v1", "v2"`})
+
+	test.ExpectInt(t, rec.Code, http.StatusOK)
+	test.ExpectBool(t, l.Called, true)
+
+	l.Current = ""
+
+	rec = serve(h, http.MethodPut, map[string]string{"If-Match": "*"})
+	test.ExpectInt(t, rec.Code, http.StatusPreconditionFailed)
+
+	rec = serve(h, http.MethodPut, nil)
+	test.ExpectInt(t, rec.Code, http.StatusOK)
+}
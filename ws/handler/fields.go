@@ -0,0 +1,85 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package handler
+
+import (
+	"context"
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/ws"
+	"net/http"
+	"reflect"
+)
+
+const defaultSparseFieldsParam = "fields"
+
+// WsResponseBody is implemented by Logic components that declare the type of the Body they set on successful responses.
+// If the Logic for a handler with SparseFields enabled implements this interface, requests naming a field that the Body
+// cannot have are rejected (before the Logic is invoked) with an UnknownResponseField framework error. The type is also
+// used to describe the body of successful responses in generated OpenAPI documents (see the ws/openapi package).
+type WsResponseBody interface {
+	// ResponseBody returns a value (normally empty, or a nil pointer) of the type that is set as the ws.Response Body.
+	ResponseBody() interface{}
+}
+
+// parseFields parses the SparseFieldsParam query parameter. If the type of the response's body is known, a framework
+// error is added to the request if the selection names a field that the body cannot have.
+func (wh *WsHandler) parseFields(ctx context.Context, req *http.Request, wsReq *ws.Request) ws.FieldSelection {
+
+	if !wh.SparseFields {
+		return nil
+	}
+
+	fs := ws.ParseFieldSelection(req.URL.Query().Get(wh.SparseFieldsParam))
+
+	if len(fs) == 0 || wh.responseBody == nil {
+		return fs
+	}
+
+	err := ws.CheckFieldSelection(reflect.TypeOf(wh.responseBody.ResponseBody()), fs)
+
+	if ufe, unknown := err.(*ws.UnknownFieldError); unknown {
+		wh.addUnknownFieldError(wsReq, ufe)
+	}
+
+	return fs
+}
+
+// selectFields removes any fields from the response's body that were not requested in the supplied selection (parsed
+// from the SparseFieldsParam query parameter by parseFields). Returns false if the selection named a field that does not
+// exist, in which case an error response has already been written.
+func (wh *WsHandler) selectFields(ctx context.Context, fs ws.FieldSelection, wsReq *ws.Request, wsRes *ws.Response, w *httpendpoint.HTTPResponseWriter) bool {
+
+	if len(fs) == 0 || wsRes.Body == nil || wsRes.Errors.HasErrors() {
+		return true
+	}
+
+	if _, streamed := wsRes.Body.(*ws.StreamedResponse); streamed {
+		return true
+	}
+
+	body, err := ws.SelectFields(wsRes.Body, fs)
+
+	if err == nil {
+		wsRes.Body = body
+		return true
+	}
+
+	ufe, unknown := err.(*ws.UnknownFieldError)
+
+	if !unknown {
+		wh.Log.LogErrorfCtx(ctx, "Unable to select fields from response: %s", err.Error())
+		return true
+	}
+
+	wh.addUnknownFieldError(wsReq, ufe)
+	wh.handleFrameworkErrors(ctx, w, wsReq)
+
+	return false
+}
+
+func (wh *WsHandler) addUnknownFieldError(wsReq *ws.Request, ufe *ws.UnknownFieldError) {
+
+	m, c := wh.FrameworkErrors.MessageCode(ws.UnknownResponseField, ufe.Path, wh.SparseFieldsParam)
+	wsReq.AddFrameworkError(ws.NewQueryBindFrameworkError(m, c, wh.SparseFieldsParam, ""))
+}
@@ -0,0 +1,134 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package handler
+
+import (
+	"context"
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"github.com/graniticio/granitic/v2/ws"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type sparseAlbum struct {
+	Title string `json:"title"`
+	Year  int    `json:"year"`
+}
+
+type sparseArtist struct {
+	ID     int           `json:"id"`
+	Name   string        `json:"name"`
+	Albums []sparseAlbum `json:"albums"`
+}
+
+type sparseLogic struct{}
+
+func (sl *sparseLogic) Process(ctx context.Context, request *ws.Request, response *ws.Response) {
+	response.Body = &sparseArtist{ID: 1, Name: "Nico", Albums: []sparseAlbum{{Title: "Chelsea Girl", Year: 1967}}}
+}
+
+func sparseHandler(t *testing.T, param string) *WsHandler {
+	return sparseHandlerFor(t, param, http.MethodGet, new(sparseLogic))
+}
+
+func sparseHandlerFor(t *testing.T, param, method string, logic WsRequestProcessor) *WsHandler {
+
+	h := new(WsHandler)
+	h.PathPattern = "/artist$"
+	h.HTTPMethod = method
+	h.Logic = logic
+	h.Log = new(logging.ConsoleErrorLogger)
+	h.ResponseWriter = jsonResponseWriter()
+	h.SparseFields = true
+	h.SparseFieldsParam = param
+	h.FrameworkErrors = &ws.FrameworkErrorGenerator{
+		Messages: map[ws.FrameworkErrorEvent][]string{
+			ws.UnknownResponseField: {"FIELDS", "No field %s (%s)"},
+		},
+		FrameworkLogger: new(logging.ConsoleErrorLogger),
+	}
+
+	test.ExpectNil(t, h.StartComponent())
+
+	return h
+}
+
+func serveSparse(h *WsHandler, path string) *httptest.ResponseRecorder {
+
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	rec := httptest.NewRecorder()
+
+	h.ServeHTTP(context.Background(), httpendpoint.NewHTTPResponseWriter(rec), req)
+
+	return rec
+}
+
+func TestSparseFields(t *testing.T) {
+
+	h := sparseHandler(t, "")
+	test.ExpectString(t, h.SparseFieldsParam, "fields")
+
+	rec := serveSparse(h, "/artist?fields=name,albums.title")
+	test.ExpectInt(t, rec.Code, http.StatusOK)
+	test.ExpectString(t, strings.TrimSpace(rec.Body.String()), `{"name":"Nico","albums":[{"title":"Chelsea Girl"}]}`)
+
+	rec = serveSparse(h, "/artist")
+	test.ExpectString(t, strings.TrimSpace(rec.Body.String()), `{"id":1,"name":"Nico","albums":[{"title":"Chelsea Girl","year":1967}]}`)
+
+	rec = serveSparse(h, "/artist?fields=name,albums.label")
+	test.ExpectInt(t, rec.Code, http.StatusBadRequest)
+	test.ExpectBool(t, strings.Contains(rec.Body.String(), "No field albums.label (fields)"), true)
+
+	h = sparseHandler(t, "select")
+
+	rec = serveSparse(h, "/artist?select=id&fields=name")
+	test.ExpectString(t, strings.TrimSpace(rec.Body.String()), `{"id":1}`)
+
+	h.SparseFields = false
+
+	rec = serveSparse(h, "/artist?select=unknown")
+	test.ExpectInt(t, rec.Code, http.StatusOK)
+}
+
+type declaredSparseLogic struct {
+	sparseLogic
+	calls int
+}
+
+func (dl *declaredSparseLogic) Process(ctx context.Context, request *ws.Request, response *ws.Response) {
+	dl.calls++
+	dl.sparseLogic.Process(ctx, request, response)
+}
+
+func (dl *declaredSparseLogic) ResponseBody() interface{} {
+	return (*sparseArtist)(nil)
+}
+
+func TestSparseFieldsCheckedBeforeProcessing(t *testing.T) {
+
+	l := new(declaredSparseLogic)
+	h := sparseHandlerFor(t, "", http.MethodPost, l)
+
+	req := httptest.NewRequest(http.MethodPost, "/artist?fields=name,albums.label", nil)
+	rec := httptest.NewRecorder()
+
+	h.ServeHTTP(context.Background(), httpendpoint.NewHTTPResponseWriter(rec), req)
+
+	test.ExpectInt(t, rec.Code, http.StatusBadRequest)
+	test.ExpectBool(t, strings.Contains(rec.Body.String(), "No field albums.label (fields)"), true)
+	test.ExpectInt(t, l.calls, 0)
+
+	req = httptest.NewRequest(http.MethodPost, "/artist?fields=name,albums.title", nil)
+	rec = httptest.NewRecorder()
+
+	h.ServeHTTP(context.Background(), httpendpoint.NewHTTPResponseWriter(rec), req)
+
+	test.ExpectInt(t, rec.Code, http.StatusOK)
+	test.ExpectString(t, strings.TrimSpace(rec.Body.String()), `{"name":"Nico","albums":[{"title":"Chelsea Girl"}]}`)
+	test.ExpectInt(t, l.calls, 1)
+}
@@ -27,7 +27,8 @@ A handler is declared in your component definition file like:
 
 Each handler must have the following before it is considered a valid web service endpoint.
 
-1. A regular expression that will be matched against the path component of incoming HTTP requests.
+1. A regular expression (PathPattern) that will be matched against the path component of incoming HTTP requests or a
+path template (PathTemplate) such as /artist/{ID:int} that declares named path parameters.
 
 2. A single HTTP method that it will be responsible for handling. This is generally GET, POST, PUT or DELETE but any
 standard or custom HTTP method can be used.
@@ -49,9 +50,13 @@ import (
 	"github.com/graniticio/granitic/v2/logging"
 	"github.com/graniticio/granitic/v2/validate"
 	"github.com/graniticio/granitic/v2/ws"
+	"github.com/graniticio/granitic/v2/ws/idempotency"
+	"github.com/graniticio/granitic/v2/ws/version"
 	"net/http"
 	"reflect"
 	"regexp"
+	"strings"
+	"time"
 )
 
 const processPayloadFunc = "ProcessPayload"
@@ -60,7 +65,8 @@ const processPayloadFunc = "ProcessPayload"
 // use by a WsHandler.
 type WsRequestProcessor interface {
 	// Process performs the actual 'work' of a web service request. The response parameter will be modified according to
-	// the output or errors that the web service caller should see.
+	// the output or errors that the web service caller should see. If the handler has a timeout, ctx has a deadline and
+	// implementations should stop work when ctx is done, as the handler waits for Process to return.
 	Process(ctx context.Context, request *ws.Request, response *ws.Response)
 }
 
@@ -127,13 +133,15 @@ type WsHandler struct {
 	// Whether or not the underlying HTTP request and response writer should be made available to request Logic.
 	AllowDirectHTTPAccess bool
 
-	// Whether or not query parameters should be automatically injected into the request body.
+	// Whether or not query parameters should be automatically injected into the request body. Query parameters named in
+	// FieldQueryParam are also bound.
 	AutoBindQuery bool
 
 	// A component able to use a set of user-defined rules to validate a request.
 	AutoValidator *validate.RuleValidator
 
 	// A list of field names on the target object into which path parameters (groups in the request regex) should be bound to.
+	// If not set, fields with a ws:"path=name" struct tag are bound to the path parameter with that name (or index).
 	BindPathParams []string
 
 	// Check caller's permissions after request has been parsed (true) or before parsing (false).
@@ -142,6 +150,10 @@ type WsHandler struct {
 	// A function able to create an empty initialised struct to use as a target for request binding
 	createTarget func() interface{}
 
+	// The number of results per page if the client does not specify a page size, overriding WS.Pagination.DefaultSize.
+	// Limited to the maximum page size (MaxPageSize or WS.Pagination.MaxSize).
+	DefaultPageSize int
+
 	// If true, do not automatically return an error response if errors are found during the parsing and binding phases of request processing.
 	DeferFrameworkErrors bool
 
@@ -157,15 +169,37 @@ type WsHandler struct {
 	// An object that provides access to application defined error messages for use during validation.
 	ErrorFinder ws.ServiceErrorFinder
 
-	// A map of fields on the request body object and the names of query parameters that should be used to populate them
+	// A map of fields on the request body object and the names of cookies whose values should be used to populate them.
+	// Fields with a ws:"cookie=name" struct tag are added to this map when the handler starts.
+	FieldCookie map[string]string
+
+	// A map of fields on the request body object and the names of request headers that should be used to populate them.
+	// Fields with a ws:"header=name" struct tag are added to this map when the handler starts.
+	FieldHeader map[string]string
+
+	// A map of fields on the request body object and the names of query parameters that should be used to populate them.
+	// Fields with a ws:"query=name" struct tag are added to this map when the handler starts.
 	FieldQueryParam map[string]string
 
 	// An object that provides access to built-in error messages to use when an error is found during the automated phases of request processing.
 	FrameworkErrors *ws.FrameworkErrorGenerator
 
+	// If true, an entity tag is computed over the marshalled body of successful responses to GET and HEAD requests (unless
+	// the Logic sets ws.Response.ETag) and requests with a matching If-None-Match header receive an HTTP 304 response.
+	GenerateETag bool
+
 	// The HTTP method (GET, POST etc) that this handler supports.
 	HTTPMethod string
 
+	// A component that records the responses to requests made with an Idempotency-Key header. Injected by the JSONWs and
+	// XMLWs facilities.
+	IdempotencyStore idempotency.Store
+
+	// If true, the first response to a request (other than GET, HEAD or OPTIONS) with an Idempotency-Key header is recorded
+	// and replayed to later requests from the same caller with the same key, without invoking Logic again. A key re-used with
+	// a different request (or while the original request is still being processed) results in an HTTP 409 response.
+	Idempotent bool
+
 	// A logger injected by the Granitic framework. Note this will be an application logger rather than a framework logger
 	// as instances of WsHandler are considered application components.
 	Log logging.Logger
@@ -173,13 +207,29 @@ type WsHandler struct {
 	// The object representing the 'logic' behind this handler.
 	Logic interface{}
 
+	// The maximum size (in bytes) of a request body accepted by this handler, overriding HTTPServer.MaxRequestBodyBytes.
+	// Zero means the server's limit applies and a negative value means no limit.
+	MaxBodyBytes int64
+
+	// The largest page size a client can request, overriding WS.Pagination.MaxSize.
+	MaxPageSize int
+
 	// A component injected by the Granitic framework that can map text representations of query and path parameters to Go
 	// and Granitic types.
 	ParamBinder *ws.ParamBinder
 
+	// A component injected by the Granitic framework that binds the page of results requested by the client into a
+	// ws.OffsetPageRequest or ws.CursorPageRequest field on the request target and generates Link headers.
+	Paginator *ws.Paginator
+
 	// A regex that will be matched against inbound request paths to check if this handler should be used to service the request.
 	PathPattern string
 
+	// An alternative to PathPattern that declares the path with named parameters, e.g. /users/{id}/orders/{orderId:int}
+	// (see httpendpoint.PathTemplate). Unless BindPathParams is set, each parameter is bound to the field on the request
+	// target with the same name.
+	PathTemplate string
+
 	// A component that might want to modify a response after it has been processed by the supplied Logic component.
 	PostProcessor WsPostProcessor
 
@@ -189,12 +239,45 @@ type WsHandler struct {
 	// Stop the framework automatically adding this handler to an HTTP server.
 	PreventAutoWiring bool
 
+	// The maximum number of requests a client can make to this handler in a burst. Zero means RateLimitRequestsPerSecond
+	// (rounded up) is used.
+	RateLimitBurst int
+
+	// The request header used to identify clients if RateLimitKey is HEADER.
+	RateLimitHeader string
+
+	// How clients are identified for rate limiting: REMOTE (the default), HEADER or USER (the LoggableUserID of the identity
+	// established by UserIdentifier).
+	RateLimitKey string
+
+	// The rate (requests per second) at which each client can make requests to this handler, overriding HTTPServer.RateLimit.
+	// Zero means the server's limit applies.
+	RateLimitRequestsPerSecond float64
+
 	// A component injected by the Granitic framework that writes the response from this handler to an HTTP response.
 	ResponseWriter ws.ResponseWriter
 
 	// Whether on not the caller needs to be authenticated (using a ws.Identifier) in order to access the logic behind this handler.
 	RequireAuthentication bool
 
+	// If true, clients can request that only some of the fields of the response's body are returned by listing them
+	// (using dot separated paths for nested fields) in the query parameter named in SparseFieldsParam.
+	SparseFields bool
+
+	// The name of the query parameter listing the fields the client wants returned. Defaults to fields.
+	SparseFieldsParam string
+
+	// A semantic version range (e.g. ^2.1 or >=1.0 <3, see version.Range) describing the versions of functionality this
+	// handler supports. If set, a version.RangeAssessor is created as this handler's VersionAssessor.
+	SupportedVersions string
+
+	// If true (and SupportedVersions is set), this handler also serves requests that do not specify a version.
+	SupportsUnversioned bool
+
+	// The maximum time (in milliseconds) allowed to serve a request to this handler, overriding HTTPServer.RequestTimeoutMS.
+	// Zero means the server's timeout applies and a negative value means no timeout.
+	TimeoutMS time.Duration
+
 	// A component injected by the Granitic framework that can extract the body of the incoming HTTP request into a Go struct.
 	Unmarshaller ws.Unmarshaller
 
@@ -202,16 +285,25 @@ type WsHandler struct {
 	UserIdentifier ws.Identifier
 
 	// A component that can check if this handler supports the version of functionality required by the caller.
-	VersionAssessor   WsVersionAssessor
+	VersionAssessor WsVersionAssessor
+
+	// If true, entity tags computed by this handler (see GenerateETag) are weak (prefixed with W/).
+	WeakETag bool
+
 	bindPathParams    bool
+	currentETag       WsCurrentETag
 	bindQuery         bool
 	httpMethods       []string
 	componentName     string
 	pathRegex         *regexp.Regexp
+	pathTemplate      *httpendpoint.PathTemplate
 	state             ioc.ComponentState
 	validationEnabled bool
 	validator         WsRequestValidator
 	genericProcessor  WsRequestProcessor
+	pageField         int
+	pageStyle         ws.PaginationStyle
+	responseBody      WsResponseBody
 }
 
 // ProvideErrorFinder receives a component that can be used to map error codes to categorised errors.
@@ -223,9 +315,27 @@ func (wh *WsHandler) ProvideErrorFinder(finder ws.ServiceErrorFinder) {
 }
 
 // ServeHTTP is the entry point called by the HTTP server once it has been determined that this handler instance
-// is the correct one to handle the incoming request.
+// is the correct one to handle the incoming request. If the supplied context has a deadline (or TimeoutMS is set), an
+// error response is written as soon as the deadline passes, after which ServeHTTP waits for request processing to finish.
 func (wh *WsHandler) ServeHTTP(ctx context.Context, w *httpendpoint.HTTPResponseWriter, req *http.Request) context.Context {
 
+	if _, found := ctx.Deadline(); !found && wh.TimeoutMS > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = httpendpoint.WithRequestTimeout(ctx, wh.RequestTimeout(), 0)
+		defer cancel()
+	}
+
+	if _, found := ctx.Deadline(); found {
+		return wh.serveWithDeadline(ctx, w, req)
+	}
+
+	return wh.serve(ctx, w, req, new(phaseTracker))
+}
+
+// serve processes the request, recording its progress through the phases of processing in the supplied phaseTracker.
+func (wh *WsHandler) serve(ctx context.Context, w *httpendpoint.HTTPResponseWriter, req *http.Request, pt *phaseTracker) context.Context {
+
 	defer func() {
 		if r := recover(); r != nil {
 			wh.Log.LogErrorfCtxWithTrace(ctx, "Panic recovered while trying process a request or write its response %s", r)
@@ -233,6 +343,9 @@ func (wh *WsHandler) ServeHTTP(ctx context.Context, w *httpendpoint.HTTPResponse
 		}
 	}()
 
+	// The HTTP server only removes the temporary files of multipart forms parsed on its own copy of the request
+	defer wh.removeUploadedFiles(ctx, req)
+
 	if ri := instrument.InstrumentorFromContext(ctx); ri != nil {
 		//This request is being instrumented, let the instrumentation have access to this handler
 		ri.Amend(instrument.Handler, wh)
@@ -240,6 +353,7 @@ func (wh *WsHandler) ServeHTTP(ctx context.Context, w *httpendpoint.HTTPResponse
 
 	wsReq := new(ws.Request)
 	wsReq.HTTPMethod = req.Method
+	wsReq.Path = req.URL.Path
 	wsReq.ServingHandler = wh.ComponentName()
 
 	wsReq.ID = ws.RecoverIDFunction(ctx)
@@ -258,6 +372,20 @@ func (wh *WsHandler) ServeHTTP(ctx context.Context, w *httpendpoint.HTTPResponse
 		wsReq.UnderlyingHTTP = da
 	}
 
+	//Choose the format of the response if the ResponseWriter supports more than one
+	if cn, found := wh.ResponseWriter.(ws.ContentNegotiator); found {
+
+		var acceptable bool
+
+		if ctx, acceptable = cn.Negotiate(ctx, req); !acceptable {
+			state := ws.NewAbnormalState(http.StatusNotAcceptable, w)
+			state.WsRequest = wsReq
+
+			wh.ResponseWriter.Write(ctx, state, ws.Abnormal)
+			return ctx
+		}
+	}
+
 	//Try to identify and/or authenticate the caller
 	var okay bool
 
@@ -266,15 +394,38 @@ func (wh *WsHandler) ServeHTTP(ctx context.Context, w *httpendpoint.HTTPResponse
 		return ctx
 	}
 
+	if !wh.enforceUserRateLimit(ctx, w, req, wsReq) {
+		return ctx
+	}
+
 	//Check caller has permission to use this resource
 	if !wh.CheckAccessAfterParse && !wh.checkAccess(ctx, w, wsReq) {
 		return ctx
 	}
 
 	//Unmarshall body, query parameters and path parameters
+	pt.enter(phaseUnmarshal)
+	fp := wh.fingerprintBody(req)
 	wh.unmarshall(ctx, req, wsReq)
 	wh.processQueryParams(ctx, req, wsReq)
+	wh.processHeaders(ctx, req, wsReq)
 	wh.processPathParams(req, wsReq)
+	wh.processPagination(ctx, req, wsReq)
+	fs := wh.parseFields(ctx, req, wsReq)
+
+	//Replay the response to an earlier request with the same Idempotency-Key
+	var ir *idempotentRequest
+
+	if ir, okay = wh.reserveIdempotencyKey(ctx, w, req, wsReq, fp); !okay {
+		return ctx
+	}
+
+	if ir != nil {
+		ir.capture = &capturingWriter{w: w}
+		w = httpendpoint.NewHTTPResponseWriter(ir.capture)
+
+		defer wh.recordIdempotentResponse(ctx, ir)
+	}
 
 	if wsReq.HasFrameworkErrors() && !wh.DeferFrameworkErrors {
 		wh.handleFrameworkErrors(ctx, w, wsReq)
@@ -287,6 +438,8 @@ func (wh *WsHandler) ServeHTTP(ctx context.Context, w *httpendpoint.HTTPResponse
 	}
 
 	//Validate request
+	pt.enter(phaseValidate)
+
 	var errors ws.ServiceErrors
 	errors.ErrorFinder = wh.ErrorFinder
 
@@ -298,8 +451,14 @@ func (wh *WsHandler) ServeHTTP(ctx context.Context, w *httpendpoint.HTTPResponse
 		return ctx
 	}
 
+	//Check any If-Match precondition
+	if !wh.checkPreconditions(ctx, w, req, wsReq) {
+		return ctx
+	}
+
 	//Execute logic
-	wh.process(ctx, wsReq, w)
+	pt.enter(phaseProcess)
+	wh.process(ctx, wsReq, w, req, fs, pt)
 
 	return ctx
 }
@@ -388,9 +547,22 @@ func (wh *WsHandler) unmarshall(ctx context.Context, req *http.Request, wsReq *w
 		return
 	}
 
-	err := wh.Unmarshaller.Unmarshall(ctx, req, wsReq)
+	if wh.MaxBodyBytes > 0 && req.ContentLength > wh.MaxBodyBytes {
+		wh.addTooLargeError(ctx, req, wsReq, wh.MaxBodyBytes)
+		return
+	}
 
-	if err != nil {
+	wh.bodyReadError(ctx, req, wsReq, wh.Unmarshaller.Unmarshall(ctx, req, wsReq))
+}
+
+// bodyReadError records a framework error if the request's body could not be read or parsed
+func (wh *WsHandler) bodyReadError(ctx context.Context, req *http.Request, wsReq *ws.Request, err error) {
+
+	var tooLarge *http.MaxBytesError
+
+	if errors.As(err, &tooLarge) {
+		wh.addTooLargeError(ctx, req, wsReq, tooLarge.Limit)
+	} else if err != nil {
 
 		wh.Log.LogDebugfCtx(ctx, "Error unmarshalling request body for %s %s %s", req.URL.Path, req.Method, err)
 
@@ -402,6 +574,23 @@ func (wh *WsHandler) unmarshall(ctx context.Context, req *http.Request, wsReq *w
 
 }
 
+func (wh *WsHandler) addTooLargeError(ctx context.Context, req *http.Request, wsReq *ws.Request, limit int64) {
+
+	wh.Log.LogDebugfCtx(ctx, "Request body for %s %s is larger than the limit of %d bytes", req.URL.Path, req.Method, limit)
+
+	m, c := wh.FrameworkErrors.MessageCode(ws.RequestTooLarge, limit)
+
+	f := ws.NewUnmarshallFrameworkError(m, c)
+	f.HTTPStatus = http.StatusRequestEntityTooLarge
+
+	wsReq.AddFrameworkError(f)
+}
+
+// RequestBodyLimit returns the value of MaxBodyBytes. Implements httpendpoint.BodyLimitedProvider
+func (wh *WsHandler) RequestBodyLimit() int64 {
+	return wh.MaxBodyBytes
+}
+
 func (wh *WsHandler) processPathParams(req *http.Request, wsReq *ws.Request) {
 
 	if wh.DisablePathParsing {
@@ -436,7 +625,9 @@ func (wh *WsHandler) processQueryParams(ctx context.Context, req *http.Request,
 
 		if wh.AutoBindQuery {
 			wh.ParamBinder.AutoBindQueryParameters(wsReq)
-		} else {
+		}
+
+		if len(wh.FieldQueryParam) > 0 {
 			wh.ParamBinder.BindQueryParameters(wsReq, wh.FieldQueryParam)
 		}
 
@@ -458,6 +649,15 @@ func (wh *WsHandler) checkAccess(ctx context.Context, w *httpendpoint.HTTPRespon
 		return true
 	}
 
+	if ds, found := ac.(ws.AccessDeniedErrorSource); found {
+		var se ws.ServiceErrors
+		se.HTTPStatus = http.StatusForbidden
+		se.AddError(ds.DeniedError(ctx, wsReq))
+
+		wh.writeErrorResponse(ctx, &se, w, wsReq)
+		return false
+	}
+
 	state := ws.NewAbnormalState(http.StatusForbidden, w)
 	state.Identity = wsReq.UserIdentity
 	state.WsRequest = wsReq
@@ -478,6 +678,11 @@ func (wh *WsHandler) identifyAndAuthenticate(ctx context.Context, w *httpendpoin
 
 		if wh.RequireAuthentication && !i.Authenticated() {
 
+			// Callers that fail authentication are rate limited by remote address, so credentials cannot be guessed at will
+			if !wh.takeUserRateLimit(ctx, w, wsReq, httpendpoint.RemoteHost(req)) {
+				return false, ctx
+			}
+
 			state := ws.NewAbnormalState(http.StatusUnauthorized, w)
 			state.Identity = wsReq.UserIdentity
 			state.WsRequest = wsReq
@@ -509,12 +714,49 @@ func (wh *WsHandler) SupportedHTTPMethods() []string {
 // RegexPattern returns the unparsed regex pattern that should be applicaed to the path of incoming requests to
 // see if this handler should handle the request.
 func (wh *WsHandler) RegexPattern() string {
-	return wh.PathPattern
+
+	if wh.PathTemplate == "" {
+		return wh.PathPattern
+	}
+
+	if pt, err := wh.parsePathTemplate(); err == nil {
+		return pt.Regex()
+	}
+
+	return ""
+}
+
+// ParsedPathTemplate returns the handler's parsed PathTemplate, or nil if the handler uses a PathPattern (or the
+// template is invalid). Implements httpendpoint.TemplatedProvider
+func (wh *WsHandler) ParsedPathTemplate() *httpendpoint.PathTemplate {
+
+	if wh.PathTemplate == "" {
+		return nil
+	}
+
+	pt, _ := wh.parsePathTemplate()
+
+	return pt
+}
+
+func (wh *WsHandler) parsePathTemplate() (*httpendpoint.PathTemplate, error) {
+
+	if wh.pathTemplate != nil {
+		return wh.pathTemplate, nil
+	}
+
+	pt, err := httpendpoint.ParsePathTemplate(wh.PathTemplate)
+
+	if err == nil {
+		wh.pathTemplate = pt
+	}
+
+	return pt, err
 }
 
 // VersionAware returns true if this handler can be considered when a user requests a specific version of functionality.
 func (wh *WsHandler) VersionAware() bool {
-	return wh.VersionAssessor != nil
+	return wh.VersionAssessor != nil || wh.SupportedVersions != ""
 }
 
 // SupportsVersion returns true if this handler supports the version of functionality requested by the caller. Defers to the
@@ -536,13 +778,17 @@ func (wh *WsHandler) handleFrameworkErrors(ctx context.Context, w *httpendpoint.
 
 	for _, fe := range wsReq.FrameworkErrors {
 		se.AddNewError(ws.Client, fe.Code, fe.Message)
+
+		if fe.HTTPStatus != 0 && se.HTTPStatus == http.StatusBadRequest {
+			se.HTTPStatus = fe.HTTPStatus
+		}
 	}
 
 	wh.writeErrorResponse(ctx, &se, w, wsReq)
 
 }
 
-func (wh *WsHandler) process(ctx context.Context, request *ws.Request, w *httpendpoint.HTTPResponseWriter) {
+func (wh *WsHandler) process(ctx context.Context, request *ws.Request, w *httpendpoint.HTTPResponseWriter, req *http.Request, fs ws.FieldSelection, pt *phaseTracker) {
 
 	defer func() {
 		if r := recover(); r != nil {
@@ -570,6 +816,19 @@ func (wh *WsHandler) process(ctx context.Context, request *ws.Request, w *httpen
 		wh.PostProcessor.PostProcess(ctx, wh.ComponentName(), request, wsRes)
 	}
 
+	if ctx.Err() != nil {
+		// The deadline has passed or the client has disconnected, so the response would not be received
+		return
+	}
+
+	pt.enter(phaseMarshal)
+
+	if !wh.selectFields(ctx, fs, request, wsRes, w) {
+		return
+	}
+
+	wh.addPageLinks(wsRes, req)
+
 	state := new(ws.ProcessState)
 	state.Identity = request.UserIdentity
 	state.HTTPResponseWriter = w
@@ -584,7 +843,9 @@ func (wh *WsHandler) process(ctx context.Context, request *ws.Request, w *httpen
 
 	var err error
 
-	if wsRes.HTTPStatus < 300 {
+	if wh.conditionalGet(req, wsRes) {
+		err = wh.writeConditionally(ctx, state, req)
+	} else if wsRes.HTTPStatus < 300 {
 		err = wh.ResponseWriter.Write(ctx, state, ws.Normal)
 	} else {
 		err = wh.ResponseWriter.Write(ctx, state, ws.Abnormal)
@@ -635,6 +896,18 @@ func (wh *WsHandler) writeErrorResponse(ctx context.Context, errors *ws.ServiceE
 
 }
 
+// removeUploadedFiles deletes any temporary files created while unmarshalling a multipart request
+func (wh *WsHandler) removeUploadedFiles(ctx context.Context, req *http.Request) {
+
+	if req.MultipartForm == nil {
+		return
+	}
+
+	if err := req.MultipartForm.RemoveAll(); err != nil {
+		wh.Log.LogWarnfCtx(ctx, "Unable to remove temporary files for uploads to %s %s: %s", req.Method, req.URL.Path, err.Error())
+	}
+}
+
 func (wh *WsHandler) writePanicResponse(ctx context.Context, r interface{}, w *httpendpoint.HTTPResponseWriter) {
 
 	state := ws.NewAbnormalState(http.StatusInternalServerError, w)
@@ -655,8 +928,35 @@ func (wh *WsHandler) StartComponent() error {
 
 	wh.state = ioc.StartingState
 
-	if wh.PathPattern == "" || wh.HTTPMethod == "" || wh.Logic == nil {
-		return errors.New("handlers must have at least a PathPattern string, HTTPMethod string and Logic component set")
+	if (wh.PathPattern == "" && wh.PathTemplate == "") || wh.HTTPMethod == "" || wh.Logic == nil {
+		return errors.New("handlers must have at least a PathPattern (or PathTemplate) string, HTTPMethod string and Logic component set")
+	}
+
+	if wh.Idempotent && wh.IdempotencyStore == nil {
+		return errors.New("handlers with Idempotent set to true must have an IdempotencyStore set")
+	}
+
+	if wh.SupportedVersions != "" {
+
+		if wh.VersionAssessor != nil {
+			return errors.New("handlers cannot have both SupportedVersions and a VersionAssessor set")
+		}
+
+		ra, err := version.NewRangeAssessor(wh.SupportedVersions, wh.SupportsUnversioned)
+
+		if err != nil {
+			return fmt.Errorf("SupportedVersions for %s is invalid: %s", wh.ComponentName(), err.Error())
+		}
+
+		wh.VersionAssessor = ra
+	}
+
+	if wh.SparseFields && wh.SparseFieldsParam == "" {
+		wh.SparseFieldsParam = defaultSparseFieldsParam
+	}
+
+	if wh.PathPattern != "" && wh.PathTemplate != "" {
+		return errors.New("handlers must have either a PathPattern or a PathTemplate, not both")
 	}
 
 	if wh.AutoValidator != nil && wh.ErrorFinder == nil {
@@ -675,36 +975,115 @@ func (wh *WsHandler) StartComponent() error {
 		wh.validator = validator
 	}
 
+	if ce, found := wh.Logic.(WsCurrentETag); found {
+		wh.currentETag = ce
+	}
+
+	if rb, found := wh.Logic.(WsResponseBody); found {
+		wh.responseBody = rb
+	}
+
+	if err := wh.validateProcessPayload(); err == nil {
+		//The logic attached to this handler has a ProcessPayload method. Extract a func for creating empty structs to pass to it
+		wh.createTarget = wh.extractFactoryFromLogic()
+	}
+
+	taggedPathParams, err := wh.addTaggedBindings()
+
+	if err != nil {
+		return err
+	}
+
+	if err := wh.findPageRequestField(); err != nil {
+		return err
+	}
+
 	wh.bindQuery = wh.AutoBindQuery || (wh.FieldQueryParam != nil && len(wh.FieldQueryParam) > 0)
 
-	if !wh.DisablePathParsing {
+	configuredPathParams := len(wh.BindPathParams) > 0
 
-		wh.bindPathParams = len(wh.BindPathParams) > 0
+	if wh.PathTemplate != "" {
 
-		r, err := regexp.Compile(wh.PathPattern)
+		pt, err := wh.parsePathTemplate()
+
+		if err != nil {
+			return err
+		}
+
+		if !configuredPathParams {
+			wh.BindPathParams = wh.fieldsForParams(pt.Params)
+		}
+	}
+
+	if !wh.DisablePathParsing {
+
+		r, err := regexp.Compile(wh.RegexPattern())
 
 		if err != nil {
 			return err
 		}
 
 		wh.pathRegex = r
+
+		if !configuredPathParams {
+			if err := wh.addTaggedPathParams(taggedPathParams); err != nil {
+				return err
+			}
+		}
+
+		wh.bindPathParams = len(wh.BindPathParams) > 0
 	}
 
 	if wh.DeferAutoErrors && wh.validator == nil {
 		return errors.New("if you want to defer errors generated during auto validation, your logic component must implement WsRequestValidator")
 	}
 
-	if err := wh.validateProcessPayload(); err == nil {
-		//The logic attached to this handler has a ProcessPayload method. Extract a func for creating empty structs to pass to it
-		wh.createTarget = wh.extractFactoryFromLogic()
-	}
-
 	wh.state = ioc.RunningState
 
 	return nil
 
 }
 
+// fieldsForParams finds the fields on the request target that path template parameters should be bound to. A field with
+// exactly the same name as the parameter is preferred, otherwise a case-insensitive match is used.
+func (wh *WsHandler) fieldsForParams(params []string) []string {
+
+	fields := make([]string, len(params))
+	copy(fields, params)
+
+	target := wh.RequestTarget()
+
+	if target == nil {
+		return fields
+	}
+
+	t := reflect.TypeOf(target)
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return fields
+	}
+
+	for i, p := range params {
+
+		if _, found := t.FieldByName(p); found {
+			continue
+		}
+
+		for j := 0; j < t.NumField(); j++ {
+			if f := t.Field(j); strings.EqualFold(f.Name, p) {
+				fields[i] = f.Name
+				break
+			}
+		}
+	}
+
+	return fields
+}
+
 func (wh *WsHandler) checkLogicComponent() error {
 	if rp, found := wh.Logic.(WsRequestProcessor); found {
 
@@ -783,6 +1162,21 @@ func (wh *WsHandler) extractFactoryFromLogic() func() interface{} {
 
 }
 
+// RequestTarget returns a new, empty instance of the type that this handler parses requests into, or nil if the handler's
+// Logic component does not declare one (by implementing WsUnmarshallTarget or having a ProcessPayload method).
+func (wh *WsHandler) RequestTarget() interface{} {
+
+	if targetSource, found := wh.Logic.(WsUnmarshallTarget); found {
+		return targetSource.UnmarshallTarget()
+	}
+
+	if wh.createTarget != nil {
+		return wh.createTarget()
+	}
+
+	return nil
+}
+
 // ComponentName implements ComponentNamer.ComponentName
 func (wh *WsHandler) ComponentName() string {
 	return wh.componentName
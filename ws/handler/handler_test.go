@@ -8,11 +8,19 @@ import (
 	"bytes"
 	"context"
 	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/logging"
 	"github.com/graniticio/granitic/v2/test"
+	"github.com/graniticio/granitic/v2/types"
 	"github.com/graniticio/granitic/v2/ws"
+	"github.com/graniticio/granitic/v2/ws/form"
+	"github.com/graniticio/granitic/v2/ws/json"
+	"github.com/graniticio/granitic/v2/ws/version"
+	"mime/multipart"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -174,6 +182,96 @@ func TestHandlerStart(t *testing.T) {
 
 }
 
+func TestSupportedVersions(t *testing.T) {
+
+	wh, _ := GetHandler(t)
+	wh.Logic = new(mockLogic)
+	wh.SupportedVersions = "^2.1"
+
+	test.ExpectBool(t, wh.VersionAware(), true)
+	test.ExpectNil(t, wh.StartComponent())
+
+	test.ExpectBool(t, wh.SupportsVersion(httpendpoint.RequiredVersion{version.Key: "2.4"}), true)
+	test.ExpectBool(t, wh.SupportsVersion(httpendpoint.RequiredVersion{version.Key: "3"}), false)
+	test.ExpectBool(t, wh.SupportsVersion(httpendpoint.RequiredVersion{}), false)
+
+	wh, _ = GetHandler(t)
+	wh.Logic = new(mockLogic)
+	wh.SupportedVersions = "^a"
+
+	test.ExpectBool(t, wh.StartComponent() == nil, false)
+}
+
+func TestHandlerWithPathTemplate(t *testing.T) {
+
+	wh, _ := GetHandler(t)
+
+	wh.PathPattern = ""
+	wh.PathTemplate = "/test/{outcome}"
+	wh.Logic = new(mockLogic)
+
+	if err := wh.StartComponent(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	test.ExpectBool(t, wh.bindPathParams, true)
+	test.ExpectInt(t, len(wh.BindPathParams), 1)
+	test.ExpectString(t, wh.BindPathParams[0], "Outcome")
+
+	test.ExpectBool(t, wh.pathRegex.MatchString("/test/ok"), true)
+	test.ExpectBool(t, wh.pathRegex.MatchString("/test/ok/"), true)
+	test.ExpectBool(t, wh.pathRegex.MatchString("/test/ok/more"), false)
+
+	wh, _ = GetHandler(t)
+
+	wh.PathTemplate = "/test/{outcome}"
+	wh.Logic = new(mockLogic)
+
+	if err := wh.StartComponent(); err == nil {
+		t.Fatalf("Expected an error when both PathPattern and PathTemplate are set")
+	}
+
+	wh, _ = GetHandler(t)
+
+	wh.PathPattern = ""
+	wh.PathTemplate = "/test/{outcome:unknown}"
+	wh.Logic = new(mockLogic)
+
+	if err := wh.StartComponent(); err == nil {
+		t.Fatalf("Expected an error with an invalid template")
+	}
+}
+
+func TestAccessDeniedWithServiceError(t *testing.T) {
+
+	l := new(ProcessOnlyLogic)
+
+	h, req := GetHandler(t)
+
+	rw := new(recordingResponseWriter)
+
+	h.Logic = l
+	h.ResponseWriter = rw
+	h.AccessChecker = new(denyingChecker)
+
+	test.ExpectNil(t, h.StartComponent())
+
+	w := httpendpoint.NewHTTPResponseWriter(NewStringBufferResponseWriter())
+
+	h.ServeHTTP(context.Background(), w, req)
+
+	test.ExpectBool(t, l.Called, false)
+
+	if rw.Outcome != ws.Error {
+		t.Fatalf("Expected an error outcome")
+	}
+
+	se := rw.State.ServiceErrors
+
+	test.ExpectInt(t, se.HTTPStatus, http.StatusForbidden)
+	test.ExpectString(t, se.Errors[0].Code, "DENIED")
+}
+
 func GetHandler(t *testing.T) (*WsHandler, *http.Request) {
 
 	gf := filepath.Join("ws", "get")
@@ -234,6 +332,28 @@ func (rw *NilResponseWriter) Write(ctx context.Context, state *ws.ProcessState,
 	return nil
 }
 
+type recordingResponseWriter struct {
+	State   *ws.ProcessState
+	Outcome ws.Outcome
+}
+
+func (rw *recordingResponseWriter) Write(ctx context.Context, state *ws.ProcessState, outcome ws.Outcome) error {
+	rw.State = state
+	rw.Outcome = outcome
+
+	return nil
+}
+
+type denyingChecker struct{}
+
+func (dc *denyingChecker) Allowed(ctx context.Context, r *ws.Request) bool {
+	return false
+}
+
+func (dc *denyingChecker) DeniedError(ctx context.Context, r *ws.Request) *ws.CategorisedError {
+	return ws.NewCategorisedError(ws.Security, "DENIED", "Denied")
+}
+
 type AllPhasesLogic struct {
 	ProcessCalled          bool
 	UnmarshallTargetCalled bool
@@ -286,3 +406,133 @@ type mockLogicInvalid struct {
 func (ml *mockLogicInvalid) ProcessPayload(ctx context.Context, request *ws.Request, response *ws.Response, target mockTarget) {
 
 }
+
+func TestBodyTooLarge(t *testing.T) {
+
+	body := `{"Outcome":"` + strings.Repeat("a", 100) + `"}`
+
+	for _, contentLength := range []int64{int64(len(body)), -1} {
+
+		h, _ := GetHandler(t)
+
+		rw := new(recordingResponseWriter)
+
+		h.Logic = new(mockLogic)
+		h.ResponseWriter = rw
+		h.Unmarshaller = new(json.Unmarshaller)
+		h.MaxBodyBytes = 50
+		h.Log = new(logging.ConsoleErrorLogger)
+		h.FrameworkErrors = &ws.FrameworkErrorGenerator{
+			Messages: map[ws.FrameworkErrorEvent][]string{ws.RequestTooLarge: {"TOOLARGE", "Limit is %d"}},
+		}
+
+		test.ExpectNil(t, h.StartComponent())
+
+		test.ExpectInt(t, int(h.RequestBodyLimit()), 50)
+
+		req := httptest.NewRequest(http.MethodPost, "/test", strings.NewReader(body))
+		req.ContentLength = contentLength
+
+		// Servers wrap the body to enforce the limit when the content length is unknown
+		req.Body = http.MaxBytesReader(nil, req.Body, h.MaxBodyBytes)
+
+		w := httpendpoint.NewHTTPResponseWriter(NewStringBufferResponseWriter())
+
+		h.ServeHTTP(context.Background(), w, req)
+
+		if rw.Outcome != ws.Error {
+			t.Fatalf("Expected an error outcome")
+		}
+
+		se := rw.State.ServiceErrors
+
+		test.ExpectInt(t, se.HTTPStatus, http.StatusRequestEntityTooLarge)
+		test.ExpectString(t, se.Errors[0].Code, "TOOLARGE")
+		test.ExpectString(t, se.Errors[0].Message, "Limit is 50")
+	}
+}
+
+type uploadTarget struct {
+	Avatar *types.UploadedFile
+}
+
+type uploadLogic struct {
+	avatar *types.UploadedFile
+}
+
+func (ul *uploadLogic) ProcessPayload(ctx context.Context, request *ws.Request, response *ws.Response, target *uploadTarget) {
+
+	ul.avatar = target.Avatar
+
+	if f, err := target.Avatar.Open(); err == nil {
+		f.Close()
+		response.Body = "OPENED"
+	}
+}
+
+func TestUploadedFilesRemoved(t *testing.T) {
+
+	b := new(bytes.Buffer)
+	mw := multipart.NewWriter(b)
+
+	fw, _ := mw.CreateFormFile("Avatar", "me.png")
+	fw.Write(make([]byte, 2048))
+	mw.Close()
+
+	l := new(uploadLogic)
+	rw := new(recordingResponseWriter)
+
+	mu := new(form.MultipartUnmarshaller)
+	mu.ParamBinder = paramBinder()
+	mu.FrameworkLogger = new(logging.ConsoleErrorLogger)
+
+	// Files larger than this are stored in temporary files
+	mu.MaxMemoryBytes = 1
+
+	h, _ := GetHandler(t)
+	h.HTTPMethod = http.MethodPost
+	h.Logic = l
+	h.ResponseWriter = rw
+	h.Unmarshaller = mu
+	h.Log = new(logging.ConsoleErrorLogger)
+
+	test.ExpectNil(t, h.StartComponent())
+
+	req := httptest.NewRequest(http.MethodPost, "/test", b)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	h.ServeHTTP(context.Background(), httpendpoint.NewHTTPResponseWriter(NewStringBufferResponseWriter()), req)
+
+	test.ExpectString(t, rw.State.WsResponse.Body.(string), "OPENED")
+
+	if _, err := l.avatar.Open(); err == nil {
+		t.Errorf("Expected the uploaded file to have been removed")
+	}
+}
+
+func TestNotAcceptable(t *testing.T) {
+
+	h, _ := GetHandler(t)
+
+	rw := new(recordingResponseWriter)
+
+	nw := new(ws.NegotiatingResponseWriter)
+	nw.Writers = map[string]ws.ResponseWriter{"application/json": rw}
+	nw.MediaTypes = []string{"application/json"}
+
+	h.Logic = new(mockLogic)
+	h.ResponseWriter = nw
+	h.Log = new(logging.ConsoleErrorLogger)
+
+	test.ExpectNil(t, h.StartComponent())
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set("Accept", "text/html")
+
+	w := httpendpoint.NewHTTPResponseWriter(NewStringBufferResponseWriter())
+
+	h.ServeHTTP(context.Background(), w, req)
+
+	test.ExpectBool(t, rw.Outcome == ws.Abnormal, true)
+	test.ExpectInt(t, rw.State.Status, http.StatusNotAcceptable)
+}
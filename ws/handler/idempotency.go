@@ -0,0 +1,205 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package handler
+
+import (
+	"bytes"
+	"context"
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/ws"
+	"github.com/graniticio/granitic/v2/ws/idempotency"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// idempotentRequest tracks a request whose idempotency key has been reserved, so that its response can be recorded.
+type idempotentRequest struct {
+	key         string
+	fingerprint string
+	capture     *capturingWriter
+}
+
+// fingerprintBody arranges for the body of a request carrying an Idempotency-Key header to be hashed as it is read, so
+// that the request can be fingerprinted without holding its body in memory. Returns nil if the request's idempotency
+// key does not need to be checked.
+func (wh *WsHandler) fingerprintBody(req *http.Request) *idempotency.Fingerprinter {
+
+	if !wh.Idempotent {
+		return nil
+	}
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return nil
+	}
+
+	if req.Header.Get(idempotency.KeyHeader) == "" {
+		return nil
+	}
+
+	fp := idempotency.NewFingerprinter(req)
+
+	if req.Body != nil {
+		req.Body = fingerprintingBody{Reader: io.TeeReader(req.Body, fp), Closer: req.Body}
+	}
+
+	return fp
+}
+
+// fingerprintingBody passes a request's body through to a Fingerprinter as it is read
+type fingerprintingBody struct {
+	io.Reader
+	io.Closer
+}
+
+// reserveIdempotencyKey checks whether a request carrying an Idempotency-Key header can be processed. It is called once the
+// request has been unmarshalled, after which any of the body not read by the Unmarshaller is read and discarded to complete
+// the supplied Fingerprinter (the overall size of the body is still subject to the handler's body limit).
+//
+// If the key has already been used, the recorded response is replayed (or an HTTP 409 written if the key was used for a
+// different request or the original request is still being processed) and false is returned. If the key is reserved for
+// this request, an idempotentRequest is returned so that the response can be recorded. Requests that will be rejected
+// because of framework errors are not reserved, as their response depends only on the part of the body that was read.
+func (wh *WsHandler) reserveIdempotencyKey(ctx context.Context, w *httpendpoint.HTTPResponseWriter, req *http.Request, wsReq *ws.Request, fp *idempotency.Fingerprinter) (*idempotentRequest, bool) {
+
+	if fp == nil || (wsReq.HasFrameworkErrors() && !wh.DeferFrameworkErrors) {
+		return nil, true
+	}
+
+	if req.Body != nil {
+		if _, err := io.Copy(ioutil.Discard, req.Body); err != nil {
+			wh.bodyReadError(ctx, req, wsReq, err)
+			return nil, true
+		}
+	}
+
+	key := req.Header.Get(idempotency.KeyHeader)
+
+	// Keys are only meaningful to the client that created them
+	storeKey := wh.ComponentName() + " " + wsReq.UserIdentity.LoggableUserID() + " " + key
+	fingerprint := fp.Fingerprint()
+
+	r, err := wh.IdempotencyStore.Reserve(ctx, storeKey, fingerprint)
+
+	if err != nil {
+		wh.Log.LogErrorfCtx(ctx, "Unable to reserve idempotency key: %s", err.Error())
+
+		state := ws.NewAbnormalState(http.StatusInternalServerError, w)
+		state.WsRequest = wsReq
+
+		wh.ResponseWriter.Write(ctx, state, ws.Abnormal)
+
+		return nil, false
+	}
+
+	switch {
+	case r == nil:
+		return &idempotentRequest{key: storeKey, fingerprint: fingerprint}, true
+	case r.Fingerprint != fingerprint:
+		wh.writeIdempotencyConflict(ctx, w, wsReq, ws.IdempotencyKeyReused, key)
+	case !r.Complete:
+		wh.writeIdempotencyConflict(ctx, w, wsReq, ws.IdempotencyKeyInProgress, key)
+	default:
+		replay(w, r)
+	}
+
+	return nil, false
+}
+
+func (wh *WsHandler) writeIdempotencyConflict(ctx context.Context, w *httpendpoint.HTTPResponseWriter, wsReq *ws.Request, event ws.FrameworkErrorEvent, key string) {
+
+	m, c := wh.FrameworkErrors.MessageCode(event, key)
+
+	var se ws.ServiceErrors
+	se.HTTPStatus = http.StatusConflict
+	se.AddNewError(ws.Client, c, m)
+
+	wh.writeErrorResponse(ctx, &se, w, wsReq)
+}
+
+// recordIdempotentResponse stores the response written to the client. Responses indicating a server error are not stored
+// so that the client can retry the request. Neither are responses to requests that exceeded their deadline or whose client
+// disconnected, as the client will not have received them.
+func (wh *WsHandler) recordIdempotentResponse(ctx context.Context, ir *idempotentRequest) {
+
+	var err error
+
+	if status := ir.capture.status; status == 0 || status >= http.StatusInternalServerError || ctx.Err() != nil {
+		err = wh.IdempotencyStore.Release(ctx, ir.key)
+	} else {
+
+		r := new(idempotency.Record)
+		r.Fingerprint = ir.fingerprint
+		r.Status = status
+		r.Header = ir.capture.header
+		r.Body = ir.capture.body.Bytes()
+
+		err = wh.IdempotencyStore.Complete(ctx, ir.key, r)
+	}
+
+	if err != nil {
+		wh.Log.LogErrorfCtx(ctx, "Unable to record response for idempotency key: %s", err.Error())
+	}
+}
+
+// replay writes a recorded response. Headers already set on the response (e.g. by the HTTP server) are not overwritten.
+func replay(w *httpendpoint.HTTPResponseWriter, r *idempotency.Record) {
+
+	h := w.Header()
+
+	for k, vs := range r.Header {
+		if _, set := h[k]; !set {
+			h[k] = vs
+		}
+	}
+
+	h.Set(idempotency.ReplayedHeader, "true")
+
+	w.WriteHeader(r.Status)
+
+	if len(r.Body) > 0 {
+		w.Write(r.Body)
+	}
+}
+
+// capturingWriter passes a response through to the client while keeping a copy of its status, headers and body. The
+// headers are copied before they are passed on, so headers added by the HTTP server while sending the response (e.g. the
+// Content-Encoding of a compressed response, which does not apply to the captured body) are not captured.
+type capturingWriter struct {
+	w      *httpendpoint.HTTPResponseWriter
+	status int
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (cw *capturingWriter) Header() http.Header {
+	return cw.w.Header()
+}
+
+func (cw *capturingWriter) WriteHeader(status int) {
+
+	if cw.status == 0 {
+		cw.status = status
+		cw.header = cw.w.Header().Clone()
+	}
+
+	cw.w.WriteHeader(status)
+}
+
+func (cw *capturingWriter) Write(b []byte) (int, error) {
+
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+		cw.header = cw.w.Header().Clone()
+	}
+
+	cw.body.Write(b)
+
+	return cw.w.Write(b)
+}
+
+func (cw *capturingWriter) Flush() {
+	cw.w.Flush()
+}
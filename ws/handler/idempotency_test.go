@@ -0,0 +1,218 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package handler
+
+import (
+	"context"
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"github.com/graniticio/granitic/v2/ws"
+	"github.com/graniticio/granitic/v2/ws/idempotency"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type countingLogic struct {
+	Calls  int
+	Status int
+}
+
+func (cl *countingLogic) Process(ctx context.Context, request *ws.Request, response *ws.Response) {
+	cl.Calls++
+
+	response.HTTPStatus = cl.Status
+	response.Body = map[string]int{"Order": cl.Calls}
+}
+
+func idempotentHandler(t *testing.T, l *countingLogic) *WsHandler {
+
+	h := new(WsHandler)
+	h.PathPattern = "/orders$"
+	h.HTTPMethod = http.MethodPost
+	h.Logic = l
+	h.Log = new(logging.ConsoleErrorLogger)
+	h.ResponseWriter = jsonResponseWriter()
+	h.FrameworkErrors = &ws.FrameworkErrorGenerator{
+		Messages: map[ws.FrameworkErrorEvent][]string{
+			ws.IdempotencyKeyReused:     {"IDEMPOTENCY", "Reused %s"},
+			ws.IdempotencyKeyInProgress: {"IDEMPOTENCY", "In progress %s"},
+		},
+	}
+	h.Idempotent = true
+	h.IdempotencyStore = new(idempotency.MemoryStore)
+	h.IdempotencyStore.(*idempotency.MemoryStore).ExpiryMS = 60000
+
+	test.ExpectNil(t, h.StartComponent())
+
+	return h
+}
+
+func post(h *WsHandler, key, body string) *httptest.ResponseRecorder {
+
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+
+	if key != "" {
+		req.Header.Set(idempotency.KeyHeader, key)
+	}
+
+	rec := httptest.NewRecorder()
+
+	h.ServeHTTP(context.Background(), httpendpoint.NewHTTPResponseWriter(rec), req)
+
+	return rec
+}
+
+func TestIdempotentReplay(t *testing.T) {
+
+	l := &countingLogic{Status: http.StatusCreated}
+	h := idempotentHandler(t, l)
+
+	rec := post(h, "abc", `{"Item":1}`)
+
+	test.ExpectInt(t, rec.Code, http.StatusCreated)
+	test.ExpectString(t, rec.Body.String(), `{"Order":1}`)
+
+	rec = post(h, "abc", `{"Item":1}`)
+
+	test.ExpectInt(t, l.Calls, 1)
+	test.ExpectInt(t, rec.Code, http.StatusCreated)
+	test.ExpectString(t, rec.Body.String(), `{"Order":1}`)
+	test.ExpectString(t, rec.Header().Get("Content-Type"), "application/json")
+	test.ExpectString(t, rec.Header().Get(idempotency.ReplayedHeader), "true")
+
+	// Requests without a key are always processed
+	post(h, "", `{"Item":1}`)
+	test.ExpectInt(t, l.Calls, 2)
+
+	rec = post(h, "abc", `{"Item":2}`)
+
+	test.ExpectInt(t, rec.Code, http.StatusConflict)
+	test.ExpectBool(t, strings.Contains(rec.Body.String(), "Reused abc"), true)
+	test.ExpectInt(t, l.Calls, 2)
+}
+
+func TestIdempotencyKeyInProgress(t *testing.T) {
+
+	l := &countingLogic{}
+	h := idempotentHandler(t, l)
+
+	fingerprint := idempotency.Fingerprint(httptest.NewRequest(http.MethodPost, "/orders", nil), []byte(`{}`))
+	h.IdempotencyStore.Reserve(context.Background(), h.ComponentName()+" - key", fingerprint)
+
+	rec := post(h, "key", `{}`)
+
+	test.ExpectInt(t, rec.Code, http.StatusConflict)
+	test.ExpectBool(t, strings.Contains(rec.Body.String(), "In progress key"), true)
+	test.ExpectInt(t, l.Calls, 0)
+}
+
+func TestServerErrorsNotRecorded(t *testing.T) {
+
+	l := &countingLogic{Status: http.StatusInternalServerError}
+	h := idempotentHandler(t, l)
+
+	post(h, "abc", `{}`)
+
+	l.Status = http.StatusOK
+
+	rec := post(h, "abc", `{}`)
+
+	test.ExpectInt(t, rec.Code, http.StatusOK)
+	test.ExpectInt(t, l.Calls, 2)
+}
+
+func TestWholeBodyFingerprinted(t *testing.T) {
+
+	l := &countingLogic{Status: http.StatusCreated}
+	h := idempotentHandler(t, l)
+
+	body := strings.Repeat("a", 100000)
+
+	post(h, "abc", body+"1")
+	rec := post(h, "abc", body+"2")
+
+	test.ExpectInt(t, rec.Code, http.StatusConflict)
+	test.ExpectInt(t, l.Calls, 1)
+
+	rec = post(h, "abc", body+"1")
+
+	test.ExpectInt(t, rec.Code, http.StatusCreated)
+	test.ExpectString(t, rec.Header().Get(idempotency.ReplayedHeader), "true")
+}
+
+func TestRejectedRequestsNotReserved(t *testing.T) {
+
+	l := &countingLogic{Status: http.StatusCreated}
+	h := idempotentHandler(t, l)
+	h.MaxBodyBytes = 10
+	h.FrameworkErrors.Messages[ws.RequestTooLarge] = []string{"TOOLARGE", "Limit is %d"}
+
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(strings.Repeat("a", 100)))
+	req.Header.Set(idempotency.KeyHeader, "abc")
+
+	// Servers wrap the body to enforce the limit
+	req.Body = http.MaxBytesReader(nil, req.Body, h.MaxBodyBytes)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(context.Background(), httpendpoint.NewHTTPResponseWriter(rec), req)
+
+	test.ExpectInt(t, rec.Code, http.StatusRequestEntityTooLarge)
+	test.ExpectInt(t, l.Calls, 0)
+
+	rec = post(h, "abc", "{}")
+
+	test.ExpectInt(t, rec.Code, http.StatusCreated)
+	test.ExpectInt(t, l.Calls, 1)
+}
+
+func TestCompressedResponsesReplayed(t *testing.T) {
+
+	l := &countingLogic{Status: http.StatusCreated}
+	h := idempotentHandler(t, l)
+
+	c := &httpendpoint.Compressor{Encodings: []string{httpendpoint.GzipEncoding}, MinSize: 5, ContentTypes: []string{"application/json"}, Level: -1}
+
+	serve := func(acceptEncoding string) *httptest.ResponseRecorder {
+
+		req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader("{}"))
+		req.Header.Set(idempotency.KeyHeader, "abc")
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+
+		rec := httptest.NewRecorder()
+
+		w := httpendpoint.NewHTTPResponseWriter(rec)
+		c.Wrap(w, req)
+
+		h.ServeHTTP(context.Background(), w, req)
+		test.ExpectNil(t, w.Finish())
+
+		return rec
+	}
+
+	rec := serve("gzip")
+
+	test.ExpectString(t, rec.Header().Get("Content-Encoding"), "gzip")
+
+	rec = serve("")
+
+	test.ExpectInt(t, l.Calls, 1)
+	test.ExpectString(t, rec.Header().Get(idempotency.ReplayedHeader), "true")
+	test.ExpectString(t, rec.Header().Get("Content-Encoding"), "")
+	test.ExpectString(t, rec.Header().Get("Vary"), "Accept-Encoding")
+	test.ExpectString(t, rec.Body.String(), `{"Order":1}`)
+}
+
+func TestIdempotentRequiresStore(t *testing.T) {
+
+	h := new(WsHandler)
+	h.PathPattern = "/orders$"
+	h.HTTPMethod = http.MethodPost
+	h.Logic = new(countingLogic)
+	h.Idempotent = true
+
+	test.ExpectBool(t, h.StartComponent() != nil, true)
+}
@@ -0,0 +1,70 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package handler
+
+import (
+	"context"
+	"errors"
+	"github.com/graniticio/granitic/v2/ws"
+	"net/http"
+)
+
+const linkHeader = "Link"
+
+// findPageRequestField checks whether the request target has a ws.OffsetPageRequest or ws.CursorPageRequest field
+func (wh *WsHandler) findPageRequestField() error {
+
+	wh.pageField, wh.pageStyle = ws.PageRequestField(wh.RequestTarget())
+
+	if wh.pageStyle != ws.NoPagination && wh.Paginator == nil {
+		return errors.New("handlers whose request target has a page request field must have a Paginator set. Check that the JSONWs or XMLWs facility is enabled")
+	}
+
+	return nil
+}
+
+// processPagination binds the page of results requested by the client into the request target
+func (wh *WsHandler) processPagination(ctx context.Context, req *http.Request, wsReq *ws.Request) {
+
+	if wh.pageStyle == ws.NoPagination {
+		return
+	}
+
+	if wsReq.RequestBody == nil {
+		wh.Log.LogErrorfCtx(ctx, "Pagination is enabled, but no target available to bind into. Does your Logic component implement the WsUnmarshallTarget interface?")
+		return
+	}
+
+	wh.Paginator.BindPageRequest(wsReq, req.URL.Query(), wh.pageField, wh.pageStyle, wh.DefaultPageSize, wh.MaxPageSize)
+}
+
+// addPageLinks sets a Link header referring to adjacent pages if the logic component has described the page of results
+// it returned. A Link header set by the logic component is not replaced.
+func (wh *WsHandler) addPageLinks(wsRes *ws.Response, req *http.Request) {
+
+	p := wh.Paginator
+
+	if p == nil || !p.LinkHeaders || wsRes.Page == nil || wsRes.Errors.HasErrors() {
+		return
+	}
+
+	if _, set := wsRes.Headers[linkHeader]; set {
+		return
+	}
+
+	uri := req.RequestURI
+
+	if uri == "" {
+		uri = req.URL.RequestURI()
+	}
+
+	if links := p.Links(uri, wsRes.Page); links != "" {
+
+		if wsRes.Headers == nil {
+			wsRes.Headers = make(map[string]string)
+		}
+
+		wsRes.Headers[linkHeader] = links
+	}
+}
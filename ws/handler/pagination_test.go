@@ -0,0 +1,111 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package handler
+
+import (
+	"context"
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"github.com/graniticio/granitic/v2/ws"
+	"github.com/graniticio/granitic/v2/ws/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type artistListRequest struct {
+	Genre string
+	Page  *ws.OffsetPageRequest
+}
+
+type artistListLogic struct {
+	request *artistListRequest
+}
+
+func (al *artistListLogic) ProcessPayload(ctx context.Context, request *ws.Request, response *ws.Response, q *artistListRequest) {
+	al.request = q
+
+	response.Body = []string{"Nico"}
+	response.Page = ws.NewOffsetPage(q.Page, 45)
+}
+
+func (al *artistListLogic) UnmarshallTarget() interface{} {
+	return new(artistListRequest)
+}
+
+func paginatedHandler(t *testing.T, l *artistListLogic) *WsHandler {
+
+	pg := new(ws.Paginator)
+	pg.PageParam = "page"
+	pg.SizeParam = "size"
+	pg.CursorParam = "cursor"
+	pg.DefaultSize = 20
+	pg.MaxSize = 100
+	pg.LinkHeaders = true
+	pg.FrameworkErrors = &ws.FrameworkErrorGenerator{
+		Messages: map[ws.FrameworkErrorEvent][]string{
+			ws.PageSizeInvalid: {"PAGINATION", "Bad size %s (%s) max %d"},
+		},
+		FrameworkLogger: new(logging.ConsoleErrorLogger),
+	}
+
+	h := new(WsHandler)
+	h.PathPattern = "/artists$"
+	h.HTTPMethod = http.MethodGet
+	h.Logic = l
+	h.Log = new(logging.ConsoleErrorLogger)
+	h.ResponseWriter = jsonResponseWriter()
+	h.Paginator = pg
+	h.MaxPageSize = 10
+
+	test.ExpectNil(t, h.StartComponent())
+
+	return h
+}
+
+func TestPaginatedHandler(t *testing.T) {
+
+	l := new(artistListLogic)
+	h := paginatedHandler(t, l)
+
+	serve := func(uri string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, uri, nil)
+		rec := httptest.NewRecorder()
+
+		h.ServeHTTP(context.Background(), httpendpoint.NewHTTPResponseWriter(rec), req)
+
+		return rec
+	}
+
+	rec := serve("/artists?page=2&size=10")
+
+	test.ExpectInt(t, rec.Code, http.StatusOK)
+	test.ExpectInt(t, l.request.Page.Page, 2)
+	test.ExpectInt(t, l.request.Page.Offset(), 10)
+	test.ExpectString(t, rec.Header().Get("Link"), `</artists?page=1&size=10>; rel="first", </artists?page=1&size=10>; rel="prev", </artists?page=3&size=10>; rel="next", </artists?page=5&size=10>; rel="last"`)
+
+	rec = serve("/artists?size=11")
+
+	test.ExpectInt(t, rec.Code, http.StatusBadRequest)
+	test.ExpectBool(t, strings.Contains(rec.Body.String(), "Bad size 11 (size) max 10"), true)
+
+	rw := h.ResponseWriter.(*ws.MarshallingResponseWriter)
+	rw.ResponseWrapper = &json.GraniticJSONResponseWrapper{ErrorsFieldName: "Errors", BodyFieldName: "Response", PageFieldName: "Page"}
+
+	rec = serve("/artists")
+
+	// The default size is limited to the handler's maximum
+	test.ExpectInt(t, l.request.Page.Size, 10)
+	test.ExpectString(t, strings.TrimSpace(rec.Body.String()), `{"Page":{"Number":1,"Size":10,"TotalItems":45,"TotalPages":5,"HasNext":true,"HasPrevious":false},"Response":["Nico"]}`)
+
+	h = new(WsHandler)
+	h.PathPattern = "/artists$"
+	h.HTTPMethod = http.MethodGet
+	h.Logic = l
+
+	err := h.StartComponent()
+	test.ExpectBool(t, err != nil && strings.Contains(err.Error(), "Paginator"), true)
+}
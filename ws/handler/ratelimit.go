@@ -0,0 +1,79 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package handler
+
+import (
+	"context"
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/ws"
+	"net/http"
+)
+
+// RequestRateLimit returns the limit defined by the RateLimit... fields, or nil if RateLimitRequestsPerSecond is not set.
+// Implements httpendpoint.RateLimitedProvider
+func (wh *WsHandler) RequestRateLimit() *httpendpoint.RateLimit {
+
+	if wh.RateLimitRequestsPerSecond == 0 {
+		return nil
+	}
+
+	rl := new(httpendpoint.RateLimit)
+	rl.Enabled = true
+	rl.RequestsPerSecond = wh.RateLimitRequestsPerSecond
+	rl.Burst = wh.RateLimitBurst
+	rl.Key = wh.RateLimitKey
+	rl.Header = wh.RateLimitHeader
+
+	return rl
+}
+
+// EnforcesDeferredRateLimit returns true, as the handler applies limits keyed by user identity once the caller has been
+// identified. Implements httpendpoint.DeferredRateLimitEnforcer
+func (wh *WsHandler) EnforcesDeferredRateLimit() bool {
+	return true
+}
+
+// enforceUserRateLimit applies any rate limit keyed by user identity that the HTTP server was unable to enforce before the
+// caller was identified. Anonymous callers are identified by their remote address. If the caller has exceeded its limit,
+// an HTTP 429 response is written and false is returned.
+func (wh *WsHandler) enforceUserRateLimit(ctx context.Context, w *httpendpoint.HTTPResponseWriter, req *http.Request, wsReq *ws.Request) bool {
+
+	key := wsReq.UserIdentity.LoggableUserID()
+
+	if key == "" || key == "-" {
+		key = httpendpoint.RemoteHost(req)
+	}
+
+	return wh.takeUserRateLimit(ctx, w, wsReq, key)
+}
+
+// takeUserRateLimit takes a token for the supplied key from any rate limit keyed by user identity. If the caller has
+// exceeded its limit, an HTTP 429 response is written and false is returned.
+func (wh *WsHandler) takeUserRateLimit(ctx context.Context, w *httpendpoint.HTTPResponseWriter, wsReq *ws.Request, key string) bool {
+
+	l := httpendpoint.DeferredRateLimit(ctx)
+
+	if l == nil {
+		return true
+	}
+
+	d := l.Take(key)
+	d.WriteHeaders(w)
+
+	if d.Allowed {
+		return true
+	}
+
+	wh.Log.LogDebugfCtx(ctx, "Rate limited request from %s", key)
+
+	state := ws.NewAbnormalState(http.StatusTooManyRequests, w)
+	state.Identity = wsReq.UserIdentity
+	state.WsRequest = wsReq
+
+	if err := wh.ResponseWriter.Write(ctx, state, ws.Abnormal); err != nil {
+		wh.Log.LogErrorfCtx(ctx, "Problem writing response: %s", err.Error())
+	}
+
+	return false
+}
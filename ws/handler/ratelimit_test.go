@@ -0,0 +1,112 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package handler
+
+import (
+	"context"
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/iam"
+	"github.com/graniticio/granitic/v2/test"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type headerIdentifier struct{}
+
+func (hi *headerIdentifier) Identify(ctx context.Context, req *http.Request) (iam.ClientIdentity, context.Context) {
+
+	u := req.Header.Get("X-User")
+
+	if u == "" {
+		return iam.NewAnonymousIdentity(), ctx
+	}
+
+	return iam.NewAuthenticatedIdentity(u), ctx
+}
+
+func TestRequestRateLimit(t *testing.T) {
+
+	h := new(WsHandler)
+
+	test.ExpectBool(t, h.RequestRateLimit() == nil, true)
+
+	h.RateLimitRequestsPerSecond = 5
+	h.RateLimitBurst = 10
+	h.RateLimitKey = httpendpoint.UserKey
+
+	rl := h.RequestRateLimit()
+
+	test.ExpectBool(t, rl.Enabled, true)
+	test.ExpectInt(t, rl.Burst, 10)
+	test.ExpectString(t, rl.Key, httpendpoint.UserKey)
+}
+
+func TestUserRateLimit(t *testing.T) {
+
+	l := &resourceLogic{}
+	h := conditionalHandler(t, http.MethodGet, l)
+	h.UserIdentifier = new(headerIdentifier)
+
+	limiter := httpendpoint.NewRateLimiter(httpendpoint.RateLimit{Enabled: true, RequestsPerSecond: 1, Key: httpendpoint.UserKey})
+	ctx := httpendpoint.WithDeferredRateLimit(context.Background(), limiter)
+
+	get := func(user string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/resource", nil)
+		req.Header.Set("X-User", user)
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(ctx, httpendpoint.NewHTTPResponseWriter(rec), req)
+
+		return rec
+	}
+
+	rec := get("alice")
+	test.ExpectInt(t, rec.Code, http.StatusOK)
+	test.ExpectString(t, rec.Header().Get("RateLimit-Remaining"), "0")
+
+	l.Called = false
+	rec = get("alice")
+
+	test.ExpectInt(t, rec.Code, http.StatusTooManyRequests)
+	test.ExpectString(t, rec.Header().Get("Retry-After"), "1")
+	test.ExpectBool(t, l.Called, false)
+
+	test.ExpectInt(t, get("bob").Code, http.StatusOK)
+
+	// Anonymous callers are identified by their remote address
+	test.ExpectInt(t, get("").Code, http.StatusOK)
+
+	stats := limiter.Stats()
+
+	test.ExpectInt(t, len(stats), 3)
+	test.ExpectString(t, stats[0].Key, "192.0.2.1")
+}
+
+func TestUnauthenticatedRateLimit(t *testing.T) {
+
+	l := &resourceLogic{}
+	h := conditionalHandler(t, http.MethodGet, l)
+	h.UserIdentifier = new(headerIdentifier)
+	h.RequireAuthentication = true
+
+	limiter := httpendpoint.NewRateLimiter(httpendpoint.RateLimit{Enabled: true, RequestsPerSecond: 1, Key: httpendpoint.UserKey})
+	ctx := httpendpoint.WithDeferredRateLimit(context.Background(), limiter)
+
+	get := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(ctx, httpendpoint.NewHTTPResponseWriter(rec), httptest.NewRequest(http.MethodGet, "/resource", nil))
+
+		return rec
+	}
+
+	// Callers failing authentication are limited by their remote address
+	test.ExpectInt(t, get().Code, http.StatusUnauthorized)
+	test.ExpectInt(t, get().Code, http.StatusTooManyRequests)
+
+	stats := limiter.Stats()
+
+	test.ExpectInt(t, len(stats), 1)
+	test.ExpectString(t, stats[0].Key, "192.0.2.1")
+}
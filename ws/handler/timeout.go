@@ -0,0 +1,108 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package handler
+
+import (
+	"context"
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/ws"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	phaseUnmarshal = "unmarshal"
+	phaseValidate  = "validate"
+	phaseProcess   = "process"
+	phaseMarshal   = "marshal"
+)
+
+// RequestTimeout returns the value of TimeoutMS as a time.Duration. Implements httpendpoint.TimeLimitedProvider
+func (wh *WsHandler) RequestTimeout() time.Duration {
+	return wh.TimeoutMS * time.Millisecond
+}
+
+// EnforcesDeadline returns true, as the handler responds to the client itself if the deadline of the context passed to
+// ServeHTTP passes. Implements httpendpoint.DeadlineEnforcer
+func (wh *WsHandler) EnforcesDeadline() bool {
+	return true
+}
+
+// serveWithDeadline processes the request in a separate goroutine so that, if the context's deadline passes before
+// processing is complete, an error response can be sent to the client straight away. Anything written by the
+// processing after that point is discarded. The context (and so the deadline) is passed to the handler's Logic, which
+// should stop work when the context is done - the handler waits for processing to finish before returning, so that the
+// request is not used after the HTTP server has finished with it.
+func (wh *WsHandler) serveWithDeadline(ctx context.Context, w *httpendpoint.HTTPResponseWriter, req *http.Request) context.Context {
+
+	dw := httpendpoint.NewDeadlineWriter(w)
+	pt := new(phaseTracker)
+	done := make(chan context.Context, 1)
+
+	go func() {
+		done <- wh.serve(ctx, httpendpoint.NewHTTPResponseWriter(dw), req, pt)
+	}()
+
+	var rctx context.Context
+
+	select {
+	case rctx = <-done:
+	case <-ctx.Done():
+	}
+
+	if ctx.Err() == nil {
+		return rctx
+	}
+
+	started := dw.Expire()
+
+	if ctx.Err() != context.DeadlineExceeded {
+		wh.Log.LogDebugfCtx(ctx, "Client disconnected during the %s phase of %s %s", pt.current(), req.Method, req.URL.Path)
+	} else if started {
+		// Too late to change the response
+		wh.Log.LogDebugfCtx(ctx, "%s %s exceeded its deadline after its response was started", req.Method, req.URL.Path)
+	} else {
+		wh.Log.LogErrorfCtx(ctx, "%s %s exceeded its deadline during the %s phase", req.Method, req.URL.Path, pt.current())
+		wh.writeTimeout(ctx, w)
+	}
+
+	if rctx == nil {
+		<-done
+		return ctx
+	}
+
+	return rctx
+}
+
+// writeTimeout sends an error response to a request that has exceeded its deadline. The response is flushed so that the
+// client receives it while the handler waits for processing to finish.
+func (wh *WsHandler) writeTimeout(ctx context.Context, w *httpendpoint.HTTPResponseWriter) {
+
+	state := ws.NewAbnormalState(httpendpoint.TimeoutStatus(ctx), w)
+
+	if err := wh.ResponseWriter.Write(ctx, state, ws.Abnormal); err != nil {
+		wh.Log.LogErrorfCtx(ctx, "Problem writing response: %s", err.Error())
+	}
+
+	w.Flush()
+}
+
+// phaseTracker records the phase of processing a request has reached. Safe for concurrent use.
+type phaseTracker struct {
+	phase atomic.Value
+}
+
+func (pt *phaseTracker) enter(phase string) {
+	pt.phase.Store(phase)
+}
+
+func (pt *phaseTracker) current() string {
+
+	if p, found := pt.phase.Load().(string); found {
+		return p
+	}
+
+	return phaseUnmarshal
+}
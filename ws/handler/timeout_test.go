@@ -0,0 +1,146 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package handler
+
+import (
+	"context"
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"github.com/graniticio/granitic/v2/ws"
+	"github.com/graniticio/granitic/v2/ws/idempotency"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type slowLogic struct {
+	block    bool
+	calls    int
+	deadline bool
+}
+
+func (sl *slowLogic) Process(ctx context.Context, request *ws.Request, response *ws.Response) {
+
+	sl.calls++
+	_, sl.deadline = ctx.Deadline()
+
+	if sl.block {
+		<-ctx.Done()
+	}
+
+	response.Body = "done"
+}
+
+func timeoutHandler(t *testing.T, l *slowLogic) *WsHandler {
+
+	h := new(WsHandler)
+	h.PathPattern = "/slow$"
+	h.HTTPMethod = http.MethodGet
+	h.Logic = l
+	h.Log = new(logging.ConsoleErrorLogger)
+	h.ResponseWriter = jsonResponseWriter()
+	h.ResponseWriter.(*ws.MarshallingResponseWriter).FrameworkErrors.HTTPMessages["504"] = "Timed out"
+
+	test.ExpectNil(t, h.StartComponent())
+
+	return h
+}
+
+func TestHandlerTimeout(t *testing.T) {
+
+	l := &slowLogic{block: true}
+	h := timeoutHandler(t, l)
+	h.TimeoutMS = 10
+
+	test.ExpectBool(t, h.RequestTimeout() == 10*time.Millisecond, true)
+	test.ExpectBool(t, h.EnforcesDeadline(), true)
+
+	rec := httptest.NewRecorder()
+
+	h.ServeHTTP(context.Background(), httpendpoint.NewHTTPResponseWriter(rec), httptest.NewRequest(http.MethodGet, "/slow", nil))
+
+	test.ExpectInt(t, rec.Code, http.StatusGatewayTimeout)
+
+	// The handler waits for the logic to finish, but what it writes is discarded
+	test.ExpectInt(t, l.calls, 1)
+	test.ExpectBool(t, l.deadline, true)
+	test.ExpectBool(t, strings.Contains(rec.Body.String(), "done"), false)
+}
+
+func TestServerDeadline(t *testing.T) {
+
+	l := &slowLogic{block: true}
+	h := timeoutHandler(t, l)
+
+	ctx, cancel := httpendpoint.WithRequestTimeout(context.Background(), 10*time.Millisecond, http.StatusServiceUnavailable)
+	defer cancel()
+
+	rec := httptest.NewRecorder()
+
+	h.ServeHTTP(ctx, httpendpoint.NewHTTPResponseWriter(rec), httptest.NewRequest(http.MethodGet, "/slow", nil))
+
+	test.ExpectInt(t, rec.Code, http.StatusServiceUnavailable)
+
+	// Requests completed within the deadline are unaffected
+	l.block = false
+
+	ctx, cancel = httpendpoint.WithRequestTimeout(context.Background(), time.Second, http.StatusServiceUnavailable)
+	defer cancel()
+
+	rec = httptest.NewRecorder()
+	w := httpendpoint.NewHTTPResponseWriter(rec)
+	w.Header().Set("X-Server", "set")
+
+	h.ServeHTTP(ctx, w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+
+	test.ExpectInt(t, rec.Code, http.StatusOK)
+	test.ExpectString(t, rec.Body.String(), `"done"`)
+	test.ExpectString(t, rec.Header().Get("X-Server"), "set")
+	test.ExpectString(t, rec.Header().Get("Content-Type"), "application/json")
+}
+
+func TestTimedOutResponsesNotRecorded(t *testing.T) {
+
+	l := &slowLogic{block: true}
+	h := timeoutHandler(t, l)
+	h.HTTPMethod = http.MethodPost
+	h.TimeoutMS = 10
+	h.Idempotent = true
+	h.IdempotencyStore = &idempotency.MemoryStore{ExpiryMS: 60000}
+
+	test.ExpectNil(t, h.StartComponent())
+
+	serve := func() *httptest.ResponseRecorder {
+
+		req := httptest.NewRequest(http.MethodPost, "/slow", nil)
+		req.Header.Set(idempotency.KeyHeader, "abc")
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(context.Background(), httpendpoint.NewHTTPResponseWriter(rec), req)
+
+		return rec
+	}
+
+	test.ExpectInt(t, serve().Code, http.StatusGatewayTimeout)
+
+	l.block = false
+
+	// The client didn't receive the logic's response, so the retry is processed
+	test.ExpectInt(t, serve().Code, http.StatusOK)
+	test.ExpectInt(t, l.calls, 2)
+}
+
+func TestPhaseTracker(t *testing.T) {
+
+	pt := new(phaseTracker)
+
+	test.ExpectString(t, pt.current(), phaseUnmarshal)
+
+	pt.enter(phaseMarshal)
+
+	test.ExpectString(t, pt.current(), phaseMarshal)
+}
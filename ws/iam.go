@@ -5,6 +5,8 @@ package ws
 
 import (
 	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"github.com/graniticio/granitic/v2/iam"
 	"net/http"
 )
@@ -21,3 +23,33 @@ type AccessChecker interface {
 	// Allowed returns true if the caller is allowed to have this request processed, false otherwise.
 	Allowed(ctx context.Context, r *Request) bool
 }
+
+// AccessDeniedErrorSource is optionally implemented by an AccessChecker that wants callers who are refused access to
+// receive a specific service error rather than a generic HTTP 403 response.
+type AccessDeniedErrorSource interface {
+	// DeniedError returns the error to be written to the response when the supplied request is not allowed.
+	DeniedError(ctx context.Context, r *Request) *CategorisedError
+}
+
+// VerifiedClientCertificate returns the certificate presented by the caller if the request was made over a TLS connection
+// and the certificate was verified against the HTTPServer's client CA bundle. Returns nil otherwise.
+func VerifiedClientCertificate(req *http.Request) *x509.Certificate {
+
+	if req == nil || req.TLS == nil || len(req.TLS.VerifiedChains) == 0 || len(req.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+
+	return req.TLS.VerifiedChains[0][0]
+}
+
+// VerifiedClientSubject returns the subject of the caller's verified client certificate (see VerifiedClientCertificate)
+// and true, or an empty name and false if the caller did not present a verified certificate. Intended for use by Identifier
+// implementations that identify callers by their certificates.
+func VerifiedClientSubject(req *http.Request) (pkix.Name, bool) {
+
+	if c := VerifiedClientCertificate(req); c != nil {
+		return c.Subject, true
+	}
+
+	return pkix.Name{}, false
+}
@@ -0,0 +1,193 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+/*
+Package idempotency provides storage for the responses to web service requests made with an Idempotency-Key header.
+
+Clients retrying a request that changes state (typically a POST) risk the change being made twice if the original request
+was processed but its response was lost. If a handler.WsHandler has its Idempotent field set to true, the first response to a
+request carrying an Idempotency-Key header is recorded in a Store and replayed to any later request with the same key,
+without the handler's Logic being invoked again.
+
+The JSONWs and XMLWs facilities create a Store (grncIdempotencyStore) that is injected into every handler. By default this is
+a MemoryStore, which is only suitable for applications running as a single instance. Applications running multiple instances
+should set WS.Idempotency.Store to RDBMS in configuration to use an RdbmsStore, which shares records through the database
+made available by the RdbmsAccess facility.
+*/
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"hash"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// KeyHeader is the request header in which clients supply an idempotency key.
+	KeyHeader = "Idempotency-Key"
+
+	// ReplayedHeader is added (with the value true) to responses that have been replayed from a Store.
+	ReplayedHeader = "Idempotent-Replayed"
+)
+
+// How often expired records are discarded from a MemoryStore
+const sweepInterval = time.Minute
+
+// Record is the state of a request made with an idempotency key.
+type Record struct {
+	// A hash of the request that first used the key (see Fingerprint).
+	Fingerprint string
+
+	// Whether or not the response to the request has been recorded. False if the request is still being processed.
+	Complete bool
+
+	// The HTTP status code of the response.
+	Status int
+
+	// The headers of the response.
+	Header http.Header
+
+	// The body of the response.
+	Body []byte
+
+	// The time after which the key can be re-used.
+	Expires time.Time
+}
+
+// Store is implemented by components able to record the responses to requests made with an idempotency key.
+type Store interface {
+	// Reserve claims the supplied key for a request with the supplied fingerprint. If the key has not been used (or its
+	// record has expired) an incomplete Record is stored and nil is returned. Otherwise the existing Record is returned.
+	Reserve(ctx context.Context, key string, fingerprint string) (*Record, error)
+
+	// Complete records the response to the request that reserved the key.
+	Complete(ctx context.Context, key string, r *Record) error
+
+	// Release discards the reservation of a key, allowing the request to be retried.
+	Release(ctx context.Context, key string) error
+}
+
+// Fingerprint creates a hash of a request's method, URI and body, used to detect a key being re-used for a different request.
+func Fingerprint(req *http.Request, body []byte) string {
+
+	f := NewFingerprinter(req)
+	f.Write(body)
+
+	return f.Fingerprint()
+}
+
+// NewFingerprinter creates a Fingerprinter for the supplied request.
+func NewFingerprinter(req *http.Request) *Fingerprinter {
+
+	f := new(Fingerprinter)
+	f.hash = sha256.New()
+	f.hash.Write([]byte(req.Method + " " + req.URL.RequestURI() + "\n"))
+
+	return f
+}
+
+// Fingerprinter calculates the same fingerprint as Fingerprint without holding the request's body in memory. The body
+// is written to the Fingerprinter as it is read (e.g. using io.TeeReader).
+type Fingerprinter struct {
+	hash hash.Hash
+}
+
+// Write adds the next part of the request's body to the fingerprint.
+func (f *Fingerprinter) Write(b []byte) (int, error) {
+	return f.hash.Write(b)
+}
+
+// Fingerprint returns the fingerprint of the request and the body written so far.
+func (f *Fingerprinter) Fingerprint() string {
+	return base64.RawURLEncoding.EncodeToString(f.hash.Sum(nil))
+}
+
+// MemoryStore is a Store that holds records in memory. Records are not shared between instances of an application and are lost
+// when the application stops.
+type MemoryStore struct {
+	// The time (in milliseconds) for which a key is retained after it is first used.
+	ExpiryMS int
+
+	records   map[string]*Record
+	lastSweep time.Time
+	mutex     sync.Mutex
+	now       func() time.Time
+}
+
+// Reserve implements Store.Reserve
+func (ms *MemoryStore) Reserve(ctx context.Context, key string, fingerprint string) (*Record, error) {
+
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	now := ms.currentTime()
+
+	if ms.records == nil {
+		ms.records = make(map[string]*Record)
+	}
+
+	ms.sweep(now)
+
+	if r := ms.records[key]; r != nil && now.Before(r.Expires) {
+		return r, nil
+	}
+
+	ms.records[key] = &Record{Fingerprint: fingerprint, Expires: now.Add(time.Duration(ms.ExpiryMS) * time.Millisecond)}
+
+	return nil, nil
+}
+
+// Complete implements Store.Complete
+func (ms *MemoryStore) Complete(ctx context.Context, key string, r *Record) error {
+
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	if existing := ms.records[key]; existing != nil {
+		r.Expires = existing.Expires
+		r.Complete = true
+
+		ms.records[key] = r
+	}
+
+	return nil
+}
+
+// Release implements Store.Release
+func (ms *MemoryStore) Release(ctx context.Context, key string) error {
+
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+
+	delete(ms.records, key)
+
+	return nil
+}
+
+func (ms *MemoryStore) currentTime() time.Time {
+
+	if ms.now == nil {
+		return time.Now()
+	}
+
+	return ms.now()
+}
+
+func (ms *MemoryStore) sweep(now time.Time) {
+
+	if now.Sub(ms.lastSweep) < sweepInterval {
+		return
+	}
+
+	ms.lastSweep = now
+
+	for k, r := range ms.records {
+		if !now.Before(r.Expires) {
+			delete(ms.records, k)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package idempotency
+
+import (
+	"context"
+	"github.com/graniticio/granitic/v2/test"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore(t *testing.T) {
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	ms := &MemoryStore{ExpiryMS: 1000}
+	ms.now = func() time.Time { return now }
+
+	ctx := context.Background()
+
+	r, err := ms.Reserve(ctx, "k", "f")
+
+	test.ExpectNil(t, err)
+	test.ExpectBool(t, r == nil, true)
+
+	r, _ = ms.Reserve(ctx, "k", "f")
+
+	test.ExpectBool(t, r.Complete, false)
+	test.ExpectString(t, r.Fingerprint, "f")
+
+	ms.Complete(ctx, "k", &Record{Fingerprint: "f", Status: http.StatusCreated, Body: []byte("body")})
+
+	r, _ = ms.Reserve(ctx, "k", "other")
+
+	test.ExpectBool(t, r.Complete, true)
+	test.ExpectInt(t, r.Status, http.StatusCreated)
+	test.ExpectString(t, string(r.Body), "body")
+
+	// Expired keys can be re-used
+	now = now.Add(time.Second)
+
+	r, _ = ms.Reserve(ctx, "k", "other")
+	test.ExpectBool(t, r == nil, true)
+
+	ms.Release(ctx, "k")
+
+	r, _ = ms.Reserve(ctx, "k", "f")
+	test.ExpectBool(t, r == nil, true)
+
+	// Completing a released key has no effect
+	ms.Release(ctx, "k")
+	ms.Complete(ctx, "k", &Record{Fingerprint: "f"})
+
+	r, _ = ms.Reserve(ctx, "k", "f")
+	test.ExpectBool(t, r == nil, true)
+}
+
+func TestFingerprint(t *testing.T) {
+
+	post := httptest.NewRequest(http.MethodPost, "/orders?x=1", nil)
+
+	f := Fingerprint(post, []byte(`{"a":1}`))
+
+	test.ExpectString(t, Fingerprint(post, []byte(`{"a":1}`)), f)
+	test.ExpectBool(t, Fingerprint(post, []byte(`{"a":2}`)) != f, true)
+	test.ExpectBool(t, Fingerprint(httptest.NewRequest(http.MethodPost, "/orders?x=2", nil), []byte(`{"a":1}`)) != f, true)
+	test.ExpectBool(t, Fingerprint(httptest.NewRequest(http.MethodPut, "/orders?x=1", nil), []byte(`{"a":1}`)) != f, true)
+}
@@ -0,0 +1,169 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package idempotency
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/graniticio/granitic/v2/rdbms"
+	"net/http"
+	"time"
+)
+
+/*
+RdbmsStore is a Store that holds records in a database table, allowing them to be shared by multiple instances of an application.
+The SQL used to manipulate records is supplied by your application as query templates (see the QueryManager facility) with the
+IDs set in the ...QueryID fields. The templates are passed the parameters Key, Fingerprint, Complete, Status, Headers, Body and
+Expires, and the select query must return columns with the same names (excluding Key). Expires is a Unix time in milliseconds
+and Headers and Body are text.
+
+The insert query must fail if a record with the same key already exists (e.g. because Key is the table's primary key).
+*/
+type RdbmsStore struct {
+	// Injected by the RdbmsAccess facility.
+	DbClientManager rdbms.ClientManager
+
+	// The time (in milliseconds) for which a key is retained after it is first used.
+	ExpiryMS int
+
+	// The ID of a query that deletes the record with the supplied Key.
+	DeleteQueryID string
+
+	// The ID of a query that inserts a new record.
+	InsertQueryID string
+
+	// The ID of a query that selects the record with the supplied Key.
+	SelectQueryID string
+
+	// The ID of a query that updates the Complete, Status, Headers and Body of the record with the supplied Key.
+	UpdateQueryID string
+}
+
+// storedRecord is the representation of a Record passed to and from queries
+type storedRecord struct {
+	Key         string
+	Fingerprint string
+	Complete    bool
+	Status      int64
+	Headers     string
+	Body        string
+	Expires     int64
+}
+
+// Reserve implements Store.Reserve
+func (rs *RdbmsStore) Reserve(ctx context.Context, key string, fingerprint string) (*Record, error) {
+
+	c, err := rs.client(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := rs.find(c, key)
+
+	if err != nil || r != nil {
+		return r, err
+	}
+
+	sr := storedRecord{Key: key, Fingerprint: fingerprint, Headers: "{}", Expires: toMillis(time.Now().Add(time.Duration(rs.ExpiryMS) * time.Millisecond))}
+
+	if _, err = c.InsertQIDParams(rs.InsertQueryID, &sr); err != nil {
+
+		// Another request may have reserved the key since it was checked
+		if r, _ = rs.find(c, key); r != nil {
+			return r, nil
+		}
+
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// Complete implements Store.Complete
+func (rs *RdbmsStore) Complete(ctx context.Context, key string, r *Record) error {
+
+	c, err := rs.client(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	h, err := json.Marshal(r.Header)
+
+	if err != nil {
+		return err
+	}
+
+	sr := storedRecord{Key: key, Fingerprint: r.Fingerprint, Complete: true, Status: int64(r.Status), Headers: string(h),
+		Body: base64.StdEncoding.EncodeToString(r.Body)}
+
+	_, err = c.UpdateQIDParams(rs.UpdateQueryID, &sr)
+
+	return err
+}
+
+// Release implements Store.Release
+func (rs *RdbmsStore) Release(ctx context.Context, key string) error {
+
+	c, err := rs.client(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = c.DeleteQIDParam(rs.DeleteQueryID, "Key", key)
+
+	return err
+}
+
+// find returns the unexpired record with the supplied key, deleting it if it has expired
+func (rs *RdbmsStore) find(c rdbms.Client, key string) (*Record, error) {
+
+	var sr storedRecord
+
+	found, err := c.SelectBindSingleQIDParam(rs.SelectQueryID, "Key", key, &sr)
+
+	if err != nil || !found {
+		return nil, err
+	}
+
+	if toMillis(time.Now()) >= sr.Expires {
+		_, err = c.DeleteQIDParam(rs.DeleteQueryID, "Key", key)
+
+		return nil, err
+	}
+
+	r := new(Record)
+	r.Fingerprint = sr.Fingerprint
+	r.Complete = sr.Complete
+	r.Status = int(sr.Status)
+	r.Expires = time.Unix(0, sr.Expires*int64(time.Millisecond))
+	r.Header = make(http.Header)
+
+	if err = json.Unmarshal([]byte(sr.Headers), &r.Header); err != nil {
+		return nil, err
+	}
+
+	if r.Body, err = base64.StdEncoding.DecodeString(sr.Body); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (rs *RdbmsStore) client(ctx context.Context) (rdbms.Client, error) {
+
+	if rs.DbClientManager == nil {
+		return nil, errors.New("no rdbms.ClientManager has been injected into the idempotency store - make sure the RdbmsAccess facility is enabled")
+	}
+
+	return rs.DbClientManager.ClientFromContext(ctx)
+}
+
+func toMillis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
@@ -0,0 +1,124 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/graniticio/granitic/v2/rdbms"
+	"github.com/graniticio/granitic/v2/test"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type mockClientManager struct {
+	client *mockClient
+}
+
+func (cm *mockClientManager) Client() (rdbms.Client, error) {
+	return cm.client, nil
+}
+
+func (cm *mockClientManager) ClientFromContext(ctx context.Context) (rdbms.Client, error) {
+	return cm.client, nil
+}
+
+// mockClient holds rows in a map, implementing only the methods of rdbms.Client used by RdbmsStore
+type mockClient struct {
+	rdbms.Client
+	rows    map[string]storedRecord
+	queries []string
+}
+
+func (mc *mockClient) SelectBindSingleQIDParam(qid string, name string, value interface{}, target interface{}) (bool, error) {
+	mc.queries = append(mc.queries, qid)
+
+	sr, found := mc.rows[value.(string)]
+
+	if found {
+		*target.(*storedRecord) = sr
+	}
+
+	return found, nil
+}
+
+func (mc *mockClient) InsertQIDParams(qid string, params ...interface{}) (sql.Result, error) {
+	mc.queries = append(mc.queries, qid)
+
+	sr := params[0].(*storedRecord)
+
+	if _, found := mc.rows[sr.Key]; found {
+		return nil, errors.New("duplicate key")
+	}
+
+	mc.rows[sr.Key] = *sr
+
+	return nil, nil
+}
+
+func (mc *mockClient) UpdateQIDParams(qid string, params ...interface{}) (sql.Result, error) {
+	mc.queries = append(mc.queries, qid)
+
+	sr := params[0].(*storedRecord)
+	sr.Expires = mc.rows[sr.Key].Expires
+
+	mc.rows[sr.Key] = *sr
+
+	return nil, nil
+}
+
+func (mc *mockClient) DeleteQIDParam(qid string, name string, value interface{}) (sql.Result, error) {
+	mc.queries = append(mc.queries, qid)
+
+	delete(mc.rows, value.(string))
+
+	return nil, nil
+}
+
+func TestRdbmsStore(t *testing.T) {
+
+	mc := &mockClient{rows: make(map[string]storedRecord)}
+
+	rs := &RdbmsStore{DbClientManager: &mockClientManager{mc}, ExpiryMS: 60000,
+		SelectQueryID: "sel", InsertQueryID: "ins", UpdateQueryID: "upd", DeleteQueryID: "del"}
+
+	ctx := context.Background()
+
+	r, err := rs.Reserve(ctx, "k", "f")
+
+	test.ExpectNil(t, err)
+	test.ExpectBool(t, r == nil, true)
+	test.ExpectInt(t, len(mc.queries), 2)
+	test.ExpectString(t, mc.queries[1], "ins")
+
+	r, _ = rs.Reserve(ctx, "k", "f")
+	test.ExpectBool(t, r.Complete, false)
+
+	h := make(http.Header)
+	h.Set("Content-Type", "application/json")
+
+	test.ExpectNil(t, rs.Complete(ctx, "k", &Record{Fingerprint: "f", Status: http.StatusCreated, Header: h, Body: []byte{0, 1, 2}}))
+
+	r, err = rs.Reserve(ctx, "k", "f")
+
+	test.ExpectNil(t, err)
+	test.ExpectBool(t, r.Complete, true)
+	test.ExpectInt(t, r.Status, http.StatusCreated)
+	test.ExpectString(t, r.Header.Get("Content-Type"), "application/json")
+	test.ExpectInt(t, len(r.Body), 3)
+
+	test.ExpectNil(t, rs.Release(ctx, "k"))
+	test.ExpectInt(t, len(mc.rows), 0)
+
+	// Expired records are deleted
+	mc.rows["old"] = storedRecord{Key: "old", Fingerprint: "f", Headers: "{}", Expires: toMillis(time.Now().Add(-time.Second))}
+
+	r, _ = rs.Reserve(ctx, "old", "f")
+	test.ExpectBool(t, r == nil, true)
+	test.ExpectBool(t, mc.rows["old"].Expires > toMillis(time.Now()), true)
+
+	rs.DbClientManager = nil
+
+	_, err = rs.Reserve(ctx, "k", "f")
+	test.ExpectBool(t, err != nil, true)
+}
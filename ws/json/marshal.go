@@ -20,6 +20,7 @@ For more information on this behaviour (and how to override it) see: https://gra
 Error formatting
 
 Any service errors found in a response are formatted by GraniticJSONErrorFormatter before being serialised to JSON.
+Alternatively, ProblemJSONErrorFormatter can be used to render errors as RFC 7807 problem details documents.
 For more information on this behaviour (and how to override it) see: https://granitic.io/ref/json-web-services
 
 Compatibility with existing service APIs
@@ -109,6 +110,9 @@ func (rw *BodyOrErrorWrapper) WrapResponse(body interface{}, errors interface{})
 type GraniticJSONResponseWrapper struct {
 	ErrorsFieldName string
 	BodyFieldName   string
+
+	// The name of the field describing the page of results in paginated responses. If empty, pages are not described.
+	PageFieldName string
 }
 
 // WrapResponse creates a map[string]string to wrap the supplied response body and errors.
@@ -126,6 +130,18 @@ func (rw *GraniticJSONResponseWrapper) WrapResponse(body interface{}, errors int
 	return f
 }
 
+// WrapPage implements ws.PageWrapper, adding a description of the page of results to the map created by WrapResponse
+// (if PageFieldName is set).
+func (rw *GraniticJSONResponseWrapper) WrapPage(body interface{}, errors interface{}, page *ws.Page) interface{} {
+	f := rw.WrapResponse(body, errors).(map[string]interface{})
+
+	if rw.PageFieldName != "" && page != nil {
+		f[rw.PageFieldName] = page
+	}
+
+	return f
+}
+
 // GraniticJSONErrorFormatter converts service errors into a data structure for consistent serialisation to JSON.
 type GraniticJSONErrorFormatter struct{}
 
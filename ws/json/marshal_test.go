@@ -2,6 +2,7 @@ package json
 
 import (
 	"context"
+	"encoding/json"
 	"github.com/graniticio/granitic/v2/ws"
 	"io/ioutil"
 	"net/http"
@@ -53,3 +54,34 @@ func TestUnmarshalling(t *testing.T) {
 type target struct {
 	A int64
 }
+
+func TestPageWrapping(t *testing.T) {
+
+	rw := &GraniticJSONResponseWrapper{ErrorsFieldName: "Errors", BodyFieldName: "Response", PageFieldName: "Page"}
+
+	var pw ws.PageWrapper = rw
+
+	page := ws.NewOffsetPage(&ws.OffsetPageRequest{Page: 1, Size: 2}, 3)
+
+	w := pw.WrapPage([]string{"a", "b"}, nil, page).(map[string]interface{})
+
+	b, err := json.Marshal(w)
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	expected := `{"Page":{"Number":1,"Size":2,"TotalItems":3,"TotalPages":2,"HasNext":true,"HasPrevious":false},"Response":["a","b"]}`
+
+	if string(b) != expected {
+		t.Errorf("Unexpected JSON %s", b)
+	}
+
+	rw.PageFieldName = ""
+
+	w = pw.WrapPage([]string{"a"}, nil, page).(map[string]interface{})
+
+	if _, found := w["Page"]; found {
+		t.Errorf("Page included when PageFieldName is empty")
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package json
+
+import (
+	"github.com/graniticio/granitic/v2/ws"
+	"net/http"
+	"strings"
+)
+
+// ProblemJSONContentType is the media type of RFC 7807 problem details documents serialised as JSON.
+const ProblemJSONContentType = "application/problem+json"
+
+const blankProblemType = "about:blank"
+
+// ProblemDetails is the structure of an RFC 7807 problem details document (see https://tools.ietf.org/html/rfc7807)
+type ProblemDetails struct {
+	// A URI identifying the type of problem.
+	Type string `json:"type"`
+
+	// A short, human-readable summary of the type of problem.
+	Title string `json:"title,omitempty"`
+
+	// The HTTP status code of the response.
+	Status int `json:"status,omitempty"`
+
+	// A human-readable explanation specific to this occurrence of the problem.
+	Detail string `json:"detail,omitempty"`
+
+	// A URI reference identifying this occurrence of the problem.
+	Instance string `json:"instance,omitempty"`
+
+	// Problems with specific fields or parameters in the request.
+	InvalidParams []InvalidParam `json:"invalid-params,omitempty"`
+}
+
+// InvalidParam describes a problem with a specific field or parameter in a request. Used in the invalid-params
+// extension of a ProblemDetails document.
+type InvalidParam struct {
+	// The name of the field or parameter.
+	Name string `json:"name"`
+
+	// A human-readable explanation of the problem with the field.
+	Reason string `json:"reason"`
+
+	// The code of the service error associated with the field.
+	Code string `json:"code,omitempty"`
+}
+
+// ProblemJSONErrorFormatter converts service errors into RFC 7807 problem details documents. It implements
+// ws.DocumentErrorFormatter, so responses containing errors are sent with a Content-Type of application/problem+json.
+//
+// Errors associated with a field are listed in the invalid-params extension member. The messages of any other errors are
+// combined to form the document's detail member.
+type ProblemJSONErrorFormatter struct {
+	// If set, the problem's type is formed by appending the code of the first error in the response to this URI. If not
+	// set, the type is about:blank.
+	TypeBaseURI string
+}
+
+// FormatErrors implements ws.ErrorFormatter.FormatErrors, using the HTTP status recorded on the supplied errors.
+func (pf *ProblemJSONErrorFormatter) FormatErrors(errors *ws.ServiceErrors) interface{} {
+
+	if errors == nil || !errors.HasErrors() {
+		return nil
+	}
+
+	return pf.FormatErrorDocument(errors, errors.HTTPStatus, nil)
+}
+
+// FormatErrorDocument implements ws.DocumentErrorFormatter.FormatErrorDocument, returning a *ProblemDetails.
+func (pf *ProblemJSONErrorFormatter) FormatErrorDocument(errors *ws.ServiceErrors, status int, req *ws.Request) interface{} {
+
+	pd := new(ProblemDetails)
+	pd.Type = blankProblemType
+	pd.Title = http.StatusText(status)
+	pd.Status = status
+
+	if req != nil {
+		pd.Instance = req.Path
+	}
+
+	if errors == nil {
+		return pd
+	}
+
+	if pf.TypeBaseURI != "" && len(errors.Errors) > 0 {
+		pd.Type = pf.TypeBaseURI + errors.Errors[0].Code
+	}
+
+	var details []string
+
+	for _, e := range errors.Errors {
+
+		if e.Field == "" {
+			details = append(details, e.Message)
+			continue
+		}
+
+		pd.InvalidParams = append(pd.InvalidParams, InvalidParam{Name: e.Field, Reason: e.Message, Code: e.Code})
+	}
+
+	pd.Detail = strings.Join(details, " ")
+
+	return pd
+}
+
+// ContentType implements ws.DocumentErrorFormatter.ContentType
+func (pf *ProblemJSONErrorFormatter) ContentType() string {
+	return ProblemJSONContentType
+}
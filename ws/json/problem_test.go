@@ -0,0 +1,75 @@
+package json
+
+import (
+	"context"
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"github.com/graniticio/granitic/v2/ws"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProblemDetailsFormatting(t *testing.T) {
+
+	e := new(ws.ServiceErrors)
+	e.AddError(ws.NewCategorisedError(ws.Client, "NOSTOCK", "Out of stock."))
+	e.AddError(&ws.CategorisedError{Category: ws.Client, Code: "QTY", Message: "Quantity must be positive.", Field: "Quantity"})
+	e.HTTPStatus = http.StatusBadRequest
+
+	pf := new(ProblemJSONErrorFormatter)
+
+	pd := pf.FormatErrorDocument(e, http.StatusConflict, &ws.Request{Path: "/orders/1"}).(*ProblemDetails)
+
+	test.ExpectString(t, pd.Type, "about:blank")
+	test.ExpectString(t, pd.Title, "Conflict")
+	test.ExpectInt(t, pd.Status, http.StatusConflict)
+	test.ExpectString(t, pd.Detail, "Out of stock.")
+	test.ExpectString(t, pd.Instance, "/orders/1")
+	test.ExpectInt(t, len(pd.InvalidParams), 1)
+	test.ExpectString(t, pd.InvalidParams[0].Name, "Quantity")
+	test.ExpectString(t, pd.InvalidParams[0].Code, "QTY")
+
+	pf.TypeBaseURI = "https://example.com/problems/"
+
+	pd = pf.FormatErrors(e).(*ProblemDetails)
+
+	test.ExpectString(t, pd.Type, "https://example.com/problems/NOSTOCK")
+	test.ExpectInt(t, pd.Status, http.StatusBadRequest)
+	test.ExpectString(t, pd.Instance, "")
+
+	test.ExpectBool(t, pf.FormatErrors(new(ws.ServiceErrors)) == nil, true)
+}
+
+func TestProblemDetailsResponse(t *testing.T) {
+
+	sd := new(ws.GraniticHTTPStatusCodeDeterminer)
+	sd.Client = http.StatusBadRequest
+
+	rw := new(ws.MarshallingResponseWriter)
+	rw.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	rw.StatusDeterminer = sd
+	rw.DefaultHeaders = map[string]string{"Content-Type": "application/json; charset=utf-8"}
+	rw.ErrorFormatter = new(ProblemJSONErrorFormatter)
+	rw.ResponseWrapper = new(GraniticJSONResponseWrapper)
+	rw.MarshalingWriter = new(MarshalingWriter)
+
+	e := new(ws.ServiceErrors)
+	e.AddError(&ws.CategorisedError{Category: ws.Client, Code: "QTY", Message: "Quantity must be positive.", Field: "Quantity"})
+
+	rec := httptest.NewRecorder()
+
+	state := new(ws.ProcessState)
+	state.HTTPResponseWriter = httpendpoint.NewHTTPResponseWriter(rec)
+	state.ServiceErrors = e
+	state.WsRequest = &ws.Request{Path: "/orders"}
+
+	if err := rw.Write(context.Background(), state, ws.Error); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	test.ExpectInt(t, rec.Code, http.StatusBadRequest)
+	test.ExpectString(t, rec.Header().Get("Content-Type"), ProblemJSONContentType)
+	test.ExpectString(t, rec.Body.String(), `{"type":"about:blank","title":"Bad Request","status":400,"instance":"/orders","invalid-params":[{"name":"Quantity","reason":"Quantity must be positive.","code":"QTY"}]}`)
+}
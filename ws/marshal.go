@@ -45,6 +45,10 @@ type MarshallingResponseWriter struct {
 	// Component able to serialize the data to the HTTP output stream.
 	MarshalingWriter MarshalingWriter
 
+	// Component able to write responses whose Body is a *StreamedResponse. If not set, streamed responses are written
+	// without heartbeats.
+	Streamer *ResponseStreamer
+
 	// Whether or not the unique ID assigned to the request should be written as a response header
 	IncludeRequestID bool
 
@@ -77,17 +81,17 @@ func (rw *MarshallingResponseWriter) Write(ctx context.Context, state *ProcessSt
 
 	switch outcome {
 	case Normal:
-		return rw.write(ctx, state.WsResponse, state.HTTPResponseWriter, ch)
+		return rw.write(ctx, state.WsResponse, req, state.HTTPResponseWriter, ch)
 	case Error:
-		return rw.writeErrors(ctx, state.ServiceErrors, state.HTTPResponseWriter, ch)
+		return rw.writeErrors(ctx, state.ServiceErrors, req, state.HTTPResponseWriter, ch)
 	case Abnormal:
-		return rw.writeAbnormalStatus(ctx, state.Status, state.HTTPResponseWriter, ch)
+		return rw.writeAbnormalStatus(ctx, state.Status, req, state.HTTPResponseWriter, ch)
 	}
 
 	return errors.New("Unsuported Outcome value")
 }
 
-func (rw *MarshallingResponseWriter) write(ctx context.Context, res *Response, w *httpendpoint.HTTPResponseWriter, ch map[string]string) error {
+func (rw *MarshallingResponseWriter) write(ctx context.Context, res *Response, req *Request, w *httpendpoint.HTTPResponseWriter, ch map[string]string) error {
 
 	if w.DataSent {
 		//This HTTP response has already been written to by another component - not safe to continue
@@ -99,50 +103,93 @@ func (rw *MarshallingResponseWriter) write(ctx context.Context, res *Response, w
 	}
 
 	headers := MergeHeaders(res, ch, rw.DefaultHeaders)
-	WriteHeaders(w, headers)
 
 	s := rw.StatusDeterminer.DetermineCode(res)
+	e := res.Errors
+
+	df, document := rw.ErrorFormatter.(DocumentErrorFormatter)
+	document = document && e.HasErrors()
+
+	if document {
+		headers["Content-Type"] = df.ContentType()
+	}
+
+	sr, streamed := res.Body.(*StreamedResponse)
+	streamed = streamed && !e.HasErrors()
+
+	if streamed {
+		headers["Content-Type"] = sr.Format.ContentType()
+		headers["Cache-Control"] = "no-cache"
+	}
+
+	WriteHeaders(w, headers)
 	w.WriteHeader(s)
 
-	e := res.Errors
+	if streamed {
+		return rw.streamer().WriteStream(ctx, sr, w)
+	}
 
 	if res.Body == nil && !e.HasErrors() {
 		return nil
 	}
 
+	if document {
+		// The error document replaces the body (and any wrapping) entirely
+		return rw.MarshalingWriter.MarshalAndWrite(df.FormatErrorDocument(e, s, req), w)
+	}
+
 	ef := rw.ErrorFormatter
 	wrap := rw.ResponseWrapper
 
 	fe := ef.FormatErrors(e)
-	wrapper := wrap.WrapResponse(res.Body, fe)
+
+	var wrapper interface{}
+
+	if pw, found := wrap.(PageWrapper); found && res.Page != nil && !e.HasErrors() {
+		wrapper = pw.WrapPage(res.Body, fe, res.Page)
+	} else {
+		wrapper = wrap.WrapResponse(res.Body, fe)
+	}
 
 	return rw.MarshalingWriter.MarshalAndWrite(wrapper, w)
 }
 
+func (rw *MarshallingResponseWriter) streamer() *ResponseStreamer {
+
+	if rw.Streamer == nil {
+		return &ResponseStreamer{FrameworkLogger: rw.FrameworkLogger}
+	}
+
+	return rw.Streamer
+}
+
 // WriteAbnormalStatus implements AbnormalStatusWriter.WriteAbnormalStatus
 func (rw *MarshallingResponseWriter) WriteAbnormalStatus(ctx context.Context, state *ProcessState) error {
 	return rw.Write(ctx, state, Abnormal)
 }
 
-func (rw *MarshallingResponseWriter) writeAbnormalStatus(ctx context.Context, status int, w *httpendpoint.HTTPResponseWriter, ch map[string]string) error {
+func (rw *MarshallingResponseWriter) writeAbnormalStatus(ctx context.Context, status int, req *Request, w *httpendpoint.HTTPResponseWriter, ch map[string]string) error {
 
 	res := new(Response)
 	res.HTTPStatus = status
+
 	var errors ServiceErrors
 
-	e := rw.FrameworkErrors.HTTPError(status)
-	errors.AddError(e)
+	if StatusAllowsBody(status) {
+		e := rw.FrameworkErrors.HTTPError(status)
+		errors.AddError(e)
+	}
 
 	res.Errors = &errors
 
-	return rw.write(ctx, res, w, ch)
+	return rw.write(ctx, res, req, w, ch)
 
 }
 
-func (rw *MarshallingResponseWriter) writeErrors(ctx context.Context, errors *ServiceErrors, w *httpendpoint.HTTPResponseWriter, ch map[string]string) error {
+func (rw *MarshallingResponseWriter) writeErrors(ctx context.Context, errors *ServiceErrors, req *Request, w *httpendpoint.HTTPResponseWriter, ch map[string]string) error {
 
 	res := new(Response)
 	res.Errors = errors
 
-	return rw.write(ctx, res, w, ch)
+	return rw.write(ctx, res, req, w, ch)
 }
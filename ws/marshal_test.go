@@ -36,6 +36,47 @@ func TestMarshalAbnormal(t *testing.T) {
 
 }
 
+func TestMarshalAbnormalWithoutBody(t *testing.T) {
+
+	mrw := new(MarshallingResponseWriter)
+
+	feg := new(FrameworkErrorGenerator)
+	feg.FrameworkLogger = new(logging.ConsoleErrorLogger)
+
+	mrw.FrameworkErrors = feg
+	mrw.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	mrw.StatusDeterminer = NewGraniticHTTPStatusCodeDeterminer()
+	mrw.ErrorFormatter = new(mockErrorFormatter)
+	mrw.ResponseWrapper = new(mockResponseWrapper)
+
+	for status, expectBody := range map[int]bool{http.StatusNoContent: false, http.StatusNotModified: false, http.StatusMethodNotAllowed: true} {
+
+		mw := new(recordingWriter)
+		mrw.MarshalingWriter = mw
+
+		ps := new(ProcessState)
+		ps.Status = status
+		ps.HTTPResponseWriter = httpendpoint.NewHTTPResponseWriter(new(resWriter))
+
+		if err := mrw.WriteAbnormalStatus(context.Background(), ps); err != nil {
+			t.Fatal(err.Error())
+		}
+
+		if mw.called != expectBody {
+			t.Errorf("Status %d: expected body to be written %v", status, expectBody)
+		}
+	}
+}
+
+type recordingWriter struct {
+	called bool
+}
+
+func (rw *recordingWriter) MarshalAndWrite(data interface{}, w http.ResponseWriter) error {
+	rw.called = true
+	return nil
+}
+
 type resWriter struct {
 	sw bytes.Buffer
 }
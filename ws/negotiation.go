@@ -0,0 +1,230 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package ws
+
+import (
+	"context"
+	"errors"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type negotiatedTypeKey string
+
+const negotiatedType negotiatedTypeKey = "GRNCNEGTYPE"
+
+// ContentNegotiator is implemented by ResponseWriters that are able to render responses in more than one format.
+type ContentNegotiator interface {
+	// Negotiate chooses the format of the response based on the request's Accept header and records the choice in the
+	// returned context. Returns false if none of the available formats are acceptable to the client.
+	Negotiate(ctx context.Context, req *http.Request) (context.Context, bool)
+}
+
+// NegotiatedMediaType returns the media type chosen for the response to the current request by a ContentNegotiator, or
+// an empty string if no negotiation has taken place.
+func NegotiatedMediaType(ctx context.Context) string {
+
+	if mt, found := ctx.Value(negotiatedType).(string); found {
+		return mt
+	}
+
+	return ""
+}
+
+// NegotiatingResponseWriter is a ResponseWriter that delegates to one of several other ResponseWriters based on the media
+// types the client will accept (as declared in the request's Accept header).
+type NegotiatingResponseWriter struct {
+	// ResponseWriters, keyed by the media type they produce (e.g. application/json).
+	Writers map[string]ResponseWriter
+
+	// The media types that may be chosen, in order of preference. The first is used when the client has no preference
+	// and for responses written before negotiation has taken place.
+	MediaTypes []string
+}
+
+// Negotiate implements ContentNegotiator.Negotiate
+func (nw *NegotiatingResponseWriter) Negotiate(ctx context.Context, req *http.Request) (context.Context, bool) {
+
+	mt := negotiateMediaType(req.Header.Get("Accept"), nw.MediaTypes)
+
+	if mt == "" {
+		return ctx, false
+	}
+
+	return context.WithValue(ctx, negotiatedType, mt), true
+}
+
+// Write implements ResponseWriter.Write, delegating to the ResponseWriter for the negotiated media type.
+func (nw *NegotiatingResponseWriter) Write(ctx context.Context, state *ProcessState, outcome Outcome) error {
+
+	rw := nw.writer(ctx)
+
+	if rw == nil {
+		return errors.New("no ResponseWriter available for the negotiated media type")
+	}
+
+	if w := state.HTTPResponseWriter; w != nil && !w.DataSent {
+		w.Header().Add("Vary", "Accept")
+	}
+
+	return rw.Write(ctx, state, outcome)
+}
+
+// WriteAbnormalStatus implements AbnormalStatusWriter.WriteAbnormalStatus
+func (nw *NegotiatingResponseWriter) WriteAbnormalStatus(ctx context.Context, state *ProcessState) error {
+	return nw.Write(ctx, state, Abnormal)
+}
+
+func (nw *NegotiatingResponseWriter) writer(ctx context.Context) ResponseWriter {
+
+	mt := NegotiatedMediaType(ctx)
+
+	if mt == "" && len(nw.MediaTypes) > 0 {
+		mt = nw.MediaTypes[0]
+	}
+
+	return nw.Writers[mt]
+}
+
+// NegotiatingUnmarshaller is an Unmarshaller that delegates to one of several other Unmarshallers based on the
+// request's Content-Type header.
+type NegotiatingUnmarshaller struct {
+	// Unmarshallers, keyed by the media type they are able to parse (e.g. application/json).
+	Unmarshallers map[string]Unmarshaller
+
+	// The media type assumed if a request has a body but no Content-Type header.
+	DefaultMediaType string
+
+	// Source of the error recorded when a request's content type is not supported.
+	FrameworkErrors *FrameworkErrorGenerator
+}
+
+// Unmarshall implements Unmarshaller.Unmarshall. If there is no Unmarshaller for the request's content type, an
+// UnsupportedContentType framework error is recorded on the supplied Request, resulting in an HTTP 415 response.
+func (nu *NegotiatingUnmarshaller) Unmarshall(ctx context.Context, req *http.Request, wsReq *Request) error {
+
+	ct := req.Header.Get("Content-Type")
+	mt := nu.DefaultMediaType
+
+	if ct != "" {
+
+		parsed, _, err := mime.ParseMediaType(ct)
+
+		if err != nil {
+			parsed = ""
+		}
+
+		mt = strings.ToLower(parsed)
+	}
+
+	um := nu.Unmarshallers[mt]
+
+	if um == nil {
+		m, c := nu.FrameworkErrors.MessageCode(UnsupportedContentType, ct)
+
+		f := NewUnmarshallFrameworkError(m, c)
+		f.HTTPStatus = http.StatusUnsupportedMediaType
+
+		wsReq.AddFrameworkError(f)
+
+		return nil
+	}
+
+	return um.Unmarshall(ctx, req, wsReq)
+}
+
+type acceptedRange struct {
+	mediaRange string
+	quality    float64
+}
+
+// negotiateMediaType chooses the available media type most preferred by the client, using the order of available
+// types to break ties. Returns an empty string if no available type is acceptable.
+func negotiateMediaType(accept string, available []string) string {
+
+	if len(available) == 0 {
+		return ""
+	}
+
+	if strings.TrimSpace(accept) == "" {
+		return available[0]
+	}
+
+	var ranges []acceptedRange
+
+	for _, part := range strings.Split(accept, ",") {
+
+		fields := strings.Split(part, ";")
+		ar := acceptedRange{mediaRange: strings.ToLower(strings.TrimSpace(fields[0])), quality: 1.0}
+
+		for _, p := range fields[1:] {
+
+			p = strings.TrimSpace(p)
+
+			if strings.HasPrefix(p, "q=") {
+				if f, err := strconv.ParseFloat(p[2:], 64); err == nil {
+					ar.quality = f
+				}
+			}
+		}
+
+		ranges = append(ranges, ar)
+	}
+
+	// More specific ranges take precedence over wildcards
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return specificity(ranges[i].mediaRange) > specificity(ranges[j].mediaRange)
+	})
+
+	best := ""
+	bestQ := 0.0
+
+	for _, mt := range available {
+
+		for _, ar := range ranges {
+
+			if !mediaRangeMatches(ar.mediaRange, mt) {
+				continue
+			}
+
+			if ar.quality > bestQ {
+				best = mt
+				bestQ = ar.quality
+			}
+
+			break
+		}
+	}
+
+	return best
+}
+
+func specificity(mediaRange string) int {
+
+	switch {
+	case mediaRange == "*/*":
+		return 0
+	case strings.HasSuffix(mediaRange, "/*"):
+		return 1
+	}
+
+	return 2
+}
+
+func mediaRangeMatches(mediaRange, mediaType string) bool {
+
+	mediaType = strings.ToLower(mediaType)
+
+	switch {
+	case mediaRange == "*/*":
+		return true
+	case strings.HasSuffix(mediaRange, "/*"):
+		return strings.HasPrefix(mediaType, mediaRange[:len(mediaRange)-1])
+	}
+
+	return mediaRange == mediaType
+}
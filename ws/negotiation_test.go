@@ -0,0 +1,119 @@
+package ws
+
+import (
+	"context"
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type delegateWriter struct {
+	called bool
+}
+
+func (rw *delegateWriter) Write(ctx context.Context, state *ProcessState, outcome Outcome) error {
+	rw.called = true
+	return nil
+}
+
+type recordingUnmarshaller struct {
+	called bool
+}
+
+func (ru *recordingUnmarshaller) Unmarshall(ctx context.Context, req *http.Request, wsReq *Request) error {
+	ru.called = true
+	return nil
+}
+
+func TestNegotiateMediaType(t *testing.T) {
+
+	available := []string{"application/json", "application/xml", "text/xml"}
+
+	test.ExpectString(t, negotiateMediaType("", available), "application/json")
+	test.ExpectString(t, negotiateMediaType("application/xml", available), "application/xml")
+	test.ExpectString(t, negotiateMediaType("text/*", available), "text/xml")
+	test.ExpectString(t, negotiateMediaType("*/*", available), "application/json")
+	test.ExpectString(t, negotiateMediaType("application/json;q=0.5, application/xml", available), "application/xml")
+	test.ExpectString(t, negotiateMediaType("application/*, application/json;q=0", available), "application/xml")
+	test.ExpectString(t, negotiateMediaType("APPLICATION/XML;q=0.9, */*;q=0.1", available), "application/xml")
+	test.ExpectString(t, negotiateMediaType("text/html", available), "")
+	test.ExpectString(t, negotiateMediaType("application/json", nil), "")
+}
+
+func TestNegotiatingResponseWriter(t *testing.T) {
+
+	jw := new(delegateWriter)
+	xw := new(delegateWriter)
+
+	nw := new(NegotiatingResponseWriter)
+	nw.Writers = map[string]ResponseWriter{"application/json": jw, "application/xml": xw}
+	nw.MediaTypes = []string{"application/json", "application/xml"}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "application/xml")
+
+	ctx, okay := nw.Negotiate(context.Background(), req)
+
+	test.ExpectBool(t, okay, true)
+	test.ExpectString(t, NegotiatedMediaType(ctx), "application/xml")
+
+	w := httpendpoint.NewHTTPResponseWriter(httptest.NewRecorder())
+
+	if err := nw.Write(ctx, &ProcessState{HTTPResponseWriter: w}, Normal); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	test.ExpectBool(t, xw.called, true)
+	test.ExpectBool(t, jw.called, false)
+	test.ExpectString(t, w.Header().Get("Vary"), "Accept")
+
+	if err := nw.WriteAbnormalStatus(context.Background(), &ProcessState{}); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	test.ExpectBool(t, jw.called, true)
+
+	req.Header.Set("Accept", "text/html")
+
+	_, okay = nw.Negotiate(context.Background(), req)
+	test.ExpectBool(t, okay, false)
+}
+
+func TestNegotiatingUnmarshaller(t *testing.T) {
+
+	feg := new(FrameworkErrorGenerator)
+	feg.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	feg.Messages = map[FrameworkErrorEvent][]string{
+		UnsupportedContentType: {"CONTENTTYPE", "Requests with a content type of '%s' are not supported."},
+	}
+
+	ju := new(recordingUnmarshaller)
+
+	nu := new(NegotiatingUnmarshaller)
+	nu.Unmarshallers = map[string]Unmarshaller{"application/json": ju}
+	nu.DefaultMediaType = "application/json"
+	nu.FrameworkErrors = feg
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	wsReq := new(Request)
+
+	nu.Unmarshall(context.Background(), req, wsReq)
+
+	test.ExpectBool(t, ju.called, true)
+	test.ExpectInt(t, len(wsReq.FrameworkErrors), 0)
+
+	req.Header.Set("Content-Type", "text/csv; charset=utf-8")
+
+	nu.Unmarshall(context.Background(), req, wsReq)
+
+	test.ExpectInt(t, len(wsReq.FrameworkErrors), 1)
+
+	fe := wsReq.FrameworkErrors[0]
+
+	test.ExpectString(t, fe.Code, "CONTENTTYPE")
+	test.ExpectString(t, fe.Message, "Requests with a content type of 'text/csv; charset=utf-8' are not supported.")
+	test.ExpectInt(t, fe.HTTPStatus, http.StatusUnsupportedMediaType)
+}
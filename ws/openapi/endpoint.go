@@ -0,0 +1,196 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package openapi
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/ws/handler"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+// DescribeHandler uses a started handler.WsHandler (and reflection over its Logic component's request target type) to
+// create an EndpointDescription.
+func DescribeHandler(wh *handler.WsHandler) (*EndpointDescription, error) {
+
+	e := new(EndpointDescription)
+	e.Name = wh.ComponentName()
+	e.HTTPMethod = wh.HTTPMethod
+	e.PathPattern = wh.RegexPattern()
+	e.PathTemplate = wh.PathTemplate
+	e.RequireAuthentication = wh.RequireAuthentication
+	e.AccessChecked = wh.AccessChecker != nil
+
+	if !wh.DisablePathParsing {
+		e.PathParams = wh.BindPathParams
+	}
+
+	if t := wh.RequestTarget(); t != nil {
+		e.Request = SchemaFor(reflect.TypeOf(t))
+	}
+
+	if !wh.DisableQueryParsing {
+		e.QueryParams = queryParams(wh, e.Request)
+	}
+
+	e.HeaderParams = wh.FieldHeader
+	e.CookieParams = wh.FieldCookie
+
+	if rs, found := wh.Logic.(handler.WsResponseBody); found {
+		if b := rs.ResponseBody(); b != nil {
+			e.Response = SchemaFor(reflect.TypeOf(b))
+		}
+	}
+
+	if wh.AutoValidator != nil {
+
+		rules, err := wh.AutoValidator.ResolvedRules()
+
+		if err != nil {
+			return nil, err
+		}
+
+		e.Rules = rules
+	}
+
+	return e, nil
+}
+
+func queryParams(wh *handler.WsHandler, target *Schema) map[string]string {
+
+	if !wh.AutoBindQuery || target == nil {
+		return wh.FieldQueryParam
+	}
+
+	// Query parameters are bound to fields with exactly the same name, as well as those named in FieldQueryParam
+	qp := make(map[string]string)
+
+	for f := range target.fields {
+		qp[f] = f
+	}
+
+	for f, p := range wh.FieldQueryParam {
+		qp[f] = p
+	}
+
+	return qp
+}
+
+// Endpoint is an httpendpoint.Provider that serves an OpenAPI document describing every handler.WsHandler in the
+// IoC container. The document is generated when it is first requested and is then cached.
+type Endpoint struct {
+	// Injected by Granitic
+	FrameworkLogger logging.Logger
+
+	// The path on which the document is served.
+	Path string
+
+	// The component used to convert handlers into a document.
+	Generator *Generator
+
+	container *ioc.ComponentContainer
+	document  []byte
+	mutex     sync.Mutex
+}
+
+// Container allows Granitic to inject a reference to the IoC container
+func (ep *Endpoint) Container(container *ioc.ComponentContainer) {
+	ep.container = container
+}
+
+// SupportedHTTPMethods implements httpendpoint.Provider
+func (ep *Endpoint) SupportedHTTPMethods() []string {
+	return []string{http.MethodGet}
+}
+
+// RegexPattern implements httpendpoint.Provider
+func (ep *Endpoint) RegexPattern() string {
+	return "^" + regexp.QuoteMeta(ep.Path) + "$"
+}
+
+// ServeHTTP writes the OpenAPI document as JSON.
+func (ep *Endpoint) ServeHTTP(ctx context.Context, w *httpendpoint.HTTPResponseWriter, req *http.Request) context.Context {
+
+	b, err := ep.Document()
+
+	if err != nil {
+		ep.FrameworkLogger.LogErrorfCtx(ctx, "Unable to generate OpenAPI document: %s", err.Error())
+		w.WriteHeader(http.StatusInternalServerError)
+		return ctx
+	}
+
+	w.Header().Set("Content-Type", jsonContentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+
+	return ctx
+}
+
+// Document returns the JSON representation of the OpenAPI document, generating it if necessary.
+func (ep *Endpoint) Document() ([]byte, error) {
+
+	ep.mutex.Lock()
+	defer ep.mutex.Unlock()
+
+	if ep.document != nil {
+		return ep.document, nil
+	}
+
+	var handlers []*handler.WsHandler
+
+	for _, c := range ep.container.AllComponents() {
+		if wh, found := c.Instance.(*handler.WsHandler); found {
+			handlers = append(handlers, wh)
+		}
+	}
+
+	sort.Slice(handlers, func(i, j int) bool {
+		return handlers[i].ComponentName() < handlers[j].ComponentName()
+	})
+
+	var descriptions []*EndpointDescription
+
+	for _, wh := range handlers {
+
+		d, err := DescribeHandler(wh)
+
+		if err != nil {
+			return nil, err
+		}
+
+		descriptions = append(descriptions, d)
+	}
+
+	b, err := json.MarshalIndent(ep.Generator.Generate(descriptions), "", "  ")
+
+	if err != nil {
+		return nil, err
+	}
+
+	ep.document = b
+
+	return b, nil
+}
+
+// VersionAware implements httpendpoint.Provider
+func (ep *Endpoint) VersionAware() bool {
+	return false
+}
+
+// SupportsVersion implements httpendpoint.Provider
+func (ep *Endpoint) SupportsVersion(version httpendpoint.RequiredVersion) bool {
+	return true
+}
+
+// AutoWireable implements httpendpoint.Provider
+func (ep *Endpoint) AutoWireable() bool {
+	return true
+}
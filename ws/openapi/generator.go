@@ -0,0 +1,296 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package openapi
+
+import (
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const jsonContentType = "application/json"
+
+// EndpointDescription contains the information about a web service endpoint required to describe it as an OpenAPI operation.
+type EndpointDescription struct {
+	// The name of the handler component (used as the operation's ID).
+	Name string
+
+	// The HTTP method the endpoint supports.
+	HTTPMethod string
+
+	// The regular expression used to match request paths to this endpoint.
+	PathPattern string
+
+	// The path template used to match request paths to this endpoint (used in preference to PathPattern if set).
+	PathTemplate string
+
+	// The names of the fields on the request target that path parameters are bound to, in the order the parameters appear in the path.
+	PathParams []string
+
+	// A map of request target field names to the names of the query parameters that are bound to them.
+	QueryParams map[string]string
+
+	// A map of request target field names to the names of the request headers that are bound to them.
+	HeaderParams map[string]string
+
+	// A map of request target field names to the names of the cookies that are bound to them.
+	CookieParams map[string]string
+
+	// A schema describing the type that requests are parsed into (may be nil).
+	Request *Schema
+
+	// A schema describing the body of successful responses (may be nil).
+	Response *Schema
+
+	// Validation rules applied to requests, in the format used by validate.RuleValidator.
+	Rules [][]string
+
+	// Whether or not callers must be authenticated.
+	RequireAuthentication bool
+
+	// Whether or not callers' access to the endpoint is checked.
+	AccessChecked bool
+}
+
+// Generator converts EndpointDescriptions into an OpenAPI Document.
+type Generator struct {
+	// The title of the API (info.title).
+	Title string
+
+	// A description of the API (info.description).
+	Description string
+
+	// The version of the API (info.version).
+	Version string
+
+	// The content type of request and response bodies. Defaults to application/json
+	ContentType string
+}
+
+// Generate creates a Document describing all of the supplied endpoints. Endpoints are processed in the order supplied;
+// if more than one endpoint maps to the same path template and method, only the first is included.
+func (g *Generator) Generate(endpoints []*EndpointDescription) *Document {
+
+	d := new(Document)
+	d.OpenAPI = Version
+	d.Info = &Info{Title: g.Title, Description: g.Description, Version: g.Version}
+	d.Paths = make(map[string]*PathItem)
+
+	for _, e := range endpoints {
+
+		path, op := g.Operation(e)
+		method := strings.ToLower(e.HTTPMethod)
+
+		pi := d.Paths[path]
+
+		if pi == nil {
+			pi = &PathItem{}
+			d.Paths[path] = pi
+		}
+
+		if (*pi)[method] == nil {
+			(*pi)[method] = op
+		}
+	}
+
+	return d
+}
+
+// Operation converts a single EndpointDescription into an OpenAPI Operation and the path template it should be associated with.
+func (g *Generator) Operation(e *EndpointDescription) (string, *Operation) {
+
+	op := new(Operation)
+	op.OperationID = e.Name
+	op.Responses = make(map[string]*Response)
+
+	req := e.Request
+
+	if req != nil {
+		req = req.copy()
+	} else if len(e.Rules) > 0 || len(e.QueryParams) > 0 || len(e.PathParams) > 0 || len(e.HeaderParams) > 0 || len(e.CookieParams) > 0 {
+		req = &Schema{Type: objectType}
+	}
+
+	if req != nil {
+		ApplyRules(req, e.Rules)
+	}
+
+	path, names, exact := e.path()
+
+	if !exact {
+		op.PathPattern = e.PathPattern
+	}
+
+	bound := make(map[string]bool)
+
+	for i, n := range names {
+
+		p := &Parameter{Name: n, In: "path", Required: true, Schema: &Schema{Type: stringType}}
+
+		if i < len(e.PathParams) {
+			bound[e.PathParams[i]] = true
+
+			if s := req.Property(e.PathParams[i]); s != nil {
+				p.Schema = s
+			}
+		}
+
+		op.Parameters = append(op.Parameters, p)
+	}
+
+	op.Parameters = append(op.Parameters, namedParameters(req, "query", e.QueryParams, bound)...)
+	op.Parameters = append(op.Parameters, namedParameters(req, "header", e.HeaderParams, bound)...)
+	op.Parameters = append(op.Parameters, namedParameters(req, "cookie", e.CookieParams, bound)...)
+
+	if req != nil && hasBody(e.HTTPMethod) {
+
+		body := withoutFields(req, bound)
+
+		if len(body.Properties) > 0 || body.Type != objectType {
+			op.RequestBody = &RequestBody{Required: true, Content: g.content(body)}
+		}
+	}
+
+	ok := &Response{Description: http.StatusText(http.StatusOK)}
+
+	if e.Response != nil {
+		ok.Content = g.content(e.Response)
+	}
+
+	op.Responses[strconv.Itoa(http.StatusOK)] = ok
+
+	if req != nil {
+		g.addResponse(op, http.StatusBadRequest)
+	}
+
+	if e.RequireAuthentication {
+		g.addResponse(op, http.StatusUnauthorized)
+	}
+
+	if e.AccessChecked {
+		g.addResponse(op, http.StatusForbidden)
+	}
+
+	return path, op
+}
+
+// path determines the OpenAPI path template for the endpoint and the names of its path parameters
+func (e *EndpointDescription) path() (string, []string, bool) {
+
+	if e.PathTemplate == "" {
+		return PathTemplate(e.PathPattern, e.PathParams)
+	}
+
+	pt, err := httpendpoint.ParsePathTemplate(e.PathTemplate)
+
+	if err != nil {
+		return e.PathTemplate, nil, false
+	}
+
+	var b strings.Builder
+
+	for _, s := range pt.Segments {
+
+		b.WriteString("/")
+
+		if s.IsParam() {
+			b.WriteString("{" + s.Param + "}")
+		} else {
+			b.WriteString(s.Literal)
+		}
+	}
+
+	if b.Len() == 0 {
+		b.WriteString("/")
+	}
+
+	return b.String(), pt.Params, true
+}
+
+func (g *Generator) addResponse(op *Operation, status int) {
+	op.Responses[strconv.Itoa(status)] = &Response{Description: http.StatusText(status)}
+}
+
+func (g *Generator) content(s *Schema) map[string]*MediaType {
+
+	ct := g.ContentType
+
+	if ct == "" {
+		ct = jsonContentType
+	}
+
+	return map[string]*MediaType{ct: {Schema: s}}
+}
+
+// withoutFields returns a copy of the schema without the properties that are bound from path or query parameters
+func withoutFields(s *Schema, fields map[string]bool) *Schema {
+
+	if len(fields) == 0 {
+		return s
+	}
+
+	c := s.copy()
+	c.Required = nil
+
+	for f := range fields {
+		delete(c.Properties, s.PropertyName(f))
+		delete(c.fields, f)
+	}
+
+	for _, r := range s.Required {
+		if _, found := c.Properties[r]; found {
+			c.Required = append(c.Required, r)
+		}
+	}
+
+	return c
+}
+
+func hasBody(method string) bool {
+
+	switch strings.ToUpper(method) {
+	case http.MethodGet, http.MethodHead, http.MethodDelete, http.MethodOptions, http.MethodTrace:
+		return false
+	}
+
+	return true
+}
+
+// namedParameters creates parameters for request target fields bound to query parameters, headers or cookies, recording
+// the fields as bound so that they are excluded from the request body
+func namedParameters(req *Schema, in string, params map[string]string, bound map[string]bool) []*Parameter {
+
+	var ps []*Parameter
+
+	for _, field := range sortedKeys(params) {
+
+		bound[field] = true
+
+		p := &Parameter{Name: params[field], In: in, Schema: &Schema{Type: stringType}}
+
+		if s := req.Property(field); s != nil {
+			p.Schema = s
+			p.Required = req.isRequired(req.PropertyName(field))
+		}
+
+		ps = append(ps, p)
+	}
+
+	return ps
+}
+
+func sortedKeys(m map[string]string) []string {
+
+	k := make([]string, 0, len(m))
+
+	for f := range m {
+		k = append(k, f)
+	}
+
+	sort.Strings(k)
+
+	return k
+}
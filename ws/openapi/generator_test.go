@@ -0,0 +1,216 @@
+package openapi
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/graniticio/granitic/v2/ioc"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"github.com/graniticio/granitic/v2/validate"
+	"github.com/graniticio/granitic/v2/ws"
+	"github.com/graniticio/granitic/v2/ws/handler"
+	"testing"
+)
+
+type updateArtist struct {
+	ID      int64
+	Name    string `json:"name"`
+	Notify  bool
+	Website string
+}
+
+type artistResponse struct {
+	Name string
+}
+
+type updateArtistLogic struct{}
+
+func (l *updateArtistLogic) ProcessPayload(ctx context.Context, req *ws.Request, res *ws.Response, a *updateArtist) {
+}
+
+func (l *updateArtistLogic) ResponseBody() interface{} {
+	return new(artistResponse)
+}
+
+func TestDescribeAndGenerate(t *testing.T) {
+
+	wh := new(handler.WsHandler)
+	wh.SetComponentName("updateArtistHandler")
+	wh.HTTPMethod = "PUT"
+	wh.PathPattern = "^/artist/(\\d+)$"
+	wh.BindPathParams = []string{"ID"}
+	wh.FieldQueryParam = map[string]string{"Notify": "notify"}
+	wh.Logic = new(updateArtistLogic)
+	wh.RequireAuthentication = true
+
+	test.ExpectNil(t, wh.StartComponent())
+
+	rm := new(validate.UnparsedRuleManager)
+	rm.Rules = map[string][]string{"name": {"STR", "REQ", "LEN:1-64"}}
+
+	wh.AutoValidator = new(validate.RuleValidator)
+	wh.AutoValidator.RuleManager = rm
+	wh.AutoValidator.Rules = [][]string{{"Name", "RULE:name"}, {"Notify", "BOOL", "REQ"}}
+
+	d, err := DescribeHandler(wh)
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	g := &Generator{Title: "Artists", Version: "1"}
+
+	doc := g.Generate([]*EndpointDescription{d})
+
+	pi := doc.Paths["/artist/{ID}"]
+
+	if pi == nil {
+		t.Fatalf("Expected path /artist/{ID}")
+	}
+
+	op := (*pi)["put"]
+
+	test.ExpectString(t, op.OperationID, "updateArtistHandler")
+	test.ExpectString(t, op.PathPattern, "")
+	test.ExpectInt(t, len(op.Parameters), 2)
+
+	pp := op.Parameters[0]
+
+	test.ExpectString(t, pp.In, "path")
+	test.ExpectString(t, pp.Schema.Type, "integer")
+
+	qp := op.Parameters[1]
+
+	test.ExpectString(t, qp.Name, "notify")
+	test.ExpectString(t, qp.Schema.Type, "boolean")
+	test.ExpectBool(t, qp.Required, true)
+
+	body := op.RequestBody.Content["application/json"].Schema
+
+	test.ExpectInt(t, len(body.Properties), 2)
+	test.ExpectInt(t, *body.Property("Name").MaxLength, 64)
+	test.ExpectInt(t, len(body.Required), 1)
+	test.ExpectString(t, body.Required[0], "name")
+
+	if op.Responses["200"].Content["application/json"].Schema.Property("Name") == nil {
+		t.Errorf("Expected response schema")
+	}
+
+	for _, code := range []string{"400", "401"} {
+		if op.Responses[code] == nil {
+			t.Errorf("Expected a %s response", code)
+		}
+	}
+
+	if op.Responses["403"] != nil {
+		t.Errorf("Did not expect a 403 response")
+	}
+
+	if _, err := json.Marshal(doc); err != nil {
+		t.Fatal(err.Error())
+	}
+}
+
+func TestDescribeHandlerWithTemplate(t *testing.T) {
+
+	wh := new(handler.WsHandler)
+	wh.SetComponentName("getArtistHandler")
+	wh.HTTPMethod = "GET"
+	wh.PathTemplate = "/artist/{id:int}"
+	wh.Logic = new(updateArtistLogic)
+
+	test.ExpectNil(t, wh.StartComponent())
+
+	d, err := DescribeHandler(wh)
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	path, op := new(Generator).Operation(d)
+
+	test.ExpectString(t, path, "/artist/{id}")
+	test.ExpectString(t, op.PathPattern, "")
+	test.ExpectInt(t, len(op.Parameters), 1)
+	test.ExpectString(t, op.Parameters[0].Name, "id")
+	test.ExpectString(t, op.Parameters[0].Schema.Type, "integer")
+}
+
+type tenantArtist struct {
+	Name    string
+	Tenant  string `ws:"header=X-Tenant"`
+	Session string `ws:"cookie=session"`
+}
+
+type tenantArtistLogic struct{}
+
+func (l *tenantArtistLogic) ProcessPayload(ctx context.Context, req *ws.Request, res *ws.Response, a *tenantArtist) {
+}
+
+func TestHeaderAndCookieParameters(t *testing.T) {
+
+	wh := new(handler.WsHandler)
+	wh.SetComponentName("createArtistHandler")
+	wh.HTTPMethod = "POST"
+	wh.PathPattern = "^/artist$"
+	wh.Logic = new(tenantArtistLogic)
+
+	test.ExpectNil(t, wh.StartComponent())
+
+	d, err := DescribeHandler(wh)
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	_, op := new(Generator).Operation(d)
+
+	test.ExpectInt(t, len(op.Parameters), 2)
+	test.ExpectString(t, op.Parameters[0].In, "header")
+	test.ExpectString(t, op.Parameters[0].Name, "X-Tenant")
+	test.ExpectString(t, op.Parameters[1].In, "cookie")
+	test.ExpectString(t, op.Parameters[1].Name, "session")
+
+	body := op.RequestBody.Content["application/json"].Schema
+
+	test.ExpectInt(t, len(body.Properties), 1)
+}
+
+func TestEndpoint(t *testing.T) {
+
+	lm := logging.CreateComponentLoggerManager(logging.Fatal, make(map[string]interface{}), []logging.LogWriter{}, logging.NewFrameworkLogMessageFormatter(), false)
+	cc := ioc.NewComponentContainer(lm, nil, nil)
+
+	wh := new(handler.WsHandler)
+	wh.HTTPMethod = "GET"
+	wh.PathPattern = "^/artists$"
+	wh.Logic = new(updateArtistLogic)
+
+	cc.WrapAndAddProto("listHandler", wh)
+
+	if err := cc.Populate(); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	ep := new(Endpoint)
+	ep.Path = "/openapi.json"
+	ep.Generator = new(Generator)
+	ep.Container(cc)
+
+	b, err := ep.Document()
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	doc := make(map[string]interface{})
+	json.Unmarshal(b, &doc)
+
+	test.ExpectString(t, doc["openapi"].(string), Version)
+
+	paths := doc["paths"].(map[string]interface{})
+
+	if paths["/artists"] == nil {
+		t.Fatalf("Expected /artists to be described")
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+/*
+Package openapi generates OpenAPI 3 (https://swagger.io/specification/) documents describing the web service endpoints
+provided by an application.
+
+Each endpoint is first converted into an EndpointDescription, either at runtime from a handler.WsHandler (see
+DescribeHandler) or from component definition and configuration files by the grnc-openapi tool. A Generator then
+converts a set of descriptions into a Document, which can be serialised as JSON.
+
+The information used to describe an endpoint is:
+
+	HTTPMethod and PathPattern                  The operation and its path. Capture groups in the path's regular expression
+	                                            are converted to {name} path parameters, using the names in BindPathParams
+	The Logic component's target type           The schema of the request body and the types of path and query parameters
+	FieldQueryParam (or AutoBindQuery)          Query parameters
+	FieldHeader and FieldCookie                 Header and cookie parameters
+	AutoValidator rules                         Constraints (required, minLength, maxLength, pattern, enum, minimum,
+	                                            maximum, minItems and maxItems) added to the relevant schemas
+	RequireAuthentication and AccessChecker     401 and 403 responses
+
+Logic components may also implement handler.WsResponseBody to describe the body of successful responses.
+
+Runtime endpoint
+
+If HTTPServer.OpenAPI.Enabled is set to true in configuration, the HTTPServer facility creates an Endpoint component
+that serves the document for all of the application's handlers as JSON on the path set by HTTPServer.OpenAPI.Path
+(/openapi.json by default).
+*/
+package openapi
+
+// Version is the version of the OpenAPI specification that generated documents conform to.
+const Version = "3.0.3"
+
+// Document is the root of an OpenAPI 3 document.
+type Document struct {
+	OpenAPI string               `json:"openapi"`
+	Info    *Info                `json:"info"`
+	Paths   map[string]*PathItem `json:"paths"`
+}
+
+// Info contains metadata about the API being described.
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// PathItem maps a lower-case HTTP method (get, post etc) to the operation available on a path using that method.
+type PathItem map[string]*Operation
+
+// Operation describes a single endpoint.
+type Operation struct {
+	OperationID string               `json:"operationId,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+
+	// The handler's original PathPattern, included if it could not be exactly represented as an OpenAPI path.
+	PathPattern string `json:"x-granitic-path-pattern,omitempty"`
+}
+
+// Parameter describes a path or query parameter.
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema,omitempty"`
+}
+
+// RequestBody describes the body of a request.
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response describes a response with a particular HTTP status code.
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType associates a schema with a content type.
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+// Schema is the subset of the OpenAPI Schema Object used to describe request and response bodies and parameters.
+type Schema struct {
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+
+	// Go field names mapped to the names of the properties they are serialised as.
+	fields map[string]string
+}
+
+// Property returns the schema for the property that the named Go field is serialised as, or nil if there is no such property.
+func (s *Schema) Property(field string) *Schema {
+
+	if s == nil || s.Properties == nil {
+		return nil
+	}
+
+	return s.Properties[s.PropertyName(field)]
+}
+
+// PropertyName returns the name of the property that the named Go field is serialised as.
+func (s *Schema) PropertyName(field string) string {
+
+	if n, found := s.fields[field]; found {
+		return n
+	}
+
+	return field
+}
+
+func (s *Schema) setProperty(field, name string, p *Schema) {
+
+	if s.Properties == nil {
+		s.Properties = make(map[string]*Schema)
+	}
+
+	if s.fields == nil {
+		s.fields = make(map[string]string)
+	}
+
+	s.Properties[name] = p
+	s.fields[field] = name
+}
+
+func (s *Schema) markRequired(name string) {
+
+	for _, r := range s.Required {
+		if r == name {
+			return
+		}
+	}
+
+	s.Required = append(s.Required, name)
+}
+
+func (s *Schema) isRequired(name string) bool {
+
+	for _, r := range s.Required {
+		if r == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// copy creates a deep copy of the schema
+func (s *Schema) copy() *Schema {
+
+	if s == nil {
+		return nil
+	}
+
+	c := *s
+
+	c.Items = s.Items.copy()
+	c.AdditionalProperties = s.AdditionalProperties.copy()
+	c.Required = append([]string(nil), s.Required...)
+
+	if s.Properties != nil {
+		c.Properties = make(map[string]*Schema)
+
+		for k, v := range s.Properties {
+			c.Properties[k] = v.copy()
+		}
+	}
+
+	if s.fields != nil {
+		c.fields = make(map[string]string)
+
+		for k, v := range s.fields {
+			c.fields[k] = v
+		}
+	}
+
+	return &c
+}
@@ -0,0 +1,154 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package openapi
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Optional trailing slashes commonly found at the end of PathPatterns
+var trailingSlashes = []string{"[/]?", "/?", "(/)?", "(?:/)?"}
+
+/*
+PathTemplate converts the regular expression used as a handler's PathPattern into an OpenAPI path template. Each capture
+group is replaced with a {name} parameter, where the name is taken from (in order of preference) the group's own name
+(?P<name>...), the corresponding entry in names or a generated name of the form paramN. For example
+
+	^/artist/([\d]+)/track/(\d+)[/]?$
+
+with names [ArtistID, TrackID] becomes /artist/{ArtistID}/track/{TrackID}
+
+The names of the parameters are returned in the order they appear in the path. exact is false if the pattern contained
+regular expression syntax that cannot be represented in a path template (in which case that syntax is left in place).
+*/
+func PathTemplate(pattern string, names []string) (template string, params []string, exact bool) {
+
+	exact = true
+
+	p := strings.TrimPrefix(pattern, "^")
+	p = strings.TrimSuffix(p, "$")
+
+	for _, ts := range trailingSlashes {
+		if strings.HasSuffix(p, ts) {
+			p = strings.TrimSuffix(p, ts)
+			break
+		}
+	}
+
+	var b strings.Builder
+
+	group := 0
+	r := []rune(p)
+
+	for i := 0; i < len(r); i++ {
+
+		c := r[i]
+
+		switch c {
+		case '\\':
+			if i+1 < len(r) {
+				i++
+
+				if strings.ContainsRune("dDwWsSbB", r[i]) {
+					exact = false
+					b.WriteRune('\\')
+				}
+
+				b.WriteRune(r[i])
+			}
+
+		case '(':
+			end := closingParen(r, i)
+
+			if end < 0 {
+				exact = false
+				b.WriteString(string(r[i:]))
+				i = len(r)
+				continue
+			}
+
+			inner := string(r[i+1 : end])
+
+			if strings.HasPrefix(inner, "?:") {
+				// Non-capturing group
+				exact = false
+				b.WriteString(string(r[i : end+1]))
+				i = end
+				continue
+			}
+
+			name := ""
+
+			if strings.HasPrefix(inner, "?P<") {
+				if gt := strings.Index(inner, ">"); gt > 0 {
+					name = inner[3:gt]
+				}
+			}
+
+			if name == "" && group < len(names) {
+				name = names[group]
+			}
+
+			if name == "" {
+				name = fmt.Sprintf("param%d", group+1)
+			}
+
+			group++
+			params = append(params, name)
+
+			b.WriteString("{" + name + "}")
+			i = end
+
+		case '[', ']', '*', '+', '?', '|', '{', '}', '.', '^', '$':
+			exact = false
+			b.WriteRune(c)
+
+		default:
+			b.WriteRune(c)
+		}
+	}
+
+	template = b.String()
+
+	if !strings.HasPrefix(template, "/") {
+		template = "/" + template
+		exact = false
+	}
+
+	return template, params, exact
+}
+
+// closingParen finds the index of the parenthesis that closes the one at index open
+func closingParen(r []rune, open int) int {
+
+	depth := 0
+	inClass := false
+
+	for i := open; i < len(r); i++ {
+
+		switch r[i] {
+		case '\\':
+			i++
+		case '[':
+			inClass = true
+		case ']':
+			inClass = false
+		case '(':
+			if !inClass {
+				depth++
+			}
+		case ')':
+			if !inClass {
+				depth--
+
+				if depth == 0 {
+					return i
+				}
+			}
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,36 @@
+package openapi
+
+import (
+	"github.com/graniticio/granitic/v2/test"
+	"testing"
+)
+
+func TestPathTemplate(t *testing.T) {
+
+	tmpl, params, exact := PathTemplate("^/artist/([\\d]+)/track/(\\d+)[/]?$", []string{"ArtistID", "TrackID"})
+
+	test.ExpectString(t, tmpl, "/artist/{ArtistID}/track/{TrackID}")
+	test.ExpectInt(t, len(params), 2)
+	test.ExpectString(t, params[1], "TrackID")
+	test.ExpectBool(t, exact, true)
+
+	tmpl, params, exact = PathTemplate("^/a/(?P<name>[a-z]+)/(\\d+)$", nil)
+
+	test.ExpectString(t, tmpl, "/a/{name}/{param2}")
+	test.ExpectString(t, params[0], "name")
+	test.ExpectBool(t, exact, true)
+
+	tmpl, _, exact = PathTemplate("^/files/.*$", nil)
+
+	test.ExpectString(t, tmpl, "/files/.*")
+	test.ExpectBool(t, exact, false)
+
+	tmpl, _, exact = PathTemplate("^/v1\\.0/(?:a|b)/x$", nil)
+
+	test.ExpectString(t, tmpl, "/v1.0/(?:a|b)/x")
+	test.ExpectBool(t, exact, false)
+
+	tmpl, _, _ = PathTemplate("^/static$", nil)
+
+	test.ExpectString(t, tmpl, "/static")
+}
@@ -0,0 +1,220 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package openapi
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Validation rule types and operations (see the validate package) that can be expressed as schema keywords
+const (
+	ruleString = "STR"
+	ruleInt    = "INT"
+	ruleFloat  = "FLOAT"
+	ruleBool   = "BOOL"
+	ruleObject = "OBJ"
+	ruleSlice  = "SLICE"
+
+	opRequired = "REQ"
+	opLength   = "LEN"
+	opRegex    = "REG"
+	opIn       = "IN"
+	opRange    = "RANGE"
+
+	ruleSep        = ":"
+	escapedRuleSep = "::"
+	escapeReplace  = "||ESC||"
+)
+
+var ruleTypes = map[string]string{
+	ruleString: stringType,
+	ruleInt:    integerType,
+	ruleFloat:  numberType,
+	ruleBool:   booleanType,
+	ruleObject: objectType,
+	ruleSlice:  arrayType,
+}
+
+// ApplyRules adds the constraints expressed by a set of validation rules (in the format used by validate.RuleValidator)
+// to an object schema. Fields referenced by rules that are not already described by the schema are added to it, with
+// a type inferred from the rule. Operations that have no equivalent schema keyword (trimming, external validation etc)
+// are ignored.
+func ApplyRules(s *Schema, rules [][]string) {
+
+	for _, rule := range rules {
+
+		if len(rule) < 2 {
+			continue
+		}
+
+		path := strings.Split(rule[0], ".")
+		ops := rule[1:]
+
+		rt := ruleType(ops)
+
+		parent, prop := findOrCreate(s, path, ruleTypes[rt])
+
+		for _, op := range ops {
+			applyOperation(parent, prop, path[len(path)-1], rt, decompose(op))
+		}
+	}
+}
+
+func ruleType(ops []string) string {
+
+	for _, op := range ops {
+
+		t := decompose(op)[0]
+
+		if _, found := ruleTypes[t]; found {
+			return t
+		}
+	}
+
+	return ""
+}
+
+// findOrCreate locates the schema for the field at the end of path, creating it (and any intermediate objects) if required.
+func findOrCreate(s *Schema, path []string, leafType string) (parent, prop *Schema) {
+
+	parent = s
+
+	for i, field := range path {
+
+		if parent.Type == arrayType && parent.Items != nil {
+			parent = parent.Items
+		}
+
+		p := parent.Property(field)
+
+		if p == nil {
+
+			p = new(Schema)
+
+			if i < len(path)-1 {
+				p.Type = objectType
+			} else {
+				p.Type = leafType
+			}
+
+			parent.setProperty(field, field, p)
+		}
+
+		if i == len(path)-1 {
+			return parent, p
+		}
+
+		parent = p
+	}
+
+	return parent, parent
+}
+
+func applyOperation(parent, prop *Schema, field, rt string, op []string) {
+
+	switch op[0] {
+	case opRequired:
+		parent.markRequired(parent.PropertyName(field))
+
+	case opLength:
+		if len(op) < 2 {
+			return
+		}
+
+		min, max := bounds(op[1], "-")
+
+		if rt == ruleSlice {
+			prop.MinItems, prop.MaxItems = intBound(min), intBound(max)
+		} else {
+			prop.MinLength, prop.MaxLength = intBound(min), intBound(max)
+		}
+
+	case opRegex:
+		if len(op) > 1 {
+			prop.Pattern = op[1]
+		}
+
+	case opRange:
+		if len(op) < 2 {
+			return
+		}
+
+		min, max := bounds(op[1], "|")
+		prop.Minimum, prop.Maximum = floatBound(min), floatBound(max)
+
+	case opIn:
+		if len(op) < 2 {
+			return
+		}
+
+		prop.Enum = enumValues(rt, strings.Split(op[1], ","))
+	}
+}
+
+func enumValues(rt string, members []string) []interface{} {
+
+	var e []interface{}
+
+	for _, m := range members {
+
+		switch rt {
+		case ruleInt:
+			if i, err := strconv.ParseInt(m, 10, 64); err == nil {
+				e = append(e, i)
+				continue
+			}
+		case ruleFloat:
+			if f, err := strconv.ParseFloat(m, 64); err == nil {
+				e = append(e, f)
+				continue
+			}
+		}
+
+		e = append(e, m)
+	}
+
+	return e
+}
+
+func bounds(v, sep string) (min, max string) {
+
+	b := strings.SplitN(v, sep, 2)
+
+	if len(b) != 2 {
+		return "", ""
+	}
+
+	return b[0], b[1]
+}
+
+func intBound(v string) *int {
+
+	if i, err := strconv.Atoi(v); err == nil {
+		return &i
+	}
+
+	return nil
+}
+
+func floatBound(v string) *float64 {
+
+	if f, err := strconv.ParseFloat(v, 64); err == nil {
+		return &f
+	}
+
+	return nil
+}
+
+// decompose splits an operation into its components, honouring escaped separators in the same way as the validate package
+func decompose(op string) []string {
+
+	split := strings.Split(strings.Replace(op, escapedRuleSep, escapeReplace, -1), ruleSep)
+
+	for i, v := range split {
+		split[i] = strings.Replace(v, escapeReplace, ruleSep, -1)
+	}
+
+	return split
+}
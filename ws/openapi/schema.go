@@ -0,0 +1,161 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package openapi
+
+import (
+	"github.com/graniticio/granitic/v2/types"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const (
+	objectType  = "object"
+	arrayType   = "array"
+	stringType  = "string"
+	integerType = "integer"
+	numberType  = "number"
+	booleanType = "boolean"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// SchemaFor uses reflection to build a schema describing how instances of the supplied type are serialised as JSON.
+// Property names honour json struct tags and the fields of embedded structs are promoted. Granitic's nilable types are
+// described as their underlying JSON type.
+func SchemaFor(t reflect.Type) *Schema {
+	return schemaFor(t, make(map[reflect.Type]bool))
+}
+
+func schemaFor(t reflect.Type, visiting map[reflect.Type]bool) *Schema {
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if s := nilableSchema(t); s != nil {
+		return s
+	}
+
+	if t == timeType {
+		return &Schema{Type: stringType, Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: stringType}
+	case reflect.Bool:
+		return &Schema{Type: booleanType}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: integerType, Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: integerType, Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: numberType, Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: numberType, Format: "double"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: stringType, Format: "byte"}
+		}
+
+		return &Schema{Type: arrayType, Items: schemaFor(t.Elem(), visiting)}
+	case reflect.Map:
+		return &Schema{Type: objectType, AdditionalProperties: schemaFor(t.Elem(), visiting)}
+	case reflect.Struct:
+		return structSchema(t, visiting)
+	}
+
+	// Interfaces, funcs etc - any value is acceptable
+	return new(Schema)
+}
+
+func nilableSchema(t reflect.Type) *Schema {
+
+	switch t {
+	case reflect.TypeOf(types.NilableString{}):
+		return &Schema{Type: stringType}
+	case reflect.TypeOf(types.NilableBool{}):
+		return &Schema{Type: booleanType}
+	case reflect.TypeOf(types.NilableInt64{}):
+		return &Schema{Type: integerType, Format: "int64"}
+	case reflect.TypeOf(types.NilableFloat64{}):
+		return &Schema{Type: numberType, Format: "double"}
+	}
+
+	return nil
+}
+
+func structSchema(t reflect.Type, visiting map[reflect.Type]bool) *Schema {
+
+	s := &Schema{Type: objectType}
+
+	if visiting[t] {
+		// Recursive type - don't attempt to describe it again
+		return s
+	}
+
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	addStructFields(s, t, visiting)
+
+	return s
+}
+
+func addStructFields(s *Schema, t reflect.Type, visiting map[reflect.Type]bool) {
+
+	for i := 0; i < t.NumField(); i++ {
+
+		f := t.Field(i)
+
+		name, omit := jsonName(f)
+
+		if omit {
+			continue
+		}
+
+		if f.Anonymous && !hasJSONName(f) {
+
+			et := f.Type
+
+			if et.Kind() == reflect.Ptr {
+				et = et.Elem()
+			}
+
+			if et.Kind() == reflect.Struct {
+				addStructFields(s, et, visiting)
+				continue
+			}
+		}
+
+		if f.PkgPath != "" {
+			// Unexported
+			continue
+		}
+
+		s.setProperty(f.Name, name, schemaFor(f.Type, visiting))
+	}
+}
+
+func hasJSONName(f reflect.StructField) bool {
+	tag := f.Tag.Get("json")
+
+	return tag != "" && strings.Split(tag, ",")[0] != ""
+}
+
+func jsonName(f reflect.StructField) (name string, omit bool) {
+
+	tag := f.Tag.Get("json")
+
+	if tag == "-" {
+		return "", true
+	}
+
+	if n := strings.Split(tag, ",")[0]; n != "" {
+		return n, false
+	}
+
+	return f.Name, false
+}
@@ -0,0 +1,100 @@
+package openapi
+
+import (
+	"github.com/graniticio/granitic/v2/test"
+	"github.com/graniticio/granitic/v2/types"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type embedded struct {
+	Created time.Time
+}
+
+type node struct {
+	Children []*node
+}
+
+type schemaTarget struct {
+	embedded
+	Name     string `json:"name"`
+	Age      int
+	Score    float64
+	Tags     []string
+	Meta     map[string]int
+	Nilable  *types.NilableString
+	Data     []byte
+	Tree     *node
+	Ignored  string `json:"-"`
+	Any      interface{}
+	internal string
+}
+
+func TestSchemaFor(t *testing.T) {
+
+	s := SchemaFor(reflect.TypeOf(new(schemaTarget)))
+
+	test.ExpectString(t, s.Type, "object")
+
+	test.ExpectString(t, s.PropertyName("Name"), "name")
+	test.ExpectString(t, s.Property("Name").Type, "string")
+	test.ExpectString(t, s.Property("Age").Type, "integer")
+	test.ExpectString(t, s.Property("Score").Format, "double")
+	test.ExpectString(t, s.Property("Tags").Items.Type, "string")
+	test.ExpectString(t, s.Property("Meta").AdditionalProperties.Type, "integer")
+	test.ExpectString(t, s.Property("Nilable").Type, "string")
+	test.ExpectString(t, s.Property("Data").Format, "byte")
+	test.ExpectString(t, s.Property("Created").Format, "date-time")
+
+	tree := s.Property("Tree")
+
+	test.ExpectString(t, tree.Property("Children").Items.Type, "object")
+
+	for _, missing := range []string{"Ignored", "internal"} {
+		if s.Property(missing) != nil {
+			t.Errorf("Did not expect a property for %s", missing)
+		}
+	}
+
+	if s.Property("Any") == nil || s.Property("Any").Type != "" {
+		t.Errorf("Expected an untyped schema for interface field")
+	}
+}
+
+func TestApplyRules(t *testing.T) {
+
+	s := SchemaFor(reflect.TypeOf(new(schemaTarget)))
+
+	ApplyRules(s, [][]string{
+		{"Name", "STR:NAME", "REQ", "HARDTRIM", "LEN:2-10", "REG:^[a-z]::[0-9]$:BAD"},
+		{"Age", "INT", "RANGE:18|", "IN:18,21,30"},
+		{"Tags", "SLICE", "LEN:-3"},
+		{"Address.Postcode", "STR", "REQ"},
+	})
+
+	n := s.Property("Name")
+
+	test.ExpectInt(t, *n.MinLength, 2)
+	test.ExpectInt(t, *n.MaxLength, 10)
+	test.ExpectString(t, n.Pattern, "^[a-z]:[0-9]$")
+	test.ExpectBool(t, s.isRequired("name"), true)
+
+	a := s.Property("Age")
+
+	if *a.Minimum != 18 || a.Maximum != nil {
+		t.Errorf("Unexpected range")
+	}
+
+	test.ExpectInt(t, len(a.Enum), 3)
+
+	if s.Property("Tags").MinItems != nil || *s.Property("Tags").MaxItems != 3 {
+		t.Errorf("Unexpected slice length constraints")
+	}
+
+	ad := s.Property("Address")
+
+	test.ExpectString(t, ad.Type, "object")
+	test.ExpectString(t, ad.Property("Postcode").Type, "string")
+	test.ExpectBool(t, ad.isRequired("Postcode"), true)
+}
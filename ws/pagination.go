@@ -0,0 +1,361 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package ws
+
+import (
+	"fmt"
+	"github.com/graniticio/granitic/v2/types"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// PaginationStyle identifies the way a client moves between the pages of a list of results.
+type PaginationStyle int
+
+const (
+	// NoPagination indicates that results are not paginated
+	NoPagination PaginationStyle = iota
+
+	// OffsetPagination indicates that the client requests a numbered page of results (see OffsetPageRequest)
+	OffsetPagination
+
+	// CursorPagination indicates that the client requests the page of results following an opaque cursor (see CursorPageRequest)
+	CursorPagination
+)
+
+// OffsetPageRequest is the numbered page of results requested by a client. If a handler's request target has a field of
+// this type (or a pointer to this type), it is populated from the request's query parameters (e.g. ?page=3&size=20).
+type OffsetPageRequest struct {
+	// The number of the page requested, starting at 1.
+	Page int
+
+	// The maximum number of results to include in the page.
+	Size int
+}
+
+// Offset returns the number of results that precede the requested page (suitable for an SQL OFFSET clause).
+func (pr *OffsetPageRequest) Offset() int {
+	return (pr.Page - 1) * pr.Size
+}
+
+// CursorPageRequest is the page of results following an opaque cursor requested by a client. If a handler's request
+// target has a field of this type (or a pointer to this type), it is populated from the request's query parameters
+// (e.g. ?cursor=dXNlcjoxMg&size=20).
+type CursorPageRequest struct {
+	// An opaque value previously supplied to the client (see Page.NextCursor). Empty if the first page is requested.
+	Cursor string
+
+	// The maximum number of results to include in the page.
+	Size int
+}
+
+// Page describes the page of results returned by a handler. Logic components set it as the Page field of a Response so
+// that links to other pages can be generated and, if the response is wrapped, the page is described to the client.
+type Page struct {
+	// The number of this page, starting at 1 (offset pagination only).
+	Number int `json:",omitempty"`
+
+	// The maximum number of results in a page.
+	Size int
+
+	// The total number of results across all pages, if known (offset pagination only).
+	TotalItems *types.NilableInt64 `json:",omitempty"`
+
+	// The total number of pages, if known (offset pagination only).
+	TotalPages *types.NilableInt64 `json:",omitempty"`
+
+	// The cursor a client should use to request the next page (cursor pagination only).
+	NextCursor string `json:",omitempty"`
+
+	// The cursor a client should use to request the previous page (cursor pagination only).
+	PreviousCursor string `json:",omitempty"`
+
+	// Whether or not there is a page after this one.
+	HasNext bool
+
+	// Whether or not there is a page before this one.
+	HasPrevious bool
+}
+
+// NewOffsetPage describes the page of results returned for the supplied request when the total number of results is known.
+func NewOffsetPage(pr *OffsetPageRequest, totalItems int64) *Page {
+
+	p := new(Page)
+	p.Number = pr.Page
+	p.Size = pr.Size
+	p.TotalItems = types.NewNilableInt64(totalItems)
+
+	pages := int64(0)
+
+	if pr.Size > 0 {
+		pages = (totalItems + int64(pr.Size) - 1) / int64(pr.Size)
+	}
+
+	p.TotalPages = types.NewNilableInt64(pages)
+	p.HasNext = int64(pr.Page) < pages
+	p.HasPrevious = pr.Page > 1
+
+	return p
+}
+
+// NewOffsetPageWithoutTotal describes the page of results returned for the supplied request when the total number of
+// results is not known. more should be true if at least one result follows this page.
+func NewOffsetPageWithoutTotal(pr *OffsetPageRequest, more bool) *Page {
+
+	p := new(Page)
+	p.Number = pr.Page
+	p.Size = pr.Size
+	p.HasNext = more
+	p.HasPrevious = pr.Page > 1
+
+	return p
+}
+
+// NewCursorPage describes the page of results returned for the supplied request. next and previous are the cursors
+// for the adjacent pages, or empty if there is no such page.
+func NewCursorPage(pr *CursorPageRequest, next, previous string) *Page {
+
+	p := new(Page)
+	p.Size = pr.Size
+	p.NextCursor = next
+	p.PreviousCursor = previous
+	p.HasNext = next != ""
+	p.HasPrevious = previous != ""
+
+	return p
+}
+
+// PageWrapper is implemented by ResponseWrappers that can include a description of the page of results in the wrapped
+// response.
+type PageWrapper interface {
+	// WrapPage takes the supplied body, errors and page and wraps them in a standardised data structure.
+	WrapPage(body interface{}, errors interface{}, page *Page) interface{}
+}
+
+var (
+	offsetPageRequestType = reflect.TypeOf(OffsetPageRequest{})
+	cursorPageRequestType = reflect.TypeOf(CursorPageRequest{})
+)
+
+// PageRequestField finds an exported field on the supplied request target whose type is OffsetPageRequest or
+// CursorPageRequest (or a pointer to either). Unexported fields are ignored, as they cannot be populated. Returns the index of the field and the style of pagination it represents, or NoPagination
+// if the target has no such field.
+func PageRequestField(target interface{}) (int, PaginationStyle) {
+
+	if target == nil {
+		return -1, NoPagination
+	}
+
+	t := reflect.TypeOf(target)
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return -1, NoPagination
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+
+		f := t.Field(i)
+
+		if f.PkgPath != "" {
+			// Unexported
+			continue
+		}
+
+		ft := f.Type
+
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+
+		switch ft {
+		case offsetPageRequestType:
+			return i, OffsetPagination
+		case cursorPageRequestType:
+			return i, CursorPagination
+		}
+	}
+
+	return -1, NoPagination
+}
+
+// Paginator binds the pagination requested in a request's query parameters into request targets and generates links
+// between pages of results. A single instance is created by the JSONWs/XMLWs facilities (configured with WS.Pagination)
+// and injected into each handler.
+type Paginator struct {
+	// The name of the query parameter holding the number of the requested page (offset pagination).
+	PageParam string
+
+	// The name of the query parameter holding the maximum number of results per page.
+	SizeParam string
+
+	// The name of the query parameter holding the cursor of the requested page (cursor pagination).
+	CursorParam string
+
+	// The number of results per page if the client does not specify a size.
+	DefaultSize int
+
+	// The largest page size a client can request.
+	MaxSize int
+
+	// Whether or not Link headers (RFC 8288) referring to the first, previous, next and last pages are added to responses.
+	LinkHeaders bool
+
+	// Source of service errors for invalid page numbers and sizes.
+	FrameworkErrors *FrameworkErrorGenerator
+}
+
+// BindPageRequest populates the field at the supplied index on the Request.RequestBody with the page requested in the
+// supplied query parameters. defaultSize and maxSize override the Paginator's own limits if greater than zero. A default
+// size larger than the maximum size is reduced to the maximum. Invalid page numbers or sizes are recorded as framework
+// errors in the Request.
+func (p *Paginator) BindPageRequest(wsReq *Request, q url.Values, field int, style PaginationStyle, defaultSize, maxSize int) {
+
+	if defaultSize <= 0 {
+		defaultSize = p.DefaultSize
+	}
+
+	if maxSize <= 0 {
+		maxSize = p.MaxSize
+	}
+
+	if maxSize > 0 && defaultSize > maxSize {
+		defaultSize = maxSize
+	}
+
+	size := p.size(wsReq, q, defaultSize, maxSize)
+
+	var pr interface{}
+
+	if style == CursorPagination {
+		pr = &CursorPageRequest{Cursor: strings.TrimSpace(q.Get(p.CursorParam)), Size: size}
+	} else {
+		pr = &OffsetPageRequest{Page: p.number(wsReq, q, size), Size: size}
+	}
+
+	f := reflect.ValueOf(wsReq.RequestBody).Elem().Field(field)
+
+	if f.Kind() == reflect.Ptr {
+		f.Set(reflect.ValueOf(pr))
+	} else {
+		f.Set(reflect.ValueOf(pr).Elem())
+	}
+}
+
+func (p *Paginator) size(wsReq *Request, q url.Values, defaultSize, maxSize int) int {
+
+	v := strings.TrimSpace(q.Get(p.SizeParam))
+
+	if v == "" {
+		return defaultSize
+	}
+
+	size, err := strconv.Atoi(v)
+
+	if err != nil || size < 1 || (maxSize > 0 && size > maxSize) {
+		m, c := p.FrameworkErrors.MessageCode(PageSizeInvalid, v, p.SizeParam, maxSize)
+		wsReq.AddFrameworkError(NewQueryBindFrameworkError(m, c, p.SizeParam, ""))
+
+		return defaultSize
+	}
+
+	return size
+}
+
+// The largest value of an int
+const maxInt = int(^uint(0) >> 1)
+
+// number returns the requested page number. Page numbers so large that the offset of the page (see OffsetPageRequest.Offset)
+// cannot be represented are invalid.
+func (p *Paginator) number(wsReq *Request, q url.Values, size int) int {
+
+	v := strings.TrimSpace(q.Get(p.PageParam))
+
+	if v == "" {
+		return 1
+	}
+
+	n, err := strconv.Atoi(v)
+
+	if err != nil || n < 1 || (size > 0 && n-1 > maxInt/size) {
+		m, c := p.FrameworkErrors.MessageCode(PageNumberInvalid, v, p.PageParam)
+		wsReq.AddFrameworkError(NewQueryBindFrameworkError(m, c, p.PageParam, ""))
+
+		return 1
+	}
+
+	return n
+}
+
+// Links builds the value of a Link header referring to the pages before and after the supplied page (and the first and
+// last pages where known). Links are based on the supplied request URI, retaining its path and any other query
+// parameters. Returns an empty string if there are no other pages.
+func (p *Paginator) Links(requestURI string, page *Page) string {
+
+	u, err := url.ParseRequestURI(requestURI)
+
+	if err != nil || page == nil {
+		return ""
+	}
+
+	var links []string
+
+	link := func(rel string, set map[string]string) {
+
+		q := u.Query()
+
+		for k, v := range set {
+			if v == "" {
+				q.Del(k)
+			} else {
+				q.Set(k, v)
+			}
+		}
+
+		q.Set(p.SizeParam, strconv.Itoa(page.Size))
+
+		lu := *u
+		lu.RawQuery = q.Encode()
+
+		links = append(links, fmt.Sprintf("<%s>; rel=\"%s\"", lu.String(), rel))
+	}
+
+	if page.Number > 0 {
+
+		pageLink := func(rel string, n int64) {
+			link(rel, map[string]string{p.PageParam: strconv.FormatInt(n, 10)})
+		}
+
+		if page.HasPrevious {
+			pageLink("first", 1)
+			pageLink("prev", int64(page.Number-1))
+		}
+
+		if page.HasNext {
+			pageLink("next", int64(page.Number+1))
+
+			if page.TotalPages != nil && page.TotalPages.IsSet() {
+				pageLink("last", page.TotalPages.Int64())
+			}
+		}
+
+	} else {
+
+		if page.HasPrevious {
+			link("first", map[string]string{p.CursorParam: ""})
+			link("prev", map[string]string{p.CursorParam: page.PreviousCursor})
+		}
+
+		if page.HasNext {
+			link("next", map[string]string{p.CursorParam: page.NextCursor})
+		}
+	}
+
+	return strings.Join(links, ", ")
+}
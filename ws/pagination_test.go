@@ -0,0 +1,176 @@
+package ws
+
+import (
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"net/url"
+	"testing"
+)
+
+type offsetTarget struct {
+	Genre string
+	Page  OffsetPageRequest
+}
+
+type unexportedTarget struct {
+	page OffsetPageRequest
+}
+
+type cursorTarget struct {
+	*CursorPageRequest
+	Genre string
+}
+
+func paginator() *Paginator {
+
+	p := new(Paginator)
+	p.PageParam = "page"
+	p.SizeParam = "size"
+	p.CursorParam = "cursor"
+	p.DefaultSize = 20
+	p.MaxSize = 100
+	p.LinkHeaders = true
+	p.FrameworkErrors = &FrameworkErrorGenerator{
+		Messages: map[FrameworkErrorEvent][]string{
+			PageNumberInvalid: {"PAGINATION", "Bad page %s (%s)"},
+			PageSizeInvalid:   {"PAGINATION", "Bad size %s (%s) max %d"},
+		},
+		FrameworkLogger: new(logging.ConsoleErrorLogger),
+	}
+
+	return p
+}
+
+func TestPageRequestField(t *testing.T) {
+
+	i, s := PageRequestField(new(offsetTarget))
+	test.ExpectInt(t, i, 1)
+	test.ExpectBool(t, s == OffsetPagination, true)
+
+	i, s = PageRequestField(new(cursorTarget))
+	test.ExpectInt(t, i, 0)
+	test.ExpectBool(t, s == CursorPagination, true)
+
+	_, s = PageRequestField(new(unexportedTarget))
+	test.ExpectBool(t, s == NoPagination, true)
+
+	_, s = PageRequestField(new(resWriter))
+	test.ExpectBool(t, s == NoPagination, true)
+
+	_, s = PageRequestField(nil)
+	test.ExpectBool(t, s == NoPagination, true)
+}
+
+func TestBindOffsetPageRequest(t *testing.T) {
+
+	p := paginator()
+
+	bind := func(query string, defaultSize, maxSize int) (*offsetTarget, *Request) {
+		q, _ := url.ParseQuery(query)
+
+		wsReq := new(Request)
+		ot := new(offsetTarget)
+		wsReq.RequestBody = ot
+
+		p.BindPageRequest(wsReq, q, 1, OffsetPagination, defaultSize, maxSize)
+
+		return ot, wsReq
+	}
+
+	ot, wsReq := bind("", 0, 0)
+	test.ExpectBool(t, wsReq.HasFrameworkErrors(), false)
+	test.ExpectInt(t, ot.Page.Page, 1)
+	test.ExpectInt(t, ot.Page.Size, 20)
+	test.ExpectInt(t, ot.Page.Offset(), 0)
+
+	ot, _ = bind("page=3&size=10", 0, 0)
+	test.ExpectInt(t, ot.Page.Page, 3)
+	test.ExpectInt(t, ot.Page.Offset(), 20)
+
+	ot, _ = bind("", 5, 0)
+	test.ExpectInt(t, ot.Page.Size, 5)
+
+	// Default sizes are limited to the maximum size
+	ot, _ = bind("", 50, 20)
+	test.ExpectInt(t, ot.Page.Size, 20)
+
+	ot, _ = bind("", 0, 10)
+	test.ExpectInt(t, ot.Page.Size, 10)
+
+	_, wsReq = bind("size=101", 0, 0)
+	test.ExpectInt(t, len(wsReq.FrameworkErrors), 1)
+	test.ExpectString(t, wsReq.FrameworkErrors[0].Message, "Bad size 101 (size) max 100")
+
+	_, wsReq = bind("size=50", 0, 40)
+	test.ExpectBool(t, wsReq.HasFrameworkErrors(), true)
+
+	_, wsReq = bind("page=0&size=x", 0, 0)
+	test.ExpectInt(t, len(wsReq.FrameworkErrors), 2)
+
+	// Page numbers whose offset would overflow are invalid
+	_, wsReq = bind("page=9223372036854775807&size=10", 0, 0)
+	test.ExpectInt(t, len(wsReq.FrameworkErrors), 1)
+
+	_, wsReq = bind("page=a", 0, 0)
+	test.ExpectString(t, wsReq.FrameworkErrors[0].Message, "Bad page a (page)")
+	test.ExpectString(t, wsReq.FrameworkErrors[0].Code, "PAGINATION")
+}
+
+func TestBindCursorPageRequest(t *testing.T) {
+
+	p := paginator()
+	q, _ := url.ParseQuery("cursor=abc&size=5")
+
+	wsReq := new(Request)
+	ct := new(cursorTarget)
+	wsReq.RequestBody = ct
+
+	p.BindPageRequest(wsReq, q, 0, CursorPagination, 0, 0)
+
+	test.ExpectBool(t, wsReq.HasFrameworkErrors(), false)
+	test.ExpectString(t, ct.Cursor, "abc")
+	test.ExpectInt(t, ct.Size, 5)
+}
+
+func TestPages(t *testing.T) {
+
+	pr := &OffsetPageRequest{Page: 2, Size: 10}
+
+	p := NewOffsetPage(pr, 25)
+	test.ExpectInt(t, int(p.TotalPages.Int64()), 3)
+	test.ExpectBool(t, p.HasNext, true)
+	test.ExpectBool(t, p.HasPrevious, true)
+
+	p = NewOffsetPage(&OffsetPageRequest{Page: 3, Size: 10}, 30)
+	test.ExpectBool(t, p.HasNext, false)
+
+	p = NewOffsetPage(&OffsetPageRequest{Page: 1, Size: 10}, 0)
+	test.ExpectInt(t, int(p.TotalPages.Int64()), 0)
+	test.ExpectBool(t, p.HasNext, false)
+	test.ExpectBool(t, p.HasPrevious, false)
+
+	p = NewOffsetPageWithoutTotal(pr, true)
+	test.ExpectBool(t, p.TotalItems == nil, true)
+	test.ExpectBool(t, p.HasNext, true)
+
+	p = NewCursorPage(&CursorPageRequest{Size: 5}, "next", "")
+	test.ExpectBool(t, p.HasNext, true)
+	test.ExpectBool(t, p.HasPrevious, false)
+}
+
+func TestPageLinks(t *testing.T) {
+
+	p := paginator()
+
+	links := p.Links("/artists?genre=rock&page=2", NewOffsetPage(&OffsetPageRequest{Page: 2, Size: 10}, 35))
+	test.ExpectString(t, links, `</artists?genre=rock&page=1&size=10>; rel="first", </artists?genre=rock&page=1&size=10>; rel="prev", </artists?genre=rock&page=3&size=10>; rel="next", </artists?genre=rock&page=4&size=10>; rel="last"`)
+
+	links = p.Links("/artists", NewOffsetPageWithoutTotal(&OffsetPageRequest{Page: 1, Size: 10}, true))
+	test.ExpectString(t, links, `</artists?page=2&size=10>; rel="next"`)
+
+	links = p.Links("/artists?cursor=b", NewCursorPage(&CursorPageRequest{Cursor: "b", Size: 5}, "c", "a"))
+	test.ExpectString(t, links, `</artists?size=5>; rel="first", </artists?cursor=a&size=5>; rel="prev", </artists?cursor=c&size=5>; rel="next"`)
+
+	test.ExpectString(t, p.Links("/artists", NewOffsetPage(&OffsetPageRequest{Page: 1, Size: 10}, 5)), "")
+	test.ExpectString(t, p.Links("/artists", nil), "")
+}
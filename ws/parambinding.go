@@ -7,6 +7,8 @@ import (
 	"github.com/graniticio/granitic/v2/logging"
 	rt "github.com/graniticio/granitic/v2/reflecttools"
 	"github.com/graniticio/granitic/v2/types"
+	"net/http"
+	"net/url"
 	"reflect"
 	"strconv"
 )
@@ -23,15 +25,19 @@ type ParamBinder struct {
 
 // BindPathParameters takes strings extracted from an HTTP's request path (using regular expression groups) and
 // injects them into fields on the Request.RequestBody. Any errors encountered are recorded as framework errors in
-// the Request.
+// the Request. Parameters with an empty field name are not bound.
 func (pb *ParamBinder) BindPathParameters(wsReq *Request, p *types.Params) {
 
 	t := wsReq.RequestBody
 
 	for i, fieldName := range p.ParamNames() {
 
+		if fieldName == "" {
+			continue
+		}
+
 		if rt.HasFieldOfName(t, fieldName) {
-			err := pb.bindValueToField(strconv.Itoa(i), fieldName, p, t, pb.pathParamError)
+			err := pb.bindValueToField(strconv.Itoa(i), fieldName, p, t, QueryTargetNotArray, pb.pathParamError)
 
 			if err != nil {
 
@@ -69,7 +75,7 @@ func (pb *ParamBinder) BindQueryParameters(wsReq *Request, targets map[string]st
 			if p.Exists(param) {
 				l.LogTracef("Binding parameter %s to field %s", param, field)
 
-				err := pb.bindValueToField(param, field, p, t, pb.queryParamError)
+				err := pb.bindValueToField(param, field, p, t, QueryTargetNotArray, pb.queryParamError)
 
 				if err != nil {
 					if fe, okay := err.(*FrameworkError); okay {
@@ -96,15 +102,99 @@ func (pb *ParamBinder) BindQueryParameters(wsReq *Request, targets map[string]st
 // injects them into fields on the Request.RequestBody assuming the parameters have exactly the same name as the target
 // fields. Any errors encountered are recorded as framework errors in the Request.
 func (pb *ParamBinder) AutoBindQueryParameters(wsReq *Request) {
+	pb.autoBind(wsReq, wsReq.QueryParams, QueryTargetNotArray, pb.queryParamError)
+}
+
+// BindFormParameters takes the fields submitted in an HTML form (URL encoded or multipart) and
+// injects them into fields on the Request.RequestBody assuming the form fields have exactly the same name as the target
+// fields. Any errors encountered are recorded as framework errors in the Request.
+func (pb *ParamBinder) BindFormParameters(wsReq *Request, p *types.Params) {
+	pb.autoBind(wsReq, p, FormTargetNotArray, pb.formParamError)
+}
+
+// BindHeaders takes the headers from an HTTP request and injects them into fields on the Request.RequestBody using
+// the keys of the supplied map as the name of the target fields and the values as the names of the headers. Any errors
+// encountered are recorded as framework errors in the Request.
+func (pb *ParamBinder) BindHeaders(wsReq *Request, h http.Header, targets map[string]string) {
+
+	values := make(url.Values)
+
+	for _, name := range targets {
+		if v := h.Values(name); len(v) > 0 {
+			values[name] = v
+		}
+	}
+
+	pb.bindNamed(wsReq, NewParamsForQuery(values), targets, "request header", HeaderTargetNotArray, HeaderNoTargetField, pb.headerParamError)
+}
+
+// BindCookies takes the cookies sent with an HTTP request and injects their values into fields on the Request.RequestBody
+// using the keys of the supplied map as the name of the target fields and the values as the names of the cookies. Any
+// errors encountered are recorded as framework errors in the Request.
+func (pb *ParamBinder) BindCookies(wsReq *Request, cookies []*http.Cookie, targets map[string]string) {
+
+	values := make(url.Values)
+
+	for _, c := range cookies {
+		values[c.Name] = append(values[c.Name], c.Value)
+	}
+
+	pb.bindNamed(wsReq, NewParamsForQuery(values), targets, "cookie", CookieTargetNotArray, CookieNoTargetField, pb.cookieParamError)
+}
+
+func (pb *ParamBinder) bindNamed(wsReq *Request, p *types.Params, targets map[string]string, source string, notArray, noTarget FrameworkErrorEvent, errorFn types.GenerateMappingError) {
+
+	t := wsReq.RequestBody
+	l := pb.FrameworkLogger
+
+	for field, name := range targets {
+
+		if !rt.HasFieldOfName(t, field) {
+			l.LogErrorf("No field named %s exists to bind a %s into", field, source)
+			m, c := pb.FrameworkErrors.MessageCode(noTarget, field, name)
+			wsReq.AddFrameworkError(NewHeaderBindFrameworkError(m, c, name, field))
+
+			continue
+		}
+
+		if !p.Exists(name) {
+			continue
+		}
+
+		l.LogTracef("Binding %s %s to field %s", source, name, field)
+
+		if !rt.TargetFieldIsArray(t, field) && p.MultipleValues(name) {
+			m, c := pb.FrameworkErrors.MessageCode(notArray, name)
+			wsReq.AddFrameworkError(NewHeaderBindFrameworkError(m, c, name, field))
+
+			continue
+		}
+
+		pi := new(types.ParamValueInjector)
+
+		if err := pi.BindValueToField(name, field, p, t, errorFn); err != nil {
+			if fe, okay := err.(*FrameworkError); okay {
+				wsReq.AddFrameworkError(fe)
+			} else {
+				l.LogErrorf("Unexpected error of type %t (was expecting *FrameworkError). Message was: %s", err, err.Error())
+			}
+		} else {
+			wsReq.RecordFieldAsBound(field)
+		}
+	}
+
+	pb.initialiseUnsetNilables(t)
+}
+
+func (pb *ParamBinder) autoBind(wsReq *Request, p *types.Params, notArray FrameworkErrorEvent, errorFn types.GenerateMappingError) {
 
 	t := wsReq.RequestBody
-	p := wsReq.QueryParams
 
 	for _, paramName := range p.ParamNames() {
 
 		if rt.HasFieldOfName(t, paramName) {
 
-			err := pb.bindValueToField(paramName, paramName, p, t, pb.queryParamError)
+			err := pb.bindValueToField(paramName, paramName, p, t, notArray, errorFn)
 
 			if err != nil {
 
@@ -125,10 +215,10 @@ func (pb *ParamBinder) AutoBindQueryParameters(wsReq *Request) {
 	pb.initialiseUnsetNilables(t)
 }
 
-func (pb *ParamBinder) bindValueToField(paramName string, fieldName string, p *types.Params, t interface{}, errorFn types.GenerateMappingError) error {
+func (pb *ParamBinder) bindValueToField(paramName string, fieldName string, p *types.Params, t interface{}, notArray FrameworkErrorEvent, errorFn types.GenerateMappingError) error {
 
 	if !rt.TargetFieldIsArray(t, fieldName) && p.MultipleValues(paramName) {
-		m, c := pb.FrameworkErrors.MessageCode(QueryTargetNotArray, fieldName)
+		m, c := pb.FrameworkErrors.MessageCode(notArray, fieldName)
 		return NewQueryBindFrameworkError(m, c, paramName, fieldName)
 	}
 
@@ -189,6 +279,19 @@ func (pb *ParamBinder) queryParamError(paramName string, fieldName string, typeN
 
 }
 
+func (pb *ParamBinder) formParamError(paramName string, fieldName string, typeName string, p *types.Params) error {
+
+	var v = ""
+
+	if p.Exists(paramName) {
+		v, _ = p.StringValue(paramName)
+	}
+
+	m, c := pb.FrameworkErrors.MessageCode(FormWrongType, paramName, typeName, v)
+	return NewQueryBindFrameworkError(m, c, paramName, fieldName)
+
+}
+
 func (pb *ParamBinder) pathParamError(paramName string, fieldName string, typeName string, p *types.Params) error {
 
 	var v = ""
@@ -201,3 +304,29 @@ func (pb *ParamBinder) pathParamError(paramName string, fieldName string, typeNa
 	return NewPathBindFrameworkError(m, c, fieldName)
 
 }
+
+func (pb *ParamBinder) headerParamError(paramName string, fieldName string, typeName string, p *types.Params) error {
+
+	var v = ""
+
+	if p.Exists(paramName) {
+		v, _ = p.StringValue(paramName)
+	}
+
+	m, c := pb.FrameworkErrors.MessageCode(HeaderWrongType, paramName, typeName, v)
+	return NewHeaderBindFrameworkError(m, c, paramName, fieldName)
+
+}
+
+func (pb *ParamBinder) cookieParamError(paramName string, fieldName string, typeName string, p *types.Params) error {
+
+	var v = ""
+
+	if p.Exists(paramName) {
+		v, _ = p.StringValue(paramName)
+	}
+
+	m, c := pb.FrameworkErrors.MessageCode(CookieWrongType, paramName, typeName, v)
+	return NewHeaderBindFrameworkError(m, c, paramName, fieldName)
+
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/graniticio/granitic/v2/logging"
 	"github.com/graniticio/granitic/v2/test"
 	"github.com/graniticio/granitic/v2/types"
+	"net/http"
 	"net/url"
 	"testing"
 )
@@ -400,3 +401,104 @@ type InvalidTargets struct {
 type InvalidTargetField struct{}
 
 type InvalidInterface interface{}
+
+func TestHeaderBinding(t *testing.T) {
+
+	h := make(http.Header)
+	h.Set("X-Tenant", "acme")
+	h.Set("X-Page-Size", "20")
+	h.Add("X-Ids", "1,2")
+	h.Set("X-Flag", "true")
+
+	tar := struct {
+		Tenant   string
+		PageSize int
+		IDs      []int64
+		Flag     *types.NilableBool
+		Missing  *types.NilableString
+	}{}
+
+	req := Request{RequestBody: &tar}
+
+	targets := map[string]string{
+		"Tenant":   "X-Tenant",
+		"PageSize": "x-page-size",
+		"IDs":      "X-Ids",
+		"Flag":     "X-Flag",
+		"Missing":  "X-Missing",
+	}
+
+	pb := createParamBinder()
+	pb.BindHeaders(&req, h, targets)
+
+	test.ExpectInt(t, len(req.FrameworkErrors), 0)
+	test.ExpectString(t, tar.Tenant, "acme")
+	test.ExpectInt(t, tar.PageSize, 20)
+	test.ExpectInt(t, len(tar.IDs), 2)
+	test.ExpectBool(t, tar.Flag.Bool(), true)
+	test.ExpectBool(t, tar.Missing.IsSet(), false)
+	test.ExpectBool(t, req.WasFieldBound("PageSize"), true)
+}
+
+func TestHeaderBindingErrors(t *testing.T) {
+
+	h := make(http.Header)
+	h.Set("X-Page-Size", "big")
+	h.Add("X-Tenant", "a")
+	h.Add("X-Tenant", "b")
+
+	tar := struct {
+		Tenant   string
+		PageSize int
+	}{}
+
+	req := Request{RequestBody: &tar}
+
+	targets := map[string]string{
+		"Tenant":   "X-Tenant",
+		"PageSize": "X-Page-Size",
+		"Other":    "X-Other",
+	}
+
+	pb := createParamBinder()
+	pb.BindHeaders(&req, h, targets)
+
+	test.ExpectInt(t, len(req.FrameworkErrors), 3)
+
+	for _, fe := range req.FrameworkErrors {
+		test.ExpectBool(t, fe.Phase == HeaderBind, true)
+	}
+}
+
+func TestCookieBinding(t *testing.T) {
+
+	cookies := []*http.Cookie{
+		{Name: "session", Value: "abc"},
+		{Name: "visits", Value: "3"},
+		{Name: "theme", Value: "dark"},
+		{Name: "theme", Value: "light"},
+	}
+
+	tar := struct {
+		Session string
+		Visits  *types.NilableInt64
+		Theme   string
+	}{}
+
+	req := Request{RequestBody: &tar}
+
+	targets := map[string]string{
+		"Session": "session",
+		"Visits":  "visits",
+		"Theme":   "theme",
+	}
+
+	pb := createParamBinder()
+	pb.BindCookies(&req, cookies, targets)
+
+	test.ExpectString(t, tar.Session, "abc")
+	test.ExpectInt(t, int(tar.Visits.Int64()), 3)
+
+	test.ExpectInt(t, len(req.FrameworkErrors), 1)
+	test.ExpectString(t, req.FrameworkErrors[0].ClientField, "theme")
+}
@@ -15,6 +15,9 @@ type Request struct {
 	// The HTTP method (GET, POST etc) of the underlying HTTP request.
 	HTTPMethod string
 
+	// The path portion of the underlying HTTP request's URL.
+	Path string
+
 	// If the HTTP request had a body and if the handler that generated this Request implements WsUnmarshallTarget,
 	// then RequestBody will contain a struct representation of the request body.
 	RequestBody interface{}
@@ -81,6 +81,7 @@ import (
 	"github.com/graniticio/granitic/v2/httpendpoint"
 	"github.com/graniticio/granitic/v2/iam"
 	"net/http"
+	"time"
 )
 
 // Outcome is an enumeration of the high-level result of processing a request. Used internally.
@@ -147,6 +148,18 @@ type Response struct {
 	// Headers that should be set on the HTTP response.
 	Headers map[string]string
 
+	// An entity tag identifying the current representation of the resource, including quotes and any weakness indicator
+	// (e.g. "v12" or W/"v12"). If set, takes precedence over any entity tag the handler would compute from the response body.
+	ETag string
+
+	// The time at which the resource was last modified. If set, used to evaluate If-Modified-Since request headers and sent
+	// to the client as the Last-Modified header.
+	LastModified time.Time
+
+	// A description of the page of results in Body, if the results are paginated (see NewOffsetPage and NewCursorPage).
+	// Used to generate Link headers and, if the response is wrapped by a PageWrapper, described in the response.
+	Page *Page
+
 	// If the type of response rendering is template based (e.g. using the XMLWs facility in template mode), this field
 	// can be used to override any default templates or the template associated with the handler that created this response.
 	Template string
@@ -191,6 +204,20 @@ type ErrorFormatter interface {
 	FormatErrors(errors *ServiceErrors) interface{}
 }
 
+// DocumentErrorFormatter is implemented by ErrorFormatters that render errors as a standalone document with its own
+// media type (for example an RFC 7807 problem details document). A MarshallingResponseWriter writes such a document as
+// the entire body of any response containing errors, bypassing its ResponseWrapper.
+type DocumentErrorFormatter interface {
+	ErrorFormatter
+
+	// FormatErrorDocument converts the supplied errors into a document for a response with the supplied HTTP status
+	// code. The request that resulted in the errors may be nil (e.g. if the errors were generated by the HTTP server).
+	FormatErrorDocument(errors *ServiceErrors, status int, req *Request) interface{}
+
+	// ContentType returns the value of the Content-Type header to be set on responses containing an error document.
+	ContentType() string
+}
+
 // WriteHeaders writes the supplied map as HTTP headers.
 func WriteHeaders(w http.ResponseWriter, headers map[string]string) {
 
@@ -206,6 +233,22 @@ type ResponseWrapper interface {
 	WrapResponse(body interface{}, errors interface{}) interface{}
 }
 
+// StatusAllowsBody returns false if the HTTP specification does not allow a response with the supplied status to have
+// a body (1xx, 204 and 304 responses).
+func StatusAllowsBody(status int) bool {
+
+	switch {
+	case status >= 100 && status <= 199:
+		return false
+	case status == http.StatusNoContent:
+		return false
+	case status == http.StatusNotModified:
+		return false
+	}
+
+	return true
+}
+
 // MergeHeaders merges together the headers that have been defined on the Response, the static default headers attache to this writer
 // and (optionally) those constructed by the  ws.CommonResponseHeaderBuilder attached to this writer. The order of precedence,
 // from lowest to highest, is static headers, constructed headers, headers in the Response.
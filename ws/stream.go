@@ -0,0 +1,259 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package ws
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/logging"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StreamFormat identifies the format used to write the items in a streamed response.
+type StreamFormat uint
+
+const (
+	// ServerSentEvents writes each item as an event in a text/event-stream response.
+	ServerSentEvents StreamFormat = iota
+
+	// NDJSON writes each item as a line of JSON in an application/x-ndjson response.
+	NDJSON
+)
+
+// ContentType returns the media type of responses streamed in this format.
+func (sf StreamFormat) ContentType() string {
+
+	if sf == NDJSON {
+		return "application/x-ndjson"
+	}
+
+	return "text/event-stream"
+}
+
+// StreamSource is implemented by types that supply the items in a streamed response one at a time.
+type StreamSource interface {
+	// Next blocks until the next item is available. Returns false when there are no more items. Implementations should
+	// stop waiting and return false if the supplied context is cancelled (e.g. because the client has disconnected).
+	Next(ctx context.Context) (item interface{}, more bool, err error)
+}
+
+// StreamedResponse can be set as the Body of a Response to have items written to the client as they become available,
+// rather than marshalling the entire body in one go.
+type StreamedResponse struct {
+	// The format in which items are written.
+	Format StreamFormat
+
+	// The source of items to be written.
+	Source StreamSource
+}
+
+// NewChannelStream creates a StreamedResponse that writes each item received on the supplied channel until the channel is
+// closed or the client disconnects.
+func NewChannelStream(format StreamFormat, items <-chan interface{}) *StreamedResponse {
+	return &StreamedResponse{Format: format, Source: channelSource(items)}
+}
+
+// NewIteratorStream creates a StreamedResponse that writes each item returned by the supplied StreamSource.
+func NewIteratorStream(format StreamFormat, source StreamSource) *StreamedResponse {
+	return &StreamedResponse{Format: format, Source: source}
+}
+
+type channelSource <-chan interface{}
+
+func (cs channelSource) Next(ctx context.Context) (interface{}, bool, error) {
+
+	select {
+	case <-ctx.Done():
+		return nil, false, nil
+	case item, more := <-cs:
+		return item, more, nil
+	}
+}
+
+// ServerSentEvent allows the fields of an event in a ServerSentEvents stream to be controlled. Items that are not of this type are
+// written as events with only a data field.
+type ServerSentEvent struct {
+	// Written as the event's id field, if set.
+	ID string
+
+	// Written as the event's event field (the event type), if set.
+	Event string
+
+	// The event's data. Strings are written as-is, other types are marshalled to JSON.
+	Data interface{}
+
+	// Written as the event's retry field (in milliseconds), if greater than zero.
+	Retry int
+}
+
+// ResponseStreamer writes a StreamedResponse to an HTTP response, flushing each item to the client as it is written.
+type ResponseStreamer struct {
+	// Injected by Granitic
+	FrameworkLogger logging.Logger
+
+	// The interval (in milliseconds) between heartbeats sent to keep the connection open while waiting for an item.
+	// Zero means no heartbeats are sent.
+	HeartbeatIntervalMS int
+}
+
+type streamedItem struct {
+	item interface{}
+	more bool
+	err  error
+}
+
+// WriteStream writes each item supplied by the StreamedResponse's source, until the source has no more items, an error
+// occurs or the client disconnects (the supplied context is cancelled).
+func (rs *ResponseStreamer) WriteStream(ctx context.Context, sr *StreamedResponse, w *httpendpoint.HTTPResponseWriter) error {
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	items := make(chan streamedItem)
+
+	go func() {
+
+		defer close(items)
+
+		for {
+			item, more, err := sr.Source.Next(ctx)
+
+			select {
+			case items <- streamedItem{item, more, err}:
+			case <-ctx.Done():
+				return
+			}
+
+			if !more || err != nil {
+				return
+			}
+		}
+	}()
+
+	// Send headers to the client straight away
+	w.Flush()
+
+	var heartbeat <-chan time.Time
+
+	if rs.HeartbeatIntervalMS > 0 {
+		t := time.NewTicker(time.Duration(rs.HeartbeatIntervalMS) * time.Millisecond)
+		defer t.Stop()
+
+		heartbeat = t.C
+	}
+
+	for {
+
+		var b []byte
+
+		select {
+		case <-ctx.Done():
+			if rs.FrameworkLogger != nil && rs.FrameworkLogger.IsLevelEnabled(logging.Debug) {
+				rs.FrameworkLogger.LogDebugfCtx(ctx, "Client disconnected before the end of a streamed response")
+			}
+
+			return nil
+
+		case <-heartbeat:
+			b = heartbeatFor(sr.Format)
+
+		case si, open := <-items:
+
+			if !open {
+				return nil
+			}
+
+			if si.err != nil {
+				return si.err
+			}
+
+			if !si.more {
+				return nil
+			}
+
+			var err error
+
+			if b, err = encodeStreamItem(sr.Format, si.item); err != nil {
+				return err
+			}
+		}
+
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+
+		w.Flush()
+	}
+}
+
+func heartbeatFor(format StreamFormat) []byte {
+
+	if format == NDJSON {
+		return []byte("\n")
+	}
+
+	return []byte(":\n\n")
+}
+
+func encodeStreamItem(format StreamFormat, item interface{}) ([]byte, error) {
+
+	if format == NDJSON {
+
+		b, err := json.Marshal(item)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return append(b, '\n'), nil
+	}
+
+	e, found := item.(*ServerSentEvent)
+
+	if !found {
+		e = &ServerSentEvent{Data: item}
+	}
+
+	var data string
+
+	switch d := e.Data.(type) {
+	case string:
+		data = d
+	default:
+		b, err := json.Marshal(d)
+
+		if err != nil {
+			return nil, err
+		}
+
+		data = string(b)
+	}
+
+	buf := new(bytes.Buffer)
+
+	if e.ID != "" {
+		fmt.Fprintf(buf, "id: %s\n", e.ID)
+	}
+
+	if e.Event != "" {
+		fmt.Fprintf(buf, "event: %s\n", e.Event)
+	}
+
+	if e.Retry > 0 {
+		buf.WriteString("retry: " + strconv.Itoa(e.Retry) + "\n")
+	}
+
+	for _, line := range strings.Split(data, "\n") {
+		buf.WriteString("data: " + line + "\n")
+	}
+
+	buf.WriteString("\n")
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,124 @@
+package ws
+
+import (
+	"context"
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/logging"
+	"github.com/graniticio/granitic/v2/test"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type countingSource struct {
+	remaining int
+}
+
+func (cs *countingSource) Next(ctx context.Context) (interface{}, bool, error) {
+
+	if cs.remaining == 0 {
+		return nil, false, nil
+	}
+
+	cs.remaining--
+
+	return map[string]int{"Remaining": cs.remaining}, true, nil
+}
+
+type blockingSource struct{}
+
+func (bs *blockingSource) Next(ctx context.Context) (interface{}, bool, error) {
+
+	<-ctx.Done()
+
+	return nil, false, nil
+}
+
+func streamingWriter() *MarshallingResponseWriter {
+
+	rw := new(MarshallingResponseWriter)
+	rw.FrameworkLogger = new(logging.ConsoleErrorLogger)
+	rw.StatusDeterminer = &GraniticHTTPStatusCodeDeterminer{NoError: http.StatusOK}
+	rw.DefaultHeaders = map[string]string{"Content-Type": "application/json"}
+
+	return rw
+}
+
+func TestServerSentEventEncoding(t *testing.T) {
+
+	b, err := encodeStreamItem(ServerSentEvents, map[string]string{"A": "B"})
+
+	test.ExpectNil(t, err)
+	test.ExpectString(t, string(b), "data: {\"A\":\"B\"}\n\n")
+
+	b, err = encodeStreamItem(ServerSentEvents, &ServerSentEvent{ID: "7", Event: "update", Data: "line1\nline2", Retry: 500})
+
+	test.ExpectNil(t, err)
+	test.ExpectString(t, string(b), "id: 7\nevent: update\nretry: 500\ndata: line1\ndata: line2\n\n")
+
+	b, err = encodeStreamItem(NDJSON, []int{1, 2})
+
+	test.ExpectNil(t, err)
+	test.ExpectString(t, string(b), "[1,2]\n")
+}
+
+func TestChannelStream(t *testing.T) {
+
+	items := make(chan interface{}, 2)
+	items <- "first"
+	items <- "second"
+	close(items)
+
+	res := NewResponse(nil)
+	res.Body = NewChannelStream(ServerSentEvents, items)
+
+	rec := httptest.NewRecorder()
+	w := httpendpoint.NewHTTPResponseWriter(rec)
+
+	if err := streamingWriter().Write(context.Background(), &ProcessState{WsResponse: res, HTTPResponseWriter: w}, Normal); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	test.ExpectString(t, rec.Header().Get("Content-Type"), "text/event-stream")
+	test.ExpectString(t, rec.Header().Get("Cache-Control"), "no-cache")
+	test.ExpectString(t, rec.Body.String(), "data: first\n\ndata: second\n\n")
+	test.ExpectBool(t, rec.Flushed, true)
+	test.ExpectInt(t, w.BytesServed, rec.Body.Len())
+}
+
+func TestIteratorStream(t *testing.T) {
+
+	res := NewResponse(nil)
+	res.Body = NewIteratorStream(NDJSON, &countingSource{remaining: 2})
+
+	rec := httptest.NewRecorder()
+	w := httpendpoint.NewHTTPResponseWriter(rec)
+
+	if err := streamingWriter().Write(context.Background(), &ProcessState{WsResponse: res, HTTPResponseWriter: w}, Normal); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	test.ExpectString(t, rec.Header().Get("Content-Type"), "application/x-ndjson")
+	test.ExpectString(t, rec.Body.String(), "{\"Remaining\":1}\n{\"Remaining\":0}\n")
+}
+
+func TestStreamHeartbeatAndDisconnect(t *testing.T) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Millisecond)
+	defer cancel()
+
+	rs := &ResponseStreamer{HeartbeatIntervalMS: 10}
+
+	rec := httptest.NewRecorder()
+	w := httpendpoint.NewHTTPResponseWriter(rec)
+
+	if err := rs.WriteStream(ctx, NewIteratorStream(ServerSentEvents, new(blockingSource)), w); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if !strings.HasPrefix(rec.Body.String(), ":\n\n") {
+		t.Errorf("Expected heartbeats to be sent while waiting for items")
+	}
+}
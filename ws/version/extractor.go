@@ -0,0 +1,137 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+package version
+
+import (
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// required creates a RequiredVersion holding the supplied version, or the default version if none was supplied.
+func required(v string, def string) httpendpoint.RequiredVersion {
+
+	if v = strings.TrimSpace(v); v == "" {
+		v = def
+	}
+
+	rv := make(httpendpoint.RequiredVersion)
+
+	if v != "" {
+		rv[Key] = v
+	}
+
+	return rv
+}
+
+// HeaderExtractor finds the requested version in a request header, e.g. Accept-Version: 2.1
+type HeaderExtractor struct {
+	// The name of the header holding the version.
+	Header string
+
+	// The version assumed if the request does not have the header. May be empty.
+	Default string
+}
+
+// Extract implements httpendpoint.RequestedVersionExtractor
+func (he *HeaderExtractor) Extract(req *http.Request) httpendpoint.RequiredVersion {
+	return required(req.Header.Get(he.Header), he.Default)
+}
+
+// QueryExtractor finds the requested version in a query parameter, e.g. /artist/12?version=2.1
+type QueryExtractor struct {
+	// The name of the query parameter holding the version.
+	Param string
+
+	// The version assumed if the request does not have the parameter. May be empty.
+	Default string
+}
+
+// Extract implements httpendpoint.RequestedVersionExtractor
+func (qe *QueryExtractor) Extract(req *http.Request) httpendpoint.RequiredVersion {
+	return required(req.URL.Query().Get(qe.Param), qe.Default)
+}
+
+// MediaTypeExtractor finds the requested version in a parameter of the media types listed in the Accept header, e.g.
+// Accept: application/json; version=2.1  The first media type with the parameter is used.
+type MediaTypeExtractor struct {
+	// The name of the media type parameter holding the version.
+	Param string
+
+	// The version assumed if no media type has the parameter. May be empty.
+	Default string
+}
+
+// Extract implements httpendpoint.RequestedVersionExtractor
+func (me *MediaTypeExtractor) Extract(req *http.Request) httpendpoint.RequiredVersion {
+
+	for _, accept := range req.Header.Values("Accept") {
+		for _, mt := range strings.Split(accept, ",") {
+
+			if _, params, err := mime.ParseMediaType(mt); err == nil && params[me.Param] != "" {
+				return required(params[me.Param], me.Default)
+			}
+		}
+	}
+
+	return required("", me.Default)
+}
+
+// PathPrefixExtractor finds the requested version in the first segment of the request's path, e.g. /v2/artist/12
+// Requests are routed to handlers using the path with the version segment removed (/artist/12 in the example), so
+// handlers do not need to include the version in their PathPattern or PathTemplate. Implements
+// httpendpoint.VersionedPathExtractor
+type PathPrefixExtractor struct {
+	// The text preceding the version number in the segment (e.g. v). Must not be empty, otherwise any path whose first
+	// segment is a number (e.g. /2020/report) would be treated as versioned.
+	Prefix string
+
+	// The version assumed if the path does not start with a version segment. May be empty.
+	Default string
+}
+
+// Extract implements httpendpoint.RequestedVersionExtractor
+func (pe *PathPrefixExtractor) Extract(req *http.Request) httpendpoint.RequiredVersion {
+
+	v, _ := pe.split(req.URL.Path)
+
+	return required(v, pe.Default)
+}
+
+// UnversionedPath implements httpendpoint.VersionedPathExtractor
+func (pe *PathPrefixExtractor) UnversionedPath(path string) string {
+
+	_, p := pe.split(path)
+
+	return p
+}
+
+// split separates a path into a version and the remainder of the path. If the first segment of the path is not a
+// version, an empty version and the unmodified path are returned.
+func (pe *PathPrefixExtractor) split(path string) (string, string) {
+
+	if pe.Prefix == "" || !strings.HasPrefix(path, "/") {
+		return "", path
+	}
+
+	segment := path[1:]
+	rest := "/"
+
+	if i := strings.Index(segment, "/"); i >= 0 {
+		segment, rest = segment[:i], segment[i:]
+	}
+
+	if !strings.HasPrefix(segment, pe.Prefix) {
+		return "", path
+	}
+
+	v := segment[len(pe.Prefix):]
+
+	if _, err := Parse(v); err != nil || strings.HasPrefix(v, "v") || strings.HasPrefix(v, "V") {
+		return "", path
+	}
+
+	return v, rest
+}
@@ -0,0 +1,70 @@
+package version
+
+import (
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/test"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func requested(rv httpendpoint.RequiredVersion) string {
+
+	v, _ := rv[Key].(string)
+
+	return v
+}
+
+func TestHeaderExtractor(t *testing.T) {
+
+	e := &HeaderExtractor{Header: "Accept-Version", Default: "1"}
+
+	r := httptest.NewRequest(http.MethodGet, "/artist", nil)
+	test.ExpectString(t, requested(e.Extract(r)), "1")
+
+	r.Header.Set("Accept-Version", "2.1")
+	test.ExpectString(t, requested(e.Extract(r)), "2.1")
+
+	e.Default = ""
+	_, found := e.Extract(httptest.NewRequest(http.MethodGet, "/artist", nil))[Key]
+	test.ExpectBool(t, found, false)
+}
+
+func TestQueryExtractor(t *testing.T) {
+
+	e := &QueryExtractor{Param: "version"}
+
+	test.ExpectString(t, requested(e.Extract(httptest.NewRequest(http.MethodGet, "/artist?version=3", nil))), "3")
+	test.ExpectString(t, requested(e.Extract(httptest.NewRequest(http.MethodGet, "/artist", nil))), "")
+}
+
+func TestMediaTypeExtractor(t *testing.T) {
+
+	e := &MediaTypeExtractor{Param: "version"}
+
+	r := httptest.NewRequest(http.MethodGet, "/artist", nil)
+	r.Header.Set("Accept", "text/html, application/json; version=2.0; q=0.9")
+
+	test.ExpectString(t, requested(e.Extract(r)), "2.0")
+
+	r.Header.Set("Accept", "application/json")
+	test.ExpectString(t, requested(e.Extract(r)), "")
+}
+
+func TestPathPrefixExtractor(t *testing.T) {
+
+	e := &PathPrefixExtractor{Prefix: "v"}
+
+	test.ExpectString(t, requested(e.Extract(httptest.NewRequest(http.MethodGet, "/v2/artist/1", nil))), "2")
+	test.ExpectString(t, e.UnversionedPath("/v2/artist/1"), "/artist/1")
+	test.ExpectString(t, e.UnversionedPath("/v2.1"), "/")
+	test.ExpectString(t, e.UnversionedPath("/venue/1"), "/venue/1")
+	test.ExpectString(t, e.UnversionedPath("/artist/v2"), "/artist/v2")
+	test.ExpectString(t, requested(e.Extract(httptest.NewRequest(http.MethodGet, "/venue/1", nil))), "")
+
+	// Without a prefix, numeric path segments are not mistaken for versions
+	e = &PathPrefixExtractor{}
+
+	test.ExpectString(t, requested(e.Extract(httptest.NewRequest(http.MethodGet, "/2020/report", nil))), "")
+	test.ExpectString(t, e.UnversionedPath("/2020/report"), "/2020/report")
+}
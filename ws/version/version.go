@@ -0,0 +1,353 @@
+// Copyright 2016-2020 Granitic. All rights reserved.
+// Use of this source code is governed by an Apache 2.0 license that can be found in the LICENSE file at the root of this project.
+
+/*
+Package version provides ready-made components for routing web service requests to handlers according to the version of
+functionality requested by the client.
+
+An extractor (an implementation of httpendpoint.RequestedVersionExtractor) finds the version requested by the client.
+Extractors are provided for versions supplied in a request header (HeaderExtractor), as a prefix of the request's path
+(PathPrefixExtractor), in a query parameter (QueryExtractor) or as a parameter of a media type in the Accept header
+(MediaTypeExtractor). Each stores the version it finds in the httpendpoint.RequiredVersion under the key Key.
+
+A RangeAssessor (an implementation of handler.WsVersionAssessor) decides whether or not a handler supports the requested
+version by checking it against a semantic version range, such as ^2.1 or >=1.0 <3. The simplest way of using one is to set
+the SupportedVersions field on a handler.WsHandler.
+
+The HTTPServer facility creates an extractor if HTTPServer.Versioning.Enabled is set to true in configuration (see
+https://granitic.io/ref/version-routing).
+*/
+package version
+
+import (
+	"fmt"
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"strconv"
+	"strings"
+)
+
+// Key is the key under which extractors in this package store the requested version in an httpendpoint.RequiredVersion.
+const Key = "Version"
+
+// Version is a semantic version (major.minor.patch). Pre-release and build information is not supported.
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+// Compare returns -1, 0 or 1 if this version is respectively lower than, equal to or higher than the supplied version.
+func (v Version) Compare(o Version) int {
+
+	for _, d := range []int{v.Major - o.Major, v.Minor - o.Minor, v.Patch - o.Patch} {
+		switch {
+		case d < 0:
+			return -1
+		case d > 0:
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// String returns the version in the form major.minor.patch
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Parse converts a string like 2, 2.1, 2.1.3 or v2.1 into a Version. Missing minor and patch numbers are treated as zero.
+func Parse(s string) (Version, error) {
+
+	v, parts, err := parsePartial(s)
+
+	if err == nil && parts < 0 {
+		err = fmt.Errorf("%s is not a valid version", s)
+	}
+
+	return v, err
+}
+
+// parsePartial parses a version that may omit or wildcard (x, X or *) its minor and patch numbers. Returns the number
+// of components that were specified, or -1 if the major number is a wildcard.
+func parsePartial(s string) (Version, int, error) {
+
+	var v Version
+
+	s = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(s), "v"), "V")
+
+	if s == "" {
+		return v, 0, fmt.Errorf("a version cannot be empty")
+	}
+
+	elements := strings.Split(s, ".")
+
+	if len(elements) > 3 {
+		return v, 0, fmt.Errorf("%s is not a valid version", s)
+	}
+
+	numbers := []*int{&v.Major, &v.Minor, &v.Patch}
+
+	for i, e := range elements {
+
+		if e == "x" || e == "X" || e == "*" {
+
+			if i == 0 {
+				return v, -1, nil
+			}
+
+			return v, i, nil
+		}
+
+		n, err := strconv.Atoi(e)
+
+		if err != nil || n < 0 {
+			return v, 0, fmt.Errorf("%s is not a valid version", s)
+		}
+
+		*numbers[i] = n
+	}
+
+	return v, len(elements), nil
+}
+
+// FromRequired extracts the version stored under Key in the supplied RequiredVersion. Returns false if no version is
+// present or it is not valid.
+func FromRequired(rv httpendpoint.RequiredVersion) (Version, bool) {
+
+	switch r := rv[Key].(type) {
+	case Version:
+		return r, true
+	case string:
+		if v, err := Parse(r); err == nil {
+			return v, true
+		}
+	}
+
+	return Version{}, false
+}
+
+type comparator struct {
+	op string
+	v  Version
+}
+
+func (c comparator) allows(v Version) bool {
+
+	r := v.Compare(c.v)
+
+	switch c.op {
+	case ">=":
+		return r >= 0
+	case ">":
+		return r > 0
+	case "<=":
+		return r <= 0
+	case "<":
+		return r < 0
+	default:
+		return r == 0
+	}
+}
+
+// Range is a set of versions described in the style used by npm and Cargo. A range is made up of one or more sets of
+// comparators separated by ||. A version is in the range if it satisfies every comparator in any of the sets. Comparators
+// within a set are separated by spaces and can be:
+//
+//	1.2.3, =1.2.3       Exactly 1.2.3
+//	>1.2, >=1.2         Greater than (or equal to) 1.2.0
+//	<1.2, <=1.2         Less than (or equal to) 1.2.x
+//	1, 1.x, 1.2.*       Any version with the specified major (and minor) number
+//	^1.2.3              Compatible with 1.2.3: >=1.2.3 <2.0.0 (or <0.3.0 for ^0.2.3)
+//	~1.2.3              Patch updates to 1.2.3: >=1.2.3 <1.3.0
+//	*                   Any version
+type Range struct {
+	expression string
+	sets       [][]comparator
+}
+
+// ParseRange converts a string expression into a Range.
+func ParseRange(expression string) (*Range, error) {
+
+	r := new(Range)
+	r.expression = expression
+
+	for _, set := range strings.Split(expression, "||") {
+
+		var comparators []comparator
+
+		for _, c := range strings.Fields(set) {
+
+			parsed, err := parseComparator(c)
+
+			if err != nil {
+				return nil, fmt.Errorf("unable to parse version range %s: %s", expression, err.Error())
+			}
+
+			comparators = append(comparators, parsed...)
+		}
+
+		if len(comparators) == 0 {
+			return nil, fmt.Errorf("unable to parse version range %s: empty set of comparators", expression)
+		}
+
+		r.sets = append(r.sets, comparators)
+	}
+
+	return r, nil
+}
+
+// Contains returns true if the supplied version is in the range.
+func (r *Range) Contains(v Version) bool {
+
+SetLoop:
+	for _, set := range r.sets {
+
+		for _, c := range set {
+			if !c.allows(v) {
+				continue SetLoop
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
+
+// String returns the expression the Range was parsed from.
+func (r *Range) String() string {
+	return r.expression
+}
+
+// parseComparator converts a single comparator into one or more simple comparisons
+func parseComparator(c string) ([]comparator, error) {
+
+	op := ""
+
+	for _, prefix := range []string{">=", "<=", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(c, prefix) {
+			op = prefix
+			c = c[len(prefix):]
+			break
+		}
+	}
+
+	v, parts, err := parsePartial(c)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if parts < 0 {
+		// Wildcard major version
+		switch op {
+		case "", "=", ">=", "<=", "^", "~":
+			return []comparator{{">=", Version{}}}, nil
+		default:
+			return nil, fmt.Errorf("%s%s can never be satisfied", op, c)
+		}
+	}
+
+	// The lowest version that is higher than every version matching the partial version
+	next := v
+
+	switch parts {
+	case 1:
+		next = Version{Major: v.Major + 1}
+	case 2:
+		next = Version{Major: v.Major, Minor: v.Minor + 1}
+	default:
+		next.Patch++
+	}
+
+	switch op {
+	case "", "=":
+		if parts == 3 {
+			return []comparator{{"=", v}}, nil
+		}
+
+		return []comparator{{">=", v}, {"<", next}}, nil
+	case ">":
+		return []comparator{{">=", next}}, nil
+	case ">=":
+		return []comparator{{">=", v}}, nil
+	case "<":
+		return []comparator{{"<", v}}, nil
+	case "<=":
+		return []comparator{{"<", next}}, nil
+	case "~":
+		if parts == 1 {
+			return []comparator{{">=", v}, {"<", next}}, nil
+		}
+
+		return []comparator{{">=", v}, {"<", Version{Major: v.Major, Minor: v.Minor + 1}}}, nil
+	default:
+		return []comparator{{">=", v}, {"<", caretLimit(v, parts)}}, nil
+	}
+}
+
+// caretLimit finds the lowest version that is not compatible with the supplied version (the first non-zero number
+// specified is not allowed to change)
+func caretLimit(v Version, parts int) Version {
+
+	switch {
+	case v.Major > 0 || parts == 1:
+		return Version{Major: v.Major + 1}
+	case v.Minor > 0 || parts == 2:
+		return Version{Minor: v.Minor + 1}
+	default:
+		return Version{Patch: v.Patch + 1}
+	}
+}
+
+// RangeAssessor is a handler.WsVersionAssessor that supports requested versions that are within a semantic version Range.
+type RangeAssessor struct {
+	// The range of versions supported, e.g. ^2.1 (see Range).
+	Range string
+
+	// Whether or not requests that do not specify a version (or specify a version that is not valid) are supported.
+	AllowUnversioned bool
+
+	parsed *Range
+}
+
+// NewRangeAssessor creates a RangeAssessor for the supplied range expression.
+func NewRangeAssessor(expression string, allowUnversioned bool) (*RangeAssessor, error) {
+
+	ra := new(RangeAssessor)
+	ra.Range = expression
+	ra.AllowUnversioned = allowUnversioned
+
+	return ra, ra.StartComponent()
+}
+
+// SupportsVersion implements handler.WsVersionAssessor
+func (ra *RangeAssessor) SupportsVersion(handlerName string, version httpendpoint.RequiredVersion) bool {
+
+	v, found := FromRequired(version)
+
+	if !found {
+		return ra.AllowUnversioned
+	}
+
+	return ra.parsed.Contains(v)
+}
+
+// StartComponent parses the Range expression.
+func (ra *RangeAssessor) StartComponent() error {
+
+	if ra.parsed != nil {
+		return nil
+	}
+
+	r, err := ParseRange(ra.Range)
+
+	if err != nil {
+		return err
+	}
+
+	ra.parsed = r
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package version
+
+import (
+	"github.com/graniticio/granitic/v2/httpendpoint"
+	"github.com/graniticio/granitic/v2/test"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+
+	v, err := Parse("v2.1")
+
+	test.ExpectNil(t, err)
+	test.ExpectString(t, v.String(), "2.1.0")
+
+	for _, invalid := range []string{"", "a", "1.2.3.4", "-1", "x"} {
+		if _, err := Parse(invalid); err == nil {
+			t.Errorf("Expected %s to be invalid", invalid)
+		}
+	}
+
+	a, _ := Parse("1.10")
+	b, _ := Parse("1.9.9")
+
+	test.ExpectInt(t, a.Compare(b), 1)
+	test.ExpectInt(t, b.Compare(a), -1)
+	test.ExpectInt(t, a.Compare(a), 0)
+}
+
+func TestRanges(t *testing.T) {
+
+	cases := []struct {
+		expression string
+		in         []string
+		out        []string
+	}{
+		{"1.2.3", []string{"1.2.3"}, []string{"1.2.4", "1.2"}},
+		{"2", []string{"2", "2.9.9"}, []string{"1.9", "3"}},
+		{"1.x", []string{"1.0", "1.5"}, []string{"2"}},
+		{"1.2.*", []string{"1.2.0", "1.2.7"}, []string{"1.3"}},
+		{"*", []string{"0.0.1", "9"}, nil},
+		{"^1.2", []string{"1.2", "1.9"}, []string{"1.1", "2"}},
+		{"^0.2.3", []string{"0.2.3", "0.2.9"}, []string{"0.3", "0.2.2"}},
+		{"^0.0.3", []string{"0.0.3"}, []string{"0.0.4"}},
+		{"~1.2.3", []string{"1.2.3", "1.2.8"}, []string{"1.3"}},
+		{"~1", []string{"1.9"}, []string{"2"}},
+		{">1.2", []string{"1.3"}, []string{"1.2.5"}},
+		{">=1.2 <3", []string{"1.2", "2.9"}, []string{"1.1", "3"}},
+		{"<=1.2", []string{"1.2.9"}, []string{"1.3"}},
+		{"1 || >=3", []string{"1.5", "3", "4"}, []string{"2"}},
+	}
+
+	for _, c := range cases {
+
+		r, err := ParseRange(c.expression)
+
+		if err != nil {
+			t.Fatalf("Unexpected error parsing %s: %s", c.expression, err.Error())
+		}
+
+		for _, in := range c.in {
+			v, _ := Parse(in)
+
+			if !r.Contains(v) {
+				t.Errorf("Expected %s to contain %s", c.expression, in)
+			}
+		}
+
+		for _, out := range c.out {
+			v, _ := Parse(out)
+
+			if r.Contains(v) {
+				t.Errorf("Expected %s not to contain %s", c.expression, out)
+			}
+		}
+	}
+
+	for _, invalid := range []string{"", ">=a", "1 ||", ">*"} {
+		if _, err := ParseRange(invalid); err == nil {
+			t.Errorf("Expected %s to be an invalid range", invalid)
+		}
+	}
+}
+
+func TestRangeAssessor(t *testing.T) {
+
+	ra, err := NewRangeAssessor("^2", false)
+
+	test.ExpectNil(t, err)
+	test.ExpectBool(t, ra.SupportsVersion("h", httpendpoint.RequiredVersion{Key: "2.3"}), true)
+	test.ExpectBool(t, ra.SupportsVersion("h", httpendpoint.RequiredVersion{Key: Version{Major: 3}}), false)
+	test.ExpectBool(t, ra.SupportsVersion("h", httpendpoint.RequiredVersion{}), false)
+	test.ExpectBool(t, ra.SupportsVersion("h", httpendpoint.RequiredVersion{Key: "latest"}), false)
+
+	ra.AllowUnversioned = true
+	test.ExpectBool(t, ra.SupportsVersion("h", httpendpoint.RequiredVersion{}), true)
+
+	_, err = NewRangeAssessor("^", false)
+	test.ExpectBool(t, err == nil, false)
+}
@@ -156,10 +156,13 @@ func (rw *TemplatedXMLResponseWriter) writeAbnormalStatus(ctx context.Context, s
 
 	res := new(ws.Response)
 	res.HTTPStatus = status
+
 	var errors ws.ServiceErrors
 
-	e := rw.FrameworkErrors.HTTPError(status)
-	errors.AddError(e)
+	if ws.StatusAllowsBody(status) {
+		e := rw.FrameworkErrors.HTTPError(status)
+		errors.AddError(e)
+	}
 
 	res.Errors = &errors
 
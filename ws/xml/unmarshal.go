@@ -4,25 +4,19 @@
 package xml
 
 import (
-	"bytes"
 	"context"
 	"encoding/xml"
 	"github.com/graniticio/granitic/v2/ws"
 	"net/http"
 )
 
-// Unmarshaller is a component wrapper over Go's xml.Unmarshal method
+// Unmarshaller is a component wrapper over Go's xml.Decoder
 type Unmarshaller struct {
 }
 
-// Unmarshall decodes XML into a Go struct using Go's builtin xml.Unmarshal method.
+// Unmarshall decodes XML into a Go struct using Go's builtin xml.Decoder, reading directly from the request body.
 func (um *Unmarshaller) Unmarshall(ctx context.Context, req *http.Request, wsReq *ws.Request) error {
 	defer req.Body.Close()
 
-	var b bytes.Buffer
-	b.ReadFrom(req.Body)
-
-	err := xml.Unmarshal(b.Bytes(), &wsReq.RequestBody)
-
-	return err
+	return xml.NewDecoder(req.Body).Decode(&wsReq.RequestBody)
 }